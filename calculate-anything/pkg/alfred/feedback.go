@@ -21,6 +21,8 @@ type Result struct {
 	Arg       string
 	IconPath  string
 	Modifiers []Modifier
+	// Autocomplete 不为空时结果项不可执行，选中后 Alfred 会用它替换当前查询
+	Autocomplete string
 }
 
 // AddToWorkflow 将一组标准化的 Result 对象添加到 Alfred 的反馈列表中。
@@ -30,7 +32,11 @@ func AddToWorkflow(wf *aw.Workflow, results []Result) {
 		item := wf.NewItem(r.Title).
 			Subtitle(r.Subtitle).
 			Arg(r.Arg).
-			Valid(true)
+			Valid(r.Autocomplete == "")
+
+		if r.Autocomplete != "" {
+			item.Autocomplete(r.Autocomplete)
+		}
 
 		if r.IconPath != "" {
 			// 修正：使用正确的类型 aw.Icon
@@ -0,0 +1,14 @@
+// calculate-anything/pkg/api/cache.go
+package api
+
+import "time"
+
+// Cache 是 API 客户端读写缓存所需的最小接口。
+// *aw.Cache 满足该接口，因此调用方可以直接传入 wf.Cache，
+// 在 Alfred 之外使用时也可以换成其他实现。
+type Cache interface {
+	Exists(name string) bool
+	Expired(name string, maxAge time.Duration) bool
+	LoadJSON(name string, v interface{}) error
+	StoreJSON(name string, v interface{}) error
+}
@@ -4,100 +4,315 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
-
-	// 修正：根据官方文档，统一使用 aw 别名导入
-	aw "github.com/deanishe/awgo"
 )
 
-// 修正：移除了所有与 fixer.go 重复的声明
 const (
-	coinMarketCapAPIURL = "https://pro-api.coinmarketcap.com/v1/tools/price-conversion"
-	cryptoCacheKey      = "coinmarketcap_rates_%s_to_%s"
+	coinMarketCapListingsURL = "https://pro-api.coinmarketcap.com/v1/cryptocurrency/listings/latest"
+	coinMarketCapQuotesURL   = "https://pro-api.coinmarketcap.com/v2/cryptocurrency/quotes/latest"
+	cryptoPricesCacheKey     = "coinmarketcap_prices"
 )
 
-// CMCResponse 镜像 CoinMarketCap API 的 JSON 响应结构
-type CMCResponse struct {
-	Status struct {
-		Timestamp    string `json:"timestamp"`
-		ErrorCode    int    `json:"error_code"`
-		ErrorMessage string `json:"error_message"`
-		Elapsed      int    `json:"elapsed"`
-		CreditCount  int    `json:"credit_count"`
-	} `json:"status"`
-	Data struct {
-		ID          int     `json:"id"`
-		Symbol      string  `json:"symbol"`
-		Name        string  `json:"name"`
-		Amount      float64 `json:"amount"`
-		LastUpdated string  `json:"last_updated"`
-		Quote       map[string]struct {
-			Price       float64 `json:"price"`
-			LastUpdated string  `json:"last_updated"`
-		} `json:"quote"`
-	} `json:"data"`
+// CryptoQuote 是一个币种以 USD 计价的行情。
+type CryptoQuote struct {
+	Symbol           string  `json:"symbol"`
+	Name             string  `json:"name"`
+	Rank             int     `json:"rank"`
+	Price            float64 `json:"price"` // 1 个币值多少 USD
+	PercentChange1h  float64 `json:"percent_change_1h"`
+	PercentChange24h float64 `json:"percent_change_24h"`
+	PercentChange7d  float64 `json:"percent_change_7d"`
+	MarketCap        float64 `json:"market_cap"`
+	Volume24h        float64 `json:"volume_24h"`
+	LastUpdated      string  `json:"last_updated"`
+
+	// FiatPrices 是以配置的其他法币计价的价格，按大写代码索引, e.g. "EUR" -> 55000
+	FiatPrices map[string]float64 `json:"fiat_prices,omitempty"`
 }
 
-// GetCryptoConversion 获取加密货币到指定法币的转换率，优先使用缓存。
-func GetCryptoConversion(wf *aw.Workflow, apiKey string, amount float64, fromCrypto, toFiat string, cacheDuration time.Duration) (*CMCResponse, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("CoinMarketCap API 密钥未配置")
-	}
-
-	fromCrypto = strings.ToUpper(fromCrypto)
-	toFiat = strings.ToUpper(toFiat)
-	cacheKey := fmt.Sprintf(cryptoCacheKey, fromCrypto, toFiat)
-
-	// 修正：wf 的类型是 *aw.Workflow
-	if wf.Cache.Exists(cacheKey) && !wf.Cache.Expired(cacheKey, cacheDuration) {
-		var resp CMCResponse
-		if err := wf.Cache.LoadJSON(cacheKey, &resp); err == nil {
-			if cachedQuote, ok := resp.Data.Quote[toFiat]; ok {
-				resp.Data.Amount = amount
-				cachedQuote.Price *= amount
-				resp.Data.Quote[toFiat] = cachedQuote
-				return &resp, nil
+// CryptoPrices 是以 USD 计价的价格表。加密货币之间以及加密货币与法币之间的
+// 换算都在本地通过该表完成，不再为每一对货币单独请求。
+type CryptoPrices struct {
+	Timestamp int64                  `json:"timestamp"` // 价格表的更新时间 (Unix 秒)
+	Quotes    map[string]CryptoQuote `json:"quotes"`    // 按大写符号索引
+	Unknown   []string               `json:"unknown"`   // CoinMarketCap 查不到的符号，价格表刷新前不再请求
+	Stale     bool                   `json:"-"`         // 无法刷新时返回的过期缓存
+}
+
+// PriceUSD 返回 1 个 symbol 的 USD 价格。
+func (p *CryptoPrices) PriceUSD(symbol string) (float64, bool) {
+	quote, ok := p.Quotes[strings.ToUpper(symbol)]
+	if !ok || quote.Price == 0 {
+		return 0, false
+	}
+	return quote.Price, true
+}
+
+// Quote 返回 symbol 的完整行情。
+func (p *CryptoPrices) Quote(symbol string) (CryptoQuote, bool) {
+	quote, ok := p.Quotes[strings.ToUpper(symbol)]
+	return quote, ok
+}
+
+// FiatPriceUSD 返回 1 单位法币的 USD 价格，由排名最靠前、同时有 USD 和该法币报价的币种换算得到。
+// 只有请求价格表时配置过的法币才有报价。
+func (p *CryptoPrices) FiatPriceUSD(fiat string) (float64, bool) {
+	fiat = strings.ToUpper(fiat)
+	if fiat == "USD" {
+		return 1, true
+	}
+	var best CryptoQuote
+	found := false
+	for _, quote := range p.Quotes {
+		if quote.Price == 0 || quote.FiatPrices[fiat] == 0 {
+			continue
+		}
+		if !found || (quote.Rank > 0 && (best.Rank == 0 || quote.Rank < best.Rank)) {
+			best, found = quote, true
+		}
+	}
+	if !found {
+		return 0, false
+	}
+	return best.Price / best.FiatPrices[fiat], true
+}
+
+// cmcStatus 是 CoinMarketCap 所有响应共有的 status 字段
+type cmcStatus struct {
+	Timestamp    string `json:"timestamp"`
+	ErrorCode    int    `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// cmcListing 镜像 listings/latest 和 quotes/latest 中的一个币种
+type cmcListing struct {
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	Rank   int    `json:"cmc_rank"`
+	Quote  map[string]struct {
+		Price            float64 `json:"price"`
+		PercentChange1h  float64 `json:"percent_change_1h"`
+		PercentChange24h float64 `json:"percent_change_24h"`
+		PercentChange7d  float64 `json:"percent_change_7d"`
+		MarketCap        float64 `json:"market_cap"`
+		Volume24h        float64 `json:"volume_24h"`
+		LastUpdated      string  `json:"last_updated"`
+	} `json:"quote"`
+}
+
+// GetCryptoPrices 获取排名前 limit 的币种的价格表，整张表一次请求、一起缓存。
+// 价格以 USD 计价，fiats 中配置的法币 (e.g. 基准货币) 通过同一请求的 convert 参数一并获取。
+// symbols 中不在表内的币种 (排名靠后的小币) 会再用一次批量请求补充并合并进缓存，
+// CoinMarketCap 也查不到的符号记录在 Unknown 中，价格表刷新前不再请求。
+// 无法刷新时返回过期的缓存并标记为 Stale；补充请求失败时返回已有的价格表。
+func GetCryptoPrices(cache Cache, apiKey string, limit int, fiats, symbols []string, cacheDuration time.Duration) (*CryptoPrices, error) {
+	var cached *CryptoPrices
+	if cache.Exists(cryptoPricesCacheKey) {
+		var prices CryptoPrices
+		if err := cache.LoadJSON(cryptoPricesCacheKey, &prices); err == nil && len(prices.Quotes) > 0 {
+			cached = &prices
+		}
+	}
+
+	convert := cmcConvert(fiats)
+	prices := cached
+	if cached == nil || cache.Expired(cryptoPricesCacheKey, cacheDuration) {
+		fresh, err := fetchCryptoListings(apiKey, limit, convert)
+		if err != nil {
+			if cached == nil {
+				return nil, err
 			}
+			cached.Stale = true
+			return cached, nil
+		}
+		// 先缓存价格表，之后的补充请求失败也不会丢失。缓存不是关键路径，失败时忽略错误
+		_ = cache.StoreJSON(cryptoPricesCacheKey, fresh)
+		prices = fresh
+	}
+
+	var missing []string
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if _, ok := prices.Quotes[symbol]; !ok && !containsString(prices.Unknown, symbol) {
+			missing = append(missing, symbol)
 		}
 	}
+	if len(missing) == 0 {
+		return prices, nil
+	}
 
-	req, err := http.NewRequest("GET", coinMarketCapAPIURL, nil)
+	quotes, err := fetchCryptoQuotes(apiKey, missing, convert)
 	if err != nil {
+		// 旧缓存中可能有之前补充过的币种，刷新后的价格表里没有时仍可使用
+		if cached != nil && prices != cached {
+			for _, symbol := range missing {
+				if quote, ok := cached.Quotes[symbol]; ok {
+					prices.Quotes[symbol] = quote
+				}
+			}
+		}
+		return prices, nil
+	}
+	for _, symbol := range missing {
+		if quote, ok := quotes[symbol]; ok {
+			prices.Quotes[symbol] = quote
+		} else {
+			prices.Unknown = append(prices.Unknown, symbol)
+		}
+	}
+	_ = cache.StoreJSON(cryptoPricesCacheKey, prices)
+	return prices, nil
+}
+
+// cmcConvert 返回请求的 convert 参数：USD 以及去重后的其他法币代码。
+func cmcConvert(fiats []string) string {
+	codes := []string{"USD"}
+	for _, fiat := range fiats {
+		if fiat = strings.ToUpper(strings.TrimSpace(fiat)); fiat != "" && !containsString(codes, fiat) {
+			codes = append(codes, fiat)
+		}
+	}
+	return strings.Join(codes, ",")
+}
+
+// containsString 报告列表中是否有字符串 s。
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchCryptoListings 通过 listings/latest 一次获取排名前 limit 的币种价格，convert 为计价货币列表。
+func fetchCryptoListings(apiKey string, limit int, convert string) (*CryptoPrices, error) {
+	params := map[string]string{"limit": strconv.Itoa(limit), "convert": convert}
+	var body struct {
+		Status cmcStatus    `json:"status"`
+		Data   []cmcListing `json:"data"`
+	}
+	if err := cmcGet(apiKey, coinMarketCapListingsURL, params, &body); err != nil {
+		return nil, err
+	}
+	if body.Status.ErrorCode != 0 {
+		return nil, fmt.Errorf("API 错误: %s", body.Status.ErrorMessage)
+	}
+
+	prices := &CryptoPrices{
+		Timestamp: cmcTimestamp(body.Status.Timestamp),
+		Quotes:    make(map[string]CryptoQuote, len(body.Data)),
+	}
+	for _, listing := range body.Data {
+		symbol := strings.ToUpper(listing.Symbol)
+		// 符号冲突时 listings 按排名排序，保留第一个
+		if _, ok := prices.Quotes[symbol]; !ok {
+			prices.Quotes[symbol] = listing.toQuote()
+		}
+	}
+	if len(prices.Quotes) == 0 {
+		return nil, fmt.Errorf("加密货币价格数据为空")
+	}
+	return prices, nil
+}
+
+// fetchCryptoQuotes 通过 quotes/latest 一次获取多个指定币种的价格，convert 为计价货币列表。
+func fetchCryptoQuotes(apiKey string, symbols []string, convert string) (map[string]CryptoQuote, error) {
+	params := map[string]string{"symbol": strings.Join(symbols, ","), "convert": convert}
+	var body struct {
+		Status cmcStatus               `json:"status"`
+		Data   map[string][]cmcListing `json:"data"`
+	}
+	if err := cmcGet(apiKey, coinMarketCapQuotesURL, params, &body); err != nil {
 		return nil, err
 	}
+	if body.Status.ErrorCode != 0 {
+		return nil, fmt.Errorf("API 错误: %s", body.Status.ErrorMessage)
+	}
+
+	quotes := make(map[string]CryptoQuote, len(body.Data))
+	for symbol, listings := range body.Data {
+		best := -1
+		for i, listing := range listings {
+			if best < 0 || (listing.Rank > 0 && (listings[best].Rank == 0 || listing.Rank < listings[best].Rank)) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			quotes[strings.ToUpper(symbol)] = listings[best].toQuote()
+		}
+	}
+	return quotes, nil
+}
+
+// toQuote 取出 USD 报价，其他计价货币只保留价格。
+func (l cmcListing) toQuote() CryptoQuote {
+	usd := l.Quote["USD"]
+	var fiatPrices map[string]float64
+	for code, quote := range l.Quote {
+		if code != "USD" && quote.Price > 0 {
+			if fiatPrices == nil {
+				fiatPrices = map[string]float64{}
+			}
+			fiatPrices[strings.ToUpper(code)] = quote.Price
+		}
+	}
+	return CryptoQuote{
+		Symbol:           strings.ToUpper(l.Symbol),
+		Name:             l.Name,
+		Rank:             l.Rank,
+		Price:            usd.Price,
+		PercentChange1h:  usd.PercentChange1h,
+		PercentChange24h: usd.PercentChange24h,
+		PercentChange7d:  usd.PercentChange7d,
+		MarketCap:        usd.MarketCap,
+		Volume24h:        usd.Volume24h,
+		LastUpdated:      usd.LastUpdated,
+		FiatPrices:       fiatPrices,
+	}
+}
+
+// cmcGet 请求 CoinMarketCap 接口并解码 JSON 响应。
+func cmcGet(apiKey, endpoint string, params map[string]string, v interface{}) error {
+	if apiKey == "" {
+		return fmt.Errorf("CoinMarketCap API 密钥未配置")
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
 	q := req.URL.Query()
-	q.Add("amount", "1")
-	q.Add("symbol", fromCrypto)
-	q.Add("convert", toFiat)
+	for key, value := range params {
+		q.Add(key, value)
+	}
 	req.URL.RawQuery = q.Encode()
 	req.Header.Set("Accepts", "application/json")
 	req.Header.Set("X-CMC_PRO_API_KEY", apiKey)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("无法连接到 CoinMarketCap API: %w", err)
+		return fmt.Errorf("无法连接到 CoinMarketCap API: %w", err)
 	}
 	defer resp.Body.Close()
 
-	var apiResponse CMCResponse
-	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-		return nil, fmt.Errorf("解析 API 响应失败: %w", err)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 API 响应失败: %w", err)
 	}
-	if apiResponse.Status.ErrorCode != 0 {
-		return nil, fmt.Errorf("API 错误: %s", apiResponse.Status.ErrorMessage)
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("解析 API 响应失败: %w", err)
 	}
+	return nil
+}
 
-	// 缓存不是关键路径，失败时忽略错误
-	_ = wf.Cache.StoreJSON(cacheKey, apiResponse)
-
-	if baseQuote, ok := apiResponse.Data.Quote[toFiat]; ok {
-		apiResponse.Data.Amount = amount
-		baseQuote.Price *= amount
-		apiResponse.Data.Quote[toFiat] = baseQuote
+// cmcTimestamp 将 CoinMarketCap 的 RFC 3339 时间转换为 Unix 时间戳，无法解析时使用当前时间。
+func cmcTimestamp(s string) int64 {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Unix()
 	}
-
-	return &apiResponse, nil
+	return time.Now().Unix()
 }
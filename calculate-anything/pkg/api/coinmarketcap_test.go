@@ -0,0 +1,152 @@
+// calculate-anything/pkg/api/coinmarketcap_test.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memCache 是测试用的内存缓存，expired 决定缓存是否过期。
+type memCache struct {
+	data    map[string][]byte
+	expired bool
+}
+
+func (c *memCache) Exists(name string) bool                        { _, ok := c.data[name]; return ok }
+func (c *memCache) Expired(name string, maxAge time.Duration) bool { return c.expired }
+
+func (c *memCache) LoadJSON(name string, v interface{}) error {
+	return json.Unmarshal(c.data[name], v)
+}
+
+func (c *memCache) StoreJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.data[name] = data
+	return nil
+}
+
+// roundTripFunc 让测试直接响应 HTTP 请求，不访问网络。
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// stubCoinMarketCap 用 handler 替换 httpClient，返回值记录收到的请求。
+func stubCoinMarketCap(t *testing.T, handler func(req *http.Request) (string, error)) *[]*http.Request {
+	t.Helper()
+	var requests []*http.Request
+	original := httpClient
+	httpClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req)
+		body, err := handler(req)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})}
+	t.Cleanup(func() { httpClient = original })
+	return &requests
+}
+
+const (
+	testListings = `{"status":{"error_code":0},"data":[
+		{"symbol":"BTC","name":"Bitcoin","cmc_rank":1,"quote":{"USD":{"price":60000},"EUR":{"price":50000}}},
+		{"symbol":"ETH","name":"Ethereum","cmc_rank":2,"quote":{"USD":{"price":3000},"EUR":{"price":2500}}}]}`
+	testQuotes = `{"status":{"error_code":0},"data":{"XYZ":[{"symbol":"XYZ","name":"Xyz","cmc_rank":900,"quote":{"USD":{"price":2}}}]}}`
+)
+
+func TestGetCryptoPricesConvert(t *testing.T) {
+	requests := stubCoinMarketCap(t, func(req *http.Request) (string, error) { return testListings, nil })
+	cache := &memCache{data: map[string][]byte{}}
+
+	prices, err := GetCryptoPrices(cache, "key", 2, []string{"eur", "USD"}, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := (*requests)[0].URL.Query().Get("convert"); got != "USD,EUR" {
+		t.Errorf("convert = %q, 期望 USD,EUR", got)
+	}
+	if price, ok := prices.FiatPriceUSD("EUR"); !ok || price != 1.2 {
+		t.Errorf("FiatPriceUSD(EUR) = %g, %v, 期望 1.2", price, ok)
+	}
+	if _, ok := prices.FiatPriceUSD("GBP"); ok {
+		t.Errorf("未请求的法币不应有报价")
+	}
+}
+
+func TestGetCryptoPricesQuotesFailure(t *testing.T) {
+	stubCoinMarketCap(t, func(req *http.Request) (string, error) {
+		if strings.Contains(req.URL.Path, "listings") {
+			return testListings, nil
+		}
+		return "", fmt.Errorf("network down")
+	})
+	cache := &memCache{data: map[string][]byte{}}
+
+	prices, err := GetCryptoPrices(cache, "key", 2, nil, []string{"BTC", "XYZ"}, time.Hour)
+	if err != nil {
+		t.Fatalf("补充请求失败时应返回已有的价格表: %v", err)
+	}
+	if _, ok := prices.PriceUSD("BTC"); !ok {
+		t.Errorf("价格表中缺少 BTC")
+	}
+	if !cache.Exists(cryptoPricesCacheKey) {
+		t.Errorf("补充请求失败前应已缓存价格表")
+	}
+}
+
+func TestGetCryptoPricesStaleCacheKeepsExtraQuotes(t *testing.T) {
+	stubCoinMarketCap(t, func(req *http.Request) (string, error) {
+		if strings.Contains(req.URL.Path, "listings") {
+			return testListings, nil
+		}
+		return "", fmt.Errorf("network down")
+	})
+	cache := &memCache{data: map[string][]byte{}, expired: true}
+	_ = cache.StoreJSON(cryptoPricesCacheKey, CryptoPrices{Quotes: map[string]CryptoQuote{"XYZ": {Symbol: "XYZ", Price: 1.5}}})
+
+	prices, err := GetCryptoPrices(cache, "key", 2, nil, []string{"XYZ"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price, ok := prices.PriceUSD("XYZ"); !ok || price != 1.5 {
+		t.Errorf("XYZ 应使用旧缓存中的价格, 得到 %g, %v", price, ok)
+	}
+}
+
+func TestGetCryptoPricesUnknownSymbols(t *testing.T) {
+	requests := stubCoinMarketCap(t, func(req *http.Request) (string, error) {
+		if strings.Contains(req.URL.Path, "listings") {
+			return testListings, nil
+		}
+		return testQuotes, nil
+	})
+	cache := &memCache{data: map[string][]byte{}}
+
+	prices, err := GetCryptoPrices(cache, "key", 2, nil, []string{"XYZ", "NOPE"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if price, ok := prices.PriceUSD("XYZ"); !ok || price != 2 {
+		t.Errorf("PriceUSD(XYZ) = %g, %v", price, ok)
+	}
+	if len(prices.Unknown) != 1 || prices.Unknown[0] != "NOPE" {
+		t.Errorf("Unknown = %q, 期望 [NOPE]", prices.Unknown)
+	}
+
+	// 查不到的符号在价格表刷新前不再请求
+	n := len(*requests)
+	if _, err := GetCryptoPrices(cache, "key", 2, nil, []string{"NOPE"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != n {
+		t.Errorf("不应再次请求未知符号")
+	}
+}
@@ -0,0 +1,127 @@
+// calculate-anything/pkg/api/cryptomap.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	coinMarketCapMapURL = "https://pro-api.coinmarketcap.com/v1/cryptocurrency/map"
+	cryptoMapCacheKey   = "coinmarketcap_map"
+)
+
+// CryptoMapSnapshot 是随 workflow 打包的 /cryptocurrency/map 响应快照，
+// 在没有 API 密钥或离线且没有缓存时使用。
+var CryptoMapSnapshot = "data/crypto/map.json"
+
+// CryptoAsset 是 /cryptocurrency/map 中的一个币种。
+type CryptoAsset struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Symbol string `json:"symbol"`
+	Slug   string `json:"slug"`
+	Rank   int    `json:"rank"`
+}
+
+// cmcMapResponse 镜像 /cryptocurrency/map 的 JSON 响应结构
+type cmcMapResponse struct {
+	Status cmcStatus     `json:"status"`
+	Data   []CryptoAsset `json:"data"`
+}
+
+// CryptoMap 按大写符号索引的加密货币列表。
+// 多个币种共用同一符号时，保留排名最靠前的一个。
+type CryptoMap map[string]CryptoAsset
+
+// GetCryptoMap 获取加密货币符号表，缓存时长与加密货币汇率相同。
+// 缓存过期且无法刷新时依次退回到过期的缓存和打包的快照。
+func GetCryptoMap(cache Cache, apiKey string, cacheDuration time.Duration) (CryptoMap, error) {
+	var cached []CryptoAsset
+	hasCache := cache.Exists(cryptoMapCacheKey) && cache.LoadJSON(cryptoMapCacheKey, &cached) == nil && len(cached) > 0
+	if hasCache && !cache.Expired(cryptoMapCacheKey, cacheDuration) {
+		return newCryptoMap(cached), nil
+	}
+
+	var fetchErr error
+	if apiKey != "" {
+		assets, err := fetchCryptoMap(apiKey)
+		if err == nil {
+			// 缓存不是关键路径，失败时忽略错误
+			_ = cache.StoreJSON(cryptoMapCacheKey, assets)
+			return newCryptoMap(assets), nil
+		}
+		fetchErr = err
+	}
+
+	if hasCache {
+		return newCryptoMap(cached), nil
+	}
+
+	data, err := os.ReadFile(CryptoMapSnapshot)
+	if err != nil {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return nil, fmt.Errorf("无法读取加密货币列表快照: %w", err)
+	}
+	assets, err := parseCryptoMap(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", CryptoMapSnapshot, err)
+	}
+	return newCryptoMap(assets), nil
+}
+
+// fetchCryptoMap 从 CoinMarketCap 获取活跃币种列表，按排名排序。
+func fetchCryptoMap(apiKey string) ([]CryptoAsset, error) {
+	params := map[string]string{"listing_status": "active", "sort": "cmc_rank"}
+	var body cmcMapResponse
+	if err := cmcGet(apiKey, coinMarketCapMapURL, params, &body); err != nil {
+		return nil, err
+	}
+	return body.check()
+}
+
+// parseCryptoMap 解析 /cryptocurrency/map 格式的 JSON。
+func parseCryptoMap(data []byte) ([]CryptoAsset, error) {
+	var body cmcMapResponse
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("解析加密货币列表失败: %w", err)
+	}
+	return body.check()
+}
+
+// check 检查响应中的错误码并返回币种列表。
+func (body *cmcMapResponse) check() ([]CryptoAsset, error) {
+	if body.Status.ErrorCode != 0 {
+		return nil, fmt.Errorf("API 错误: %s", body.Status.ErrorMessage)
+	}
+	if len(body.Data) == 0 {
+		return nil, fmt.Errorf("加密货币列表为空")
+	}
+	return body.Data, nil
+}
+
+// newCryptoMap 按符号建立索引。
+func newCryptoMap(assets []CryptoAsset) CryptoMap {
+	m := make(CryptoMap, len(assets))
+	for _, asset := range assets {
+		symbol := strings.ToUpper(asset.Symbol)
+		if existing, ok := m[symbol]; ok && !ranksBefore(asset, existing) {
+			continue
+		}
+		m[symbol] = asset
+	}
+	return m
+}
+
+// ranksBefore 报告 a 的排名是否比 b 靠前，没有排名 (0) 的排在最后。
+func ranksBefore(a, b CryptoAsset) bool {
+	if a.Rank == 0 {
+		return false
+	}
+	return b.Rank == 0 || a.Rank < b.Rank
+}
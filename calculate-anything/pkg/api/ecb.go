@@ -0,0 +1,119 @@
+// calculate-anything/pkg/api/ecb.go
+package api
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 欧洲央行参考汇率 (eurofxref) 的地址，无需 API 密钥。
+// hist-90d 包含最近 90 天的汇率，hist 包含自 1999 年以来的全部汇率。
+const (
+	ECBDailyURL  = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	ECBHist90URL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml"
+	ECBHistURL   = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.xml"
+)
+
+// ECB 大约在欧洲中部时间 16:00 (UTC 15:00) 发布当天的汇率
+const ecbPublishHourUTC = 15
+
+// ecbEnvelope 镜像 eurofxref XML 的结构:
+// <Cube><Cube time="2024-01-05"><Cube currency="USD" rate="1.0921"/>...</Cube></Cube>
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// ECBProvider 从欧洲央行的 eurofxref XML 获取以 EUR 为基准的汇率。
+type ECBProvider struct {
+	URL string // 为空时使用 ECBDailyURL
+}
+
+// Name 返回提供者名称。
+func (p *ECBProvider) Name() string { return "ECB" }
+
+// Fetch 获取并解析 eurofxref XML，返回最新一天的汇率。
+func (p *ECBProvider) Fetch() (*Rates, error) {
+	url := p.URL
+	if url == "" {
+		url = ECBDailyURL
+	}
+	days, err := p.fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	return days[0], nil
+}
+
+// FetchDate 返回指定日期的历史汇率。ECB 在周末和节假日不发布汇率，
+// 此时返回该日期之前最近一个工作日的汇率。
+func (p *ECBProvider) FetchDate(date time.Time) (*Rates, error) {
+	url := ECBHistURL
+	if time.Since(date) < 85*24*time.Hour {
+		// 较近的日期使用小得多的 90 天文件
+		url = ECBHist90URL
+	}
+	days, err := p.fetch(url)
+	if err != nil {
+		return nil, err
+	}
+
+	want := date.Format("2006-01-02")
+	for _, day := range days {
+		// 文件中最新的日期在前，"YYYY-MM-DD" 可以直接按字符串比较
+		if day.Date <= want {
+			return day, nil
+		}
+	}
+	return nil, fmt.Errorf("ECB 没有 %s 或更早的汇率", want)
+}
+
+// fetch 获取并解析一个 eurofxref XML 文件。
+func (p *ECBProvider) fetch(url string) ([]*Rates, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接到 ECB: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ECB 返回了错误状态: %s", resp.Status)
+	}
+	return parseECB(resp.Body)
+}
+
+// parseECB 解析 eurofxref XML 中的所有日期，按文件中的顺序（最新在前）返回。
+func parseECB(r io.Reader) ([]*Rates, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("解析 ECB 汇率失败: %w", err)
+	}
+
+	var days []*Rates
+	for _, day := range envelope.Cube.Days {
+		rates := &Rates{
+			Base:      "EUR",
+			Date:      day.Time,
+			Timestamp: dateTimestamp(day.Time, ecbPublishHourUTC),
+			Source:    "ECB",
+			Rates:     make(map[string]float64, len(day.Rates)+1),
+		}
+		for _, r := range day.Rates {
+			rates.Rates[r.Currency] = r.Rate
+		}
+		days = append(days, withBase(rates))
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("ECB 汇率数据为空")
+	}
+	return days, nil
+}
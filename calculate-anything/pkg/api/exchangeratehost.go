@@ -0,0 +1,156 @@
+// calculate-anything/pkg/api/exchangeratehost.go
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// exchangerate.host 的最新汇率和历史汇率接口。历史接口中的 {date} 会被替换为 YYYY-MM-DD。
+const (
+	ExchangeRateHostURL           = "https://api.exchangerate.host/live"
+	ExchangeRateHostHistoricalURL = "https://api.exchangerate.host/historical?date={date}"
+)
+
+// ratesJSON 兼容 exchangerate.host 风格的两种 JSON 格式:
+//   - {"base": "EUR", "date": "2024-01-05", "rates": {"USD": 1.09}} (也是 fixer 和 frankfurter 的格式)
+//   - {"source": "USD", "timestamp": 1704470400, "quotes": {"USDEUR": 0.91}}
+type ratesJSON struct {
+	Success   *bool              `json:"success"`
+	Base      string             `json:"base"`
+	Source    string             `json:"source"`
+	Date      string             `json:"date"`
+	Timestamp int64              `json:"timestamp"`
+	Rates     map[string]float64 `json:"rates"`
+	Quotes    map[string]float64 `json:"quotes"`
+	Error     json.RawMessage    `json:"error"`
+}
+
+// ExchangeRateHostProvider 从 exchangerate.host 或兼容格式的接口获取汇率。
+type ExchangeRateHostProvider struct {
+	URL           string // 为空时使用 ExchangeRateHostURL
+	HistoricalURL string // 为空时使用 ExchangeRateHostHistoricalURL
+	APIKey        string // 作为 access_key 参数附加到请求中，可为空
+}
+
+// Name 返回提供者名称。
+func (p *ExchangeRateHostProvider) Name() string { return "exchangerate.host" }
+
+// Fetch 获取并解析 JSON 格式的最新汇率。
+func (p *ExchangeRateHostProvider) Fetch() (*Rates, error) {
+	endpoint := p.URL
+	if endpoint == "" {
+		endpoint = ExchangeRateHostURL
+	}
+	return p.fetch(endpoint)
+}
+
+// FetchDate 获取指定日期的历史汇率。
+func (p *ExchangeRateHostProvider) FetchDate(date time.Time) (*Rates, error) {
+	endpoint := p.HistoricalURL
+	if endpoint == "" {
+		endpoint = ExchangeRateHostHistoricalURL
+	}
+	day := date.Format("2006-01-02")
+	rates, err := p.fetch(strings.ReplaceAll(endpoint, "{date}", day))
+	if err != nil {
+		return nil, err
+	}
+	if rates.Date == "" {
+		rates.Date = day
+	}
+	return rates, nil
+}
+
+// fetch 请求 JSON 接口并解析汇率。
+func (p *ExchangeRateHostProvider) fetch(endpoint string) (*Rates, error) {
+	if p.APIKey != "" {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("无效的汇率接口地址: %s", endpoint)
+		}
+		q := u.Query()
+		q.Set("access_key", p.APIKey)
+		u.RawQuery = q.Encode()
+		endpoint = u.String()
+	}
+
+	resp, err := httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("无法连接到 %s: %w", p.Name(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s 返回了错误状态: %s", p.Name(), resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取 API 响应失败: %w", err)
+	}
+	rates, err := parseRatesJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	rates.Source = p.Name()
+	return rates, nil
+}
+
+// parseRatesJSON 解析 exchangerate.host 风格的 JSON 汇率数据。
+func parseRatesJSON(data []byte) (*Rates, error) {
+	var body ratesJSON
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("解析 API 响应失败: %w", err)
+	}
+	if body.Success != nil && !*body.Success {
+		return nil, fmt.Errorf("API 错误: %s", errorInfo(body.Error))
+	}
+
+	rates := &Rates{
+		Base:      body.Base,
+		Date:      body.Date,
+		Timestamp: body.Timestamp,
+		Rates:     body.Rates,
+	}
+	// "quotes" 的键是 "基准货币+目标货币", e.g. "USDEUR"
+	if len(rates.Rates) == 0 && len(body.Quotes) > 0 {
+		rates.Base = strings.ToUpper(body.Source)
+		rates.Rates = make(map[string]float64, len(body.Quotes))
+		for pair, rate := range body.Quotes {
+			rates.Rates[strings.TrimPrefix(strings.ToUpper(pair), rates.Base)] = rate
+		}
+	}
+	if len(rates.Rates) == 0 {
+		return nil, fmt.Errorf("汇率数据为空")
+	}
+	if rates.Timestamp == 0 {
+		rates.Timestamp = dateTimestamp(rates.Date, 0)
+	}
+	return withBase(rates), nil
+}
+
+// errorInfo 从 API 的 error 字段中提取可读的错误信息，该字段可能是字符串或对象。
+func errorInfo(raw json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil && text != "" {
+		return text
+	}
+	var obj struct {
+		Info    string `json:"info"`
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		for _, s := range []string{obj.Info, obj.Message, obj.Type} {
+			if s != "" {
+				return s
+			}
+		}
+	}
+	return "未知错误"
+}
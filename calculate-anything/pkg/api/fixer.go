@@ -4,17 +4,12 @@ package api
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
-	"strings"
 	"time"
-
-	// 修正：根据官方文档，统一使用 aw 别名导入
-	aw "github.com/deanishe/awgo"
 )
 
 const (
-	fixerAPIURL   = "http://data.fixer.io/api/latest?access_key=%s"
-	fixerCacheKey = "fixer_rates"
+	fixerAPIURL           = "http://data.fixer.io/api/latest?access_key=%s"
+	fixerHistoricalAPIURL = "http://data.fixer.io/api/%s?access_key=%s"
 )
 
 // FixerResponse 镜像 fixer.io API 的 JSON 响应结构
@@ -31,22 +26,31 @@ type FixerResponse struct {
 	} `json:"error"`
 }
 
-// GetExchangeRates 从 fixer.io 获取最新汇率，优先使用缓存。
-func GetExchangeRates(wf *aw.Workflow, apiKey string, cacheDuration time.Duration) (*FixerResponse, error) {
-	if apiKey == "" {
-		return nil, fmt.Errorf("Fixer.io API 密钥未配置")
-	}
+// FixerProvider 从 fixer.io 获取汇率，需要 API 密钥。
+type FixerProvider struct {
+	APIKey string
+}
+
+// Name 返回提供者名称。
+func (p *FixerProvider) Name() string { return "Fixer" }
+
+// Fetch 从 fixer.io 获取最新汇率。
+func (p *FixerProvider) Fetch() (*Rates, error) {
+	return p.fetch(fmt.Sprintf(fixerAPIURL, p.APIKey))
+}
+
+// FetchDate 从 fixer.io 获取指定日期的历史汇率。
+func (p *FixerProvider) FetchDate(date time.Time) (*Rates, error) {
+	return p.fetch(fmt.Sprintf(fixerHistoricalAPIURL, date.Format("2006-01-02"), p.APIKey))
+}
 
-	// 修正：wf 的类型是 *aw.Workflow
-	if wf.Cache.Exists(fixerCacheKey) && !wf.Cache.Expired(fixerCacheKey, cacheDuration) {
-		var rates FixerResponse
-		if err := wf.Cache.LoadJSON(fixerCacheKey, &rates); err == nil {
-			return &rates, nil
-		}
+// fetch 请求 fixer.io 的接口并转换为 Rates。
+func (p *FixerProvider) fetch(url string) (*Rates, error) {
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("Fixer.io API 密钥未配置")
 	}
 
-	url := fmt.Sprintf(fixerAPIURL, apiKey)
-	resp, err := http.Get(url)
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("无法连接到 Fixer.io API: %w", err)
 	}
@@ -60,29 +64,11 @@ func GetExchangeRates(wf *aw.Workflow, apiKey string, cacheDuration time.Duratio
 		return nil, fmt.Errorf("API 错误: %s", apiResponse.Error.Info)
 	}
 
-	// 缓存不是关键路径，失败时忽略错误
-	_ = wf.Cache.StoreJSON(fixerCacheKey, apiResponse)
-
-	return &apiResponse, nil
-}
-
-// ConvertCurrency 使用获取到的汇率数据进行货币转换。
-func ConvertCurrency(rates *FixerResponse, from, to string, amount float64) (float64, error) {
-	from = strings.ToUpper(from)
-	to = strings.ToUpper(to)
-	
-	fromRate, okFrom := rates.Rates[from]
-	toRate, okTo := rates.Rates[to]
-
-	if !okFrom {
-		return 0, fmt.Errorf("无效的源货币代码: %s", from)
-	}
-	if !okTo {
-		return 0, fmt.Errorf("无效的目标货币代码: %s", to)
-	}
-	if fromRate == 0 {
-		return 0, fmt.Errorf("源货币 '%s' 的汇率为零，无法计算", from)
-	}
-
-	return (amount / fromRate) * toRate, nil
+	return withBase(&Rates{
+		Base:      apiResponse.Base,
+		Date:      apiResponse.Date,
+		Timestamp: apiResponse.Timestamp,
+		Source:    p.Name(),
+		Rates:     apiResponse.Rates,
+	}), nil
 }
@@ -0,0 +1,175 @@
+// calculate-anything/pkg/api/rates.go
+package api
+
+import (
+	"calculate-anything/pkg/decimal"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	exchangeRatesCacheKey   = "exchange_rates"
+	historicalRatesCacheKey = "exchange_rates_%s" // 按日期缓存的历史汇率, e.g. "exchange_rates_2024-03-01"
+)
+
+// httpClient 是各汇率提供者共用的 HTTP 客户端。
+// 设置超时是为了在离线时尽快回退到下一个提供者或过期缓存。
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Rates 是与数据来源无关的汇率表: 1 Base = Rates[code] code。
+// 换算只依赖两种货币汇率的比值，因此不同提供者的基准货币可以不同。
+type Rates struct {
+	Base      string             `json:"base"`      // 基准货币 (e.g., "EUR")
+	Date      string             `json:"date"`      // 汇率日期 (e.g., "2024-01-05")
+	Timestamp int64              `json:"timestamp"` // 汇率的更新时间 (Unix 秒)
+	Source    string             `json:"source"`    // 提供者名称 (e.g., "ECB")
+	Rates     map[string]float64 `json:"rates"`
+	Stale     bool               `json:"-"` // 所有提供者都失败时返回的过期缓存
+}
+
+// Age 返回汇率距今的时间，未知时返回 0。
+func (r *Rates) Age() time.Duration {
+	if r.Timestamp == 0 {
+		return 0
+	}
+	return time.Since(time.Unix(r.Timestamp, 0))
+}
+
+// RateProvider 是一个汇率数据来源。
+type RateProvider interface {
+	// Name 返回提供者名称，用于错误信息和结果副标题。
+	Name() string
+	// Fetch 获取最新汇率。
+	Fetch() (*Rates, error)
+}
+
+// GetExchangeRates 按顺序尝试各提供者获取汇率，优先使用未过期的缓存。
+// 所有提供者都失败时，如果存在过期的缓存则返回它并标记为 Stale。
+func GetExchangeRates(cache Cache, providers []RateProvider, cacheDuration time.Duration) (*Rates, error) {
+	if cache.Exists(exchangeRatesCacheKey) && !cache.Expired(exchangeRatesCacheKey, cacheDuration) {
+		var rates Rates
+		if err := cache.LoadJSON(exchangeRatesCacheKey, &rates); err == nil && len(rates.Rates) > 0 {
+			return &rates, nil
+		}
+	}
+
+	var errs []string
+	for _, provider := range providers {
+		rates, err := provider.Fetch()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+		if rates.Source == "" {
+			rates.Source = provider.Name()
+		}
+		// 缓存不是关键路径，失败时忽略错误
+		_ = cache.StoreJSON(exchangeRatesCacheKey, rates)
+		return rates, nil
+	}
+
+	// 所有提供者都失败，退而使用过期的缓存
+	if cache.Exists(exchangeRatesCacheKey) {
+		var rates Rates
+		if err := cache.LoadJSON(exchangeRatesCacheKey, &rates); err == nil && len(rates.Rates) > 0 {
+			rates.Stale = true
+			return &rates, nil
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("未配置任何汇率提供者")
+	}
+	return nil, fmt.Errorf("无法获取汇率 (%s)", strings.Join(errs, "; "))
+}
+
+// ConvertCurrency 使用获取到的汇率数据进行货币转换。
+// 汇率按其最短十进制表示 (即数据源发布的数字) 参与运算，金额保持任意精度。
+func ConvertCurrency(rates *Rates, from, to string, amount decimal.Decimal) (decimal.Decimal, error) {
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+
+	fromRate, okFrom := rates.Rates[from]
+	toRate, okTo := rates.Rates[to]
+
+	if !okFrom {
+		return decimal.Decimal{}, fmt.Errorf("无效的源货币代码: %s", from)
+	}
+	if !okTo {
+		return decimal.Decimal{}, fmt.Errorf("无效的目标货币代码: %s", to)
+	}
+	if fromRate == 0 {
+		return decimal.Decimal{}, fmt.Errorf("源货币 '%s' 的汇率为零，无法计算", from)
+	}
+
+	return amount.Mul(decimal.NewFromFloat(toRate)).Quo(decimal.NewFromFloat(fromRate), decimal.DivisionPrecision)
+}
+
+// withBase 确保汇率表包含基准货币自身 (汇率为 1)。
+func withBase(rates *Rates) *Rates {
+	if rates.Base != "" {
+		rates.Base = strings.ToUpper(rates.Base)
+		if _, ok := rates.Rates[rates.Base]; !ok {
+			rates.Rates[rates.Base] = 1
+		}
+	}
+	return rates
+}
+
+// dateTimestamp 将 "YYYY-MM-DD" 加上发布时刻 (UTC 小时) 转换为 Unix 时间戳，无法解析时返回 0。
+func dateTimestamp(date string, hourUTC int) int64 {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return 0
+	}
+	return t.Add(time.Duration(hourUTC) * time.Hour).Unix()
+}
+
+// HistoricalRateProvider 是支持查询历史汇率的 RateProvider。
+type HistoricalRateProvider interface {
+	RateProvider
+	// FetchDate 获取指定日期的汇率。
+	FetchDate(date time.Time) (*Rates, error)
+}
+
+// GetHistoricalRates 获取指定日期的汇率。历史汇率不会再变化，
+// 因此按日期分别缓存且永不过期；只尝试支持历史查询的提供者。
+func GetHistoricalRates(cache Cache, providers []RateProvider, date time.Time) (*Rates, error) {
+	day := date.Format("2006-01-02")
+	if date.After(time.Now()) {
+		return nil, fmt.Errorf("无法查询未来日期 %s 的汇率", day)
+	}
+
+	cacheKey := fmt.Sprintf(historicalRatesCacheKey, day)
+	if cache.Exists(cacheKey) {
+		var rates Rates
+		if err := cache.LoadJSON(cacheKey, &rates); err == nil && len(rates.Rates) > 0 {
+			return &rates, nil
+		}
+	}
+
+	var errs []string
+	for _, provider := range providers {
+		historical, ok := provider.(HistoricalRateProvider)
+		if !ok {
+			continue
+		}
+		rates, err := historical.FetchDate(date)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+		if rates.Source == "" {
+			rates.Source = provider.Name()
+		}
+		_ = cache.StoreJSON(cacheKey, rates)
+		return rates, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("没有配置支持历史汇率的提供者 (fixer, ecb, exchangeratehost)")
+	}
+	return nil, fmt.Errorf("无法获取 %s 的汇率 (%s)", day, strings.Join(errs, "; "))
+}
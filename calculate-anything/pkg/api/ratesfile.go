@@ -0,0 +1,139 @@
+// calculate-anything/pkg/api/ratesfile.go
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileProvider 从用户提供的本地文件读取汇率，用于离线环境或自定义汇率。
+// 支持两种格式 (按扩展名区分):
+//   - .json: exchangerate.host 风格的 JSON，或简单的 {"USD": 1.09, "EUR": 1} 映射
+//   - .csv:  每行 "货币代码,汇率"，可以有表头，以 # 开头的行为注释
+//
+// 汇率的相对基准任意，文件中汇率为 1 的货币被视为基准货币。
+type FileProvider struct {
+	Path string
+}
+
+// Name 返回提供者名称。
+func (p *FileProvider) Name() string { return "file" }
+
+// Fetch 读取并解析本地汇率文件。
+func (p *FileProvider) Fetch() (*Rates, error) {
+	if p.Path == "" {
+		return nil, fmt.Errorf("未配置本地汇率文件")
+	}
+	path := expandHome(p.Path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取本地汇率文件: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取本地汇率文件: %w", err)
+	}
+
+	var rates *Rates
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		rates, err = parseRatesFileJSON(data)
+	case ".csv":
+		rates, err = parseRatesCSV(data)
+	default:
+		return nil, fmt.Errorf("不支持的汇率文件格式: %s (仅支持 .json 和 .csv)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+
+	rates.Source = filepath.Base(path)
+	// 文件没有记录时间时，以文件的修改时间作为汇率的更新时间
+	if rates.Timestamp == 0 {
+		rates.Timestamp = info.ModTime().Unix()
+	}
+	if rates.Date == "" {
+		rates.Date = info.ModTime().UTC().Format("2006-01-02")
+	}
+	return rates, nil
+}
+
+// parseRatesFileJSON 解析 JSON 汇率文件，先尝试 API 格式，再尝试简单映射。
+func parseRatesFileJSON(data []byte) (*Rates, error) {
+	if rates, err := parseRatesJSON(data); err == nil {
+		return rates, nil
+	}
+	var plain map[string]float64
+	if err := json.Unmarshal(data, &plain); err != nil {
+		return nil, fmt.Errorf("无法解析 JSON 汇率: %w", err)
+	}
+	return newFileRates(plain)
+}
+
+// parseRatesCSV 解析 "货币代码,汇率" 格式的 CSV 汇率文件。
+func parseRatesCSV(data []byte) (*Rates, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	values := make(map[string]float64)
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("无法解析 CSV 汇率: %w", err)
+		}
+		if len(record) < 2 {
+			continue
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			// 第一行允许是表头, e.g. "currency,rate"
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("第 %d 行的汇率无效: %s", line, record[1])
+		}
+		values[strings.TrimSpace(record[0])] = rate
+	}
+	return newFileRates(values)
+}
+
+// newFileRates 将货币代码到汇率的映射转换为 Rates，并推断基准货币。
+func newFileRates(values map[string]float64) (*Rates, error) {
+	rates := &Rates{Rates: make(map[string]float64, len(values))}
+	for code, rate := range values {
+		code = strings.ToUpper(code)
+		if rate <= 0 {
+			return nil, fmt.Errorf("货币 %s 的汇率必须为正数", code)
+		}
+		rates.Rates[code] = rate
+		if rate == 1 && (rates.Base == "" || code < rates.Base) {
+			rates.Base = code
+		}
+	}
+	if len(rates.Rates) == 0 {
+		return nil, fmt.Errorf("汇率数据为空")
+	}
+	return rates, nil
+}
+
+// expandHome 将路径开头的 "~" 展开为用户主目录。
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
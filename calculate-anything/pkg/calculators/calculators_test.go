@@ -0,0 +1,167 @@
+// calculate-anything/pkg/calculators/calculators_test.go
+package calculators
+
+import (
+	"calculate-anything/pkg/api"
+	"calculate-anything/pkg/config"
+	"calculate-anything/pkg/i18n"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memCache 是测试用的内存缓存，缓存内容永不过期，因此测试不会访问网络。
+type memCache struct {
+	data map[string][]byte
+}
+
+func (c *memCache) Exists(name string) bool                        { _, ok := c.data[name]; return ok }
+func (c *memCache) Expired(name string, maxAge time.Duration) bool { return false }
+
+func (c *memCache) LoadJSON(name string, v interface{}) error {
+	return json.Unmarshal(c.data[name], v)
+}
+
+func (c *memCache) StoreJSON(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	c.data[name] = data
+	return nil
+}
+
+// newTestContext 返回使用 en_US 语言包、固定汇率和加密货币价格的 Context。
+func newTestContext(t *testing.T) *Context {
+	t.Helper()
+	data, err := os.ReadFile("../../data/lang/en_US.json")
+	if err != nil {
+		t.Fatalf("读取语言包失败: %v", err)
+	}
+	var lang i18n.LanguagePack
+	if err := json.Unmarshal(data, &lang); err != nil {
+		t.Fatalf("解析语言包失败: %v", err)
+	}
+
+	now := time.Now().Unix()
+	cache := &memCache{data: map[string][]byte{}}
+	_ = cache.StoreJSON("exchange_rates", api.Rates{
+		Base: "EUR", Source: "ECB", Timestamp: now,
+		Rates: map[string]float64{"EUR": 1, "USD": 1.1, "GBP": 0.85, "JPY": 160},
+	})
+	_ = cache.StoreJSON("exchange_rates_2024-03-01", api.Rates{
+		Base: "EUR", Date: "2024-02-29", Source: "ECB",
+		Rates: map[string]float64{"EUR": 1, "USD": 1.08},
+	})
+	_ = cache.StoreJSON("coinmarketcap_map", []api.CryptoAsset{
+		{ID: 1, Name: "Bitcoin", Symbol: "BTC", Slug: "bitcoin", Rank: 1},
+		{ID: 1027, Name: "Ethereum", Symbol: "ETH", Slug: "ethereum", Rank: 2},
+	})
+	_ = cache.StoreJSON("coinmarketcap_prices", api.CryptoPrices{
+		Timestamp: now,
+		Quotes: map[string]api.CryptoQuote{
+			"BTC": {Symbol: "BTC", Name: "Bitcoin", Rank: 1, Price: 60000, PercentChange24h: -1.5, PercentChange7d: 4.2, MarketCap: 1.18e12, Volume24h: 3.5e10},
+			"ETH": {Symbol: "ETH", Name: "Ethereum", Rank: 2, Price: 3000},
+		},
+	})
+
+	return &Context{
+		Cache: cache,
+		Lang:  &lang,
+		Config: &config.AppConfig{
+			BaseCurrencies:   []string{"USD", "EUR"},
+			CurrencyDecimals: 2,
+			CryptoDecimals:   -1,
+			VATValue:         "16%",
+			PixelsBase:       "16px",
+			DateFormat:       "2006-01-02 15:04:05",
+			Timezone:         "Europe/Berlin",
+			WeekendDays:      []string{"sat", "sun"},
+		},
+	}
+}
+
+// queryTest 描述一条查询的期望结果。
+// title 是第一条结果的标题；err 不为空时期望计算失败且错误信息包含 err；
+// calculator 为空时表示没有任何计算器处理该查询。
+type queryTest struct {
+	query      string
+	calculator string
+	title      string
+	err        string
+}
+
+// checkQueries 通过 Dispatch 执行查询并与期望结果比较。
+func checkQueries(t *testing.T, tests []queryTest) {
+	t.Helper()
+	checkQueriesIn(t, newTestContext(t), tests)
+}
+
+// checkQueriesIn 与 checkQueries 相同，但使用调用者准备的 Context。
+func checkQueriesIn(t *testing.T, ctx *Context, tests []queryTest) {
+	t.Helper()
+	for _, tt := range tests {
+		out := Dispatch(ctx, tt.query)
+		if tt.calculator == "" {
+			if out != nil {
+				t.Errorf("%q: 期望没有结果, 得到 %s %+v %v", tt.query, out.Calculator, out.Results, out.Err)
+			}
+			continue
+		}
+		if out == nil {
+			t.Errorf("%q: 没有结果, 期望由 %s 处理", tt.query, tt.calculator)
+			continue
+		}
+		if out.Calculator != tt.calculator {
+			t.Errorf("%q: 由 %s 处理, 期望 %s", tt.query, out.Calculator, tt.calculator)
+			continue
+		}
+		if tt.err != "" {
+			if out.Err == nil || !strings.Contains(out.Err.Error(), tt.err) {
+				t.Errorf("%q: 错误为 %v, 期望包含 %q", tt.query, out.Err, tt.err)
+			}
+			continue
+		}
+		if out.Err != nil {
+			t.Errorf("%q: 意外的错误 %v", tt.query, out.Err)
+			continue
+		}
+		if len(out.Results) == 0 || out.Results[0].Title != tt.title {
+			t.Errorf("%q: 标题为 %q, 期望 %q", tt.query, firstTitle(out), tt.title)
+		}
+	}
+}
+
+// checkNoMatch 检查计算器不接受这些查询。
+func checkNoMatch(t *testing.T, c Calculator, queries ...string) {
+	t.Helper()
+	ctx := newTestContext(t)
+	for _, q := range queries {
+		if p := c.Match(ctx, q); p != nil {
+			t.Errorf("%s.Match(%q) = %+v, 期望 nil", c.Name(), q, p)
+		}
+	}
+}
+
+// resultTitles 返回计算器对查询的全部结果标题。
+func resultTitles(t *testing.T, ctx *Context, query string) []string {
+	t.Helper()
+	out := Dispatch(ctx, query)
+	if out == nil || out.Err != nil {
+		t.Fatalf("%q: 没有结果 (%v)", query, out)
+	}
+	titles := make([]string, len(out.Results))
+	for i, r := range out.Results {
+		titles[i] = r.Title
+	}
+	return titles
+}
+
+func firstTitle(out *Output) string {
+	if len(out.Results) == 0 {
+		return ""
+	}
+	return out.Results[0].Title
+}
@@ -0,0 +1,352 @@
+// calculate-anything/pkg/calculators/calendar.go
+package calculators
+
+import (
+	"bufio"
+	"bytes"
+	"calculate-anything/pkg/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 未配置 holidays_file 时，在 workflow 数据目录中依次查找这些文件
+var defaultHolidayFiles = []string{"holidays.ics", "holidays.json"}
+
+// businessCalendar 是工作日历: 周末和节假日之外的日子是工作日。
+type businessCalendar struct {
+	weekend  map[time.Weekday]bool
+	holidays map[string]string // "2006-01-02" -> 节假日名称
+	annual   map[string]string // "01-02" -> 每年重复的节假日名称
+
+	err  error // 读取节假日文件的错误，此时只跳过周末
+	used bool  // 是否有计算用到了工作日历
+}
+
+// loadBusinessCalendar 按配置的周末和节假日文件构建工作日历。
+// 节假日文件无法读取时仍返回只包含周末的日历，错误记录在 err 中。
+func loadBusinessCalendar(cfg *config.AppConfig, dataDir string) *businessCalendar {
+	cal := &businessCalendar{
+		weekend:  make(map[time.Weekday]bool),
+		holidays: make(map[string]string),
+		annual:   make(map[string]string),
+	}
+	for _, name := range cfg.WeekendDays {
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		wd, ok := weekdayPrefixes[name]
+		if !ok {
+			cal.err = fmt.Errorf("无效的周末设置: %s", name)
+			continue
+		}
+		cal.weekend[wd] = true
+	}
+	if len(cal.weekend) == 7 {
+		cal.err = fmt.Errorf("周末设置不能包含全部七天")
+		cal.weekend = map[time.Weekday]bool{time.Saturday: true, time.Sunday: true}
+	}
+
+	path := cfg.HolidaysFile
+	if path == "" {
+		for _, name := range defaultHolidayFiles {
+			if _, err := os.Stat(filepath.Join(dataDir, name)); err == nil {
+				path = name
+				break
+			}
+		}
+		if path == "" {
+			return cal
+		}
+	}
+	if err := cal.loadHolidays(resolveDataPath(dataDir, path)); err != nil && cal.err == nil {
+		cal.err = err
+	}
+	return cal
+}
+
+// resolveDataPath 展开 "~"，并将相对路径解析为相对于数据目录的路径。
+func resolveDataPath(dataDir, path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dataDir, path)
+}
+
+// loadHolidays 读取 iCalendar (.ics) 或 JSON 格式的节假日文件。
+func (c *businessCalendar) loadHolidays(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("无法读取节假日文件: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ics":
+		err = c.parseICS(data)
+	case ".json":
+		err = c.parseHolidaysJSON(data)
+	default:
+		return fmt.Errorf("不支持的节假日文件格式: %s (仅支持 .ics 和 .json)", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// parseICS 解析 iCalendar 文件中的 VEVENT。支持全天事件、跨多天的事件 (DTEND 不含当天)
+// 和 RRULE:FREQ=YEARLY 的每年重复事件，其他重复规则按单次事件处理。
+func (c *businessCalendar) parseICS(data []byte) error {
+	// 以空格或制表符开头的行是上一行的折叠续行
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\n "), nil)
+	data = bytes.ReplaceAll(data, []byte("\n\t"), nil)
+
+	var start, end time.Time
+	var summary string
+	var yearly, inEvent bool
+	events := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		// 去掉属性参数, e.g. "DTSTART;VALUE=DATE"
+		name, _, _ = strings.Cut(strings.ToUpper(name), ";")
+		value = strings.TrimSpace(value)
+
+		switch name {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, start, end, summary, yearly = true, time.Time{}, time.Time{}, "", false
+			}
+		case "DTSTART":
+			start = parseICSDate(value)
+		case "DTEND":
+			end = parseICSDate(value)
+		case "SUMMARY":
+			summary = strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\\`, `\`).Replace(value)
+		case "RRULE":
+			yearly = strings.Contains(strings.ToUpper(value), "FREQ=YEARLY")
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				c.addHoliday(d, summary, yearly)
+			}
+			events++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if events == 0 {
+		return fmt.Errorf("没有找到任何事件 (VEVENT)")
+	}
+	return nil
+}
+
+// parseICSDate 解析 iCalendar 的 DATE ("20250101") 或 DATE-TIME ("20250101T000000Z")，只保留日期。
+func parseICSDate(s string) time.Time {
+	if len(s) < 8 {
+		return time.Time{}
+	}
+	t, err := time.Parse("20060102", s[:8])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseHolidaysJSON 解析 JSON 节假日文件，支持两种格式:
+//   - {"2025-01-01": "元旦", "12-25": "圣诞节"}
+//   - [{"date": "2025-01-01", "name": "元旦"}, {"date": "12-25", "name": "圣诞节"}]
+//
+// "MM-DD" 形式的日期每年重复。
+func (c *businessCalendar) parseHolidaysJSON(data []byte) error {
+	entries := make(map[string]string)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		var list []struct {
+			Date string `json:"date"`
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(data, &list); err != nil {
+			return fmt.Errorf("无法解析 JSON 节假日: %w", err)
+		}
+		for _, e := range list {
+			entries[e.Date] = e.Name
+		}
+	}
+
+	for date, name := range entries {
+		if t, err := time.Parse("2006-01-02", date); err == nil {
+			c.addHoliday(t, name, false)
+		} else if t, err := time.Parse("01-02", date); err == nil {
+			c.addHoliday(t, name, true)
+		} else {
+			return fmt.Errorf("无效的节假日日期: %s", date)
+		}
+	}
+	return nil
+}
+
+// addHoliday 记录一个节假日，yearly 为 true 时每年的同一天都是节假日。
+func (c *businessCalendar) addHoliday(t time.Time, name string, yearly bool) {
+	if name == "" {
+		name = "节假日"
+	}
+	if yearly {
+		c.annual[t.Format("01-02")] = name
+	} else {
+		c.holidays[t.Format("2006-01-02")] = name
+	}
+}
+
+// holiday 返回 t 当天的节假日名称。
+func (c *businessCalendar) holiday(t time.Time) (string, bool) {
+	if name, ok := c.holidays[t.Format("2006-01-02")]; ok {
+		return name, true
+	}
+	name, ok := c.annual[t.Format("01-02")]
+	return name, ok
+}
+
+// isWorkday 报告 t 当天是否是工作日。
+func (c *businessCalendar) isWorkday(t time.Time) bool {
+	if c.weekend[t.Weekday()] {
+		return false
+	}
+	_, ok := c.holiday(t)
+	return !ok
+}
+
+// 工作日计算的上限: 偏移的工作日数和涉及的日历范围，避免超大的偏移或
+// 覆盖全部工作日的节假日让计算耗时过长或无法结束
+const (
+	maxWorkdayOffset = 100000
+	maxWorkdaySpan   = 146100 // 约 400 年
+)
+
+// errWorkdayRange 表示工作日计算超出上述上限
+var errWorkdayRange = errors.New("工作日计算超出范围")
+
+// addWorkdays 从 t 开始向前 (n > 0) 或向后 (n < 0) 数 n 个工作日，保留时刻。
+// 剩余的工作日多于一周时整周跳过，只逐日计算最后不足一周的部分。
+func (c *businessCalendar) addWorkdays(t time.Time, n int) (time.Time, error) {
+	c.used = true
+	if n > maxWorkdayOffset || n < -maxWorkdayOffset {
+		return time.Time{}, fmt.Errorf("%w: 偏移不能超过 %d 个工作日", errWorkdayRange, maxWorkdayOffset)
+	}
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	start := t
+	perWeek := 7 - len(c.weekend)
+	for n > 0 {
+		if span := calendarDays(start, t); span > maxWorkdaySpan || span < -maxWorkdaySpan {
+			return time.Time{}, fmt.Errorf("%w: %d 天内没有足够的工作日", errWorkdayRange, maxWorkdaySpan)
+		}
+		if weeks := (n - 1) / perWeek; weeks > 0 {
+			next := t.AddDate(0, 0, 7*weeks*step)
+			from, to := t.AddDate(0, 0, step), next
+			if step < 0 {
+				from, to = to, from
+			}
+			count, _ := c.countWorkdays(from, to)
+			n -= count
+			t = next
+			continue
+		}
+		t = t.AddDate(0, 0, step)
+		if c.isWorkday(t) {
+			n--
+		}
+	}
+	return t, nil
+}
+
+// workdaysBetween 统计 from 到 to 之间 (含首尾两天) 的工作日，并返回其间落在工作日的节假日。
+func (c *businessCalendar) workdaysBetween(from, to time.Time) (int, []string, error) {
+	c.used = true
+	if to.Before(from) {
+		from, to = to, from
+	}
+	if calendarDays(from, to) > maxWorkdaySpan {
+		return 0, nil, fmt.Errorf("%w: 统计的范围不能超过 %d 天", errWorkdayRange, maxWorkdaySpan)
+	}
+	count, dates := c.countWorkdays(from, to)
+	skipped := make([]string, len(dates))
+	for i, d := range dates {
+		name, _ := c.holiday(d)
+		skipped[i] = fmt.Sprintf("%s %s", d.Format("01-02"), name)
+	}
+	return count, skipped, nil
+}
+
+// countWorkdays 统计 from 到 to (from <= to，含首尾两天) 的工作日: 整周按每周的工作日数计算，
+// 再减去其间落在工作日的节假日。同时按日期顺序返回这些节假日。
+func (c *businessCalendar) countWorkdays(from, to time.Time) (int, []time.Time) {
+	from, to = startOfDay(from), startOfDay(to)
+	days := calendarDays(from, to) + 1
+	count := days / 7 * (7 - len(c.weekend))
+	for d := from.AddDate(0, 0, days/7*7); !d.After(to); d = d.AddDate(0, 0, 1) {
+		if !c.weekend[d.Weekday()] {
+			count++
+		}
+	}
+	holidays := c.holidaysIn(from, to)
+	return count - len(holidays), holidays
+}
+
+// holidaysIn 按日期顺序返回 from 到 to (含首尾两天) 之间不在周末的节假日。
+func (c *businessCalendar) holidaysIn(from, to time.Time) []time.Time {
+	first, last := from.Format("2006-01-02"), to.Format("2006-01-02")
+	seen := make(map[string]bool)
+	var dates []time.Time
+	add := func(d time.Time) {
+		key := d.Format("2006-01-02")
+		if key < first || key > last || seen[key] || c.weekend[d.Weekday()] {
+			return
+		}
+		seen[key] = true
+		dates = append(dates, d)
+	}
+	for key := range c.holidays {
+		if d, err := time.ParseInLocation("2006-01-02", key, from.Location()); err == nil {
+			add(d)
+		}
+	}
+	for key := range c.annual {
+		md, err := time.Parse("01-02", key)
+		if err != nil {
+			continue
+		}
+		for year := from.Year(); year <= to.Year(); year++ {
+			// 跳过非闰年的 02-29
+			if d := time.Date(year, md.Month(), md.Day(), 0, 0, 0, 0, from.Location()); d.Day() == md.Day() {
+				add(d)
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
@@ -2,109 +2,172 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
+	"calculate-anything/pkg/parser"
 	"fmt"
-	aw "github.com/deanishe/awgo"
 	"math"
-	"strconv"
+	"regexp"
 	"strings"
 )
 
-// HandleColor 解析颜色代码（HEX, RGB）并提供不同格式的转换结果。
-func HandleColor(wf *aw.Workflow, query string) {
+var (
+	// 两个颜色的对比度, e.g. "contrast #777 on white", "#333 vs #fff"
+	colorContrastRegex = regexp.MustCompile(`(?i)^(?:contrast\s+)?(.+?)\s+(?:on|vs\.?|versus|against|and)\s+(.+)$`)
+	// 一个颜色的浅色和深色, e.g. "#ff6347 tints", "tomato palette"
+	colorPaletteRegex = regexp.MustCompile(`(?i)^(.+?)\s+(tints|shades|palette)$`)
+)
+
+// colorPaletteSteps 是生成浅色和深色时与白色或黑色混合的比例
+var colorPaletteSteps = []float64{0.2, 0.4, 0.6, 0.8}
+
+// colorCalculator 解析颜色代码并提供不同格式的转换结果，
+// 还可以计算两个颜色的 WCAG 对比度，以及生成浅色 (tints) 和深色 (shades)。
+type colorCalculator struct{ baseCalculator }
+
+func init() { Register(&colorCalculator{}) }
+
+func (*colorCalculator) Name() string  { return "color" }
+func (*colorCalculator) Priority() int { return 5 }
+
+func (*colorCalculator) Examples() []string {
+	return []string{"#FF6347", "rgb(255, 99, 71)", "hsl(9, 100%, 64%)", "tomato", "contrast #777 on white", "#ff6347 palette"}
+}
+
+// Match 接受能解析的颜色、对比度和调色板查询。以 "#" 或 "rgb(" 开头的查询
+// 即使无法解析也属于颜色计算器，与之前的行为一致。
+func (*colorCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
 	query = strings.TrimSpace(query)
-	var r, g, b uint8 // 使用 uint8 (0-255) 来存储颜色分量
-
-	// 尝试解析 HEX 格式, e.g., "#FF6347" or "#F63"
-	if strings.HasPrefix(query, "#") {
-		hex := strings.TrimPrefix(query, "#")
-		// 将三位简写格式扩展为六位
-		if len(hex) == 3 {
-			hex = string(hex[0]) + string(hex[0]) + string(hex[1]) + string(hex[1]) + string(hex[2]) + string(hex[2])
-		}
-		// 解析六位 HEX
-		if len(hex) == 6 {
-			val, err := strconv.ParseUint(hex, 16, 32)
-			if err != nil {
-				return // 无效的 HEX 格式，静默失败
-			}
-			r = uint8(val >> 16)
-			g = uint8(val >> 8)
-			b = uint8(val)
-		} else {
-			return
+	if m := colorContrastRegex.FindStringSubmatch(query); m != nil {
+		_, ok1 := parseColor(m[1])
+		_, ok2 := parseColor(m[2])
+		if ok1 && ok2 {
+			return &parser.ParsedQuery{Type: parser.ColorQuery, Input: query, Action: "contrast", From: m[1], To: m[2]}
 		}
-	} else {
-		// 尝试解析 RGB 格式, e.g., "rgb(255, 99, 71)"
-		var rInt, gInt, bInt int
-		_, err := fmt.Sscanf(strings.ToLower(query), "rgb(%d,%d,%d)", &rInt, &gInt, &bInt)
-		if err != nil {
-			return // 不是有效的 RGB 格式
+	}
+	if m := colorPaletteRegex.FindStringSubmatch(query); m != nil {
+		if _, ok := parseColor(m[1]); ok {
+			return &parser.ParsedQuery{Type: parser.ColorQuery, Input: query, Action: strings.ToLower(m[2]), From: m[1]}
 		}
-		// 验证 RGB 值范围
-		if rInt < 0 || rInt > 255 || gInt < 0 || gInt > 255 || bInt < 0 || bInt > 255 {
-			return
+	}
+
+	lower := strings.ToLower(query)
+	if _, ok := parseColor(query); ok || strings.HasPrefix(lower, "#") || strings.HasPrefix(lower, "rgb(") {
+		return &parser.ParsedQuery{Type: parser.ColorQuery, Input: query, From: query}
+	}
+	return nil
+}
+
+// Compute 根据查询类型生成颜色格式、对比度或调色板。无效的颜色代码静默失败。
+func (*colorCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	color, ok := parseColor(p.From)
+	if !ok {
+		return nil, nil
+	}
+
+	switch p.Action {
+	case "contrast":
+		background, ok := parseColor(p.To)
+		if !ok {
+			return nil, nil
 		}
-		r, g, b = uint8(rInt), uint8(gInt), uint8(bInt)
+		return colorContrastResults(color, background), nil
+	case "tints", "shades", "palette":
+		return colorPaletteResults(color, p.Action), nil
+	}
+	return colorFormatResults(color), nil
+}
+
+// colorFormatResults 给出颜色的各种写法: CSS 格式、印刷四色以及 Swift 和 Android 代码。
+func colorFormatResults(c rgbaColor) []Result {
+	hexSubtitle := "复制 HEX 值"
+	if name := colorName(c); name != "" {
+		hexSubtitle = fmt.Sprintf("CSS 名称: %s · 复制 HEX 值", name)
 	}
+	android, signed := c.androidColor()
 
-	// 生成不同格式的结果
-	hexValue := fmt.Sprintf("#%02X%02X%02X", r, g, b)
-	rgbValue := fmt.Sprintf("rgb(%d, %d, %d)", r, g, b)
-	hslValue := toHSL(r, g, b)
+	rows := []struct{ value, subtitle string }{
+		{c.hex(), hexSubtitle},
+		{c.rgbString(), "复制 RGB 值"},
+		{c.hslString(), "复制 HSL 值"},
+		{c.hsvString(), "复制 HSV/HSB 值"},
+		{c.cmykString(), "复制 CMYK 值 (未经色彩管理)"},
+		{c.oklchString(), "复制 OKLCH 值"},
+		{c.uiColorString(), "复制 Swift UIColor"},
+		{c.nsColorString(), "复制 Swift NSColor"},
+		{android, fmt.Sprintf("Android 颜色整数 (ARGB, %d) · 复制", signed)},
+	}
+	results := make([]Result, len(rows))
+	for i, row := range rows {
+		results[i] = Result{Title: row.value, Subtitle: row.subtitle, Arg: row.value}
+	}
+	return results
+}
+
+// colorContrastResults 计算前景色在背景色上的 WCAG 2 对比度，半透明的前景色先叠加到背景上。
+func colorContrastResults(fg, bg rgbaColor) []Result {
+	bg.A = 1
+	if fg.A < 1 {
+		fg = fg.over(bg)
+	}
+	// 判断是否达标时向下取整，避免 4.499 被显示为 4.5 并判为通过
+	ratio := math.Floor(contrastRatio(fg, bg)*100) / 100
+	ratioText := fmt.Sprintf("%.2f:1", ratio)
+	pass := func(min float64) string {
+		if ratio >= min {
+			return "✓"
+		}
+		return "✗"
+	}
 
-	results := []alfred.Result{
+	return []Result{
 		{
-			Title:    hexValue,
-			Subtitle: "复制 HEX 值",
-			Arg:      hexValue,
+			Value:    ratio,
+			Title:    fmt.Sprintf("对比度 %s", ratioText),
+			Subtitle: fmt.Sprintf("%s 在 %s 上 · AA %s · AAA %s · 复制对比度", fg.hex(), bg.hex(), pass(4.5), pass(7)),
+			Arg:      ratioText,
 		},
 		{
-			Title:    rgbValue,
-			Subtitle: "复制 RGB 值",
-			Arg:      rgbValue,
+			Value:    ratio,
+			Title:    fmt.Sprintf("WCAG AA: 普通文本 %s (≥ 4.5) · 大号文本 %s (≥ 3)", pass(4.5), pass(3)),
+			Subtitle: "大号文本指 18pt 以上，或 14pt 以上的粗体",
+			Arg:      ratioText,
 		},
 		{
-			Title:    hslValue,
-			Subtitle: "复制 HSL 值",
-			Arg:      hslValue,
+			Value:    ratio,
+			Title:    fmt.Sprintf("WCAG AAA: 普通文本 %s (≥ 7) · 大号文本 %s (≥ 4.5)", pass(7), pass(4.5)),
+			Subtitle: "增强级别",
+			Arg:      ratioText,
+		},
+		{
+			Value:    ratio,
+			Title:    fmt.Sprintf("界面组件和图形: %s (≥ 3)", pass(3)),
+			Subtitle: "WCAG 1.4.11 非文本对比度",
+			Arg:      ratioText,
 		},
 	}
-
-	alfred.AddToWorkflow(wf, results)
 }
 
-// toHSL 将 RGB 转换为 HSL 字符串 (标准的转换算法)
-func toHSL(r, g, b uint8) string {
-	R := float64(r) / 255
-	G := float64(g) / 255
-	B := float64(b) / 255
-
-	max := math.Max(math.Max(R, G), B)
-	min := math.Min(math.Min(R, G), B)
-
-	h, s, l := 0.0, 0.0, (max+min)/2
+// colorPaletteResults 生成与白色混合的浅色和与黑色混合的深色，kind 为 "tints"、"shades" 或 "palette"。
+func colorPaletteResults(c rgbaColor, kind string) []Result {
+	white := rgbaColor{R: 255, G: 255, B: 255, A: 1}
+	black := rgbaColor{A: 1}
+	row := func(color rgbaColor, label string) Result {
+		return Result{
+			Title:    fmt.Sprintf("%s: %s", label, color.hex()),
+			Subtitle: fmt.Sprintf("%s · 复制 HEX 值", color.rgbString()),
+			Arg:      color.hex(),
+		}
+	}
 
-	if max != min {
-		d := max - min
-		if l > 0.5 {
-			s = d / (2 - max - min)
-		} else {
-			s = d / (max + min)
+	results := []Result{row(c, "原色")}
+	if kind != "shades" {
+		for _, t := range colorPaletteSteps {
+			results = append(results, row(mixColors(c, white, t), fmt.Sprintf("浅色 %.0f%%", t*100)))
 		}
-		switch max {
-		case R:
-			h = (G - B) / d
-			if G < B {
-				h += 6
-			}
-		case G:
-			h = (B-R)/d + 2
-		case B:
-			h = (R-G)/d + 4
+	}
+	if kind != "tints" {
+		for _, t := range colorPaletteSteps {
+			results = append(results, row(mixColors(c, black, t), fmt.Sprintf("深色 %.0f%%", t*100)))
 		}
-		h /= 6
 	}
-
-	return fmt.Sprintf("hsl(%.0f, %.0f%%, %.0f%%)", h*360, s*100, l*100)
+	return results
 }
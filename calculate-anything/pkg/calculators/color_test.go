@@ -0,0 +1,14 @@
+// calculate-anything/pkg/calculators/color_test.go
+package calculators
+
+import "testing"
+
+func TestColor(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "#FF6347", calculator: "color", title: "#FF6347"},
+		{query: "rgb(255, 99, 71)", calculator: "color", title: "#FF6347"},
+		{query: "hsl(9, 100%, 64%)", calculator: "color", title: "#FF6347"},
+		{query: "tomato", calculator: "color", title: "#FF6347"},
+		{query: "contrast #777 on white", calculator: "color", title: "对比度 4.47:1"},
+	})
+}
@@ -0,0 +1,404 @@
+// calculate-anything/pkg/calculators/colormodel.go
+package calculators
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// rgbaColor 是一个 sRGB 颜色，A 为不透明度 (0-1)。
+type rgbaColor struct {
+	R, G, B uint8
+	A       float64
+}
+
+var (
+	// CSS 函数写法, e.g. "rgb(255, 99, 71)", "hsl(9 100% 64% / 50%)", "cmyk(0%, 61%, 72%, 0%)"
+	colorFuncRegex = regexp.MustCompile(`(?i)^(rgba?|hsla?|hsv|hsb|cmyk)\(\s*(.*?)\s*\)$`)
+	// 函数参数之间可以用逗号、空格或 "/" (alpha) 分隔
+	colorArgSeparator = regexp.MustCompile(`\s*[,/]\s*|\s+`)
+)
+
+// parseColor 解析 HEX (#rgb, #rgba, #rrggbb, #rrggbbaa)、CSS 函数写法和 CSS 颜色名称。
+func parseColor(s string) (rgbaColor, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if strings.HasPrefix(s, "#") {
+		return parseHexColor(s[1:])
+	}
+	if m := colorFuncRegex.FindStringSubmatch(s); m != nil {
+		return parseColorFunc(m[1], m[2])
+	}
+	if v, ok := cssColorNames[s]; ok {
+		return rgbaColor{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 1}, true
+	}
+	return rgbaColor{}, false
+}
+
+// parseHexColor 解析不带 "#" 的 3、4、6 或 8 位 HEX，后两种简写会展开。
+func parseHexColor(hex string) (rgbaColor, bool) {
+	if len(hex) == 3 || len(hex) == 4 {
+		var b strings.Builder
+		for _, c := range hex {
+			b.WriteRune(c)
+			b.WriteRune(c)
+		}
+		hex = b.String()
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return rgbaColor{}, false
+	}
+	val, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return rgbaColor{}, false
+	}
+	return rgbaColor{R: uint8(val >> 24), G: uint8(val >> 16), B: uint8(val >> 8), A: float64(uint8(val)) / 255}, true
+}
+
+// parseColorFunc 解析 rgb()/rgba()、hsl()/hsla()、hsv()/hsb() 和 cmyk() 的参数。
+func parseColorFunc(name, args string) (rgbaColor, bool) {
+	var parts []string
+	for _, part := range colorArgSeparator.Split(args, -1) {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	color := rgbaColor{A: 1}
+	var ok bool
+	switch name {
+	case "rgb", "rgba":
+		if len(parts) != 3 && len(parts) != 4 {
+			return rgbaColor{}, false
+		}
+		channels := make([]uint8, 3)
+		for i := range channels {
+			if channels[i], ok = parseColorChannel(parts[i]); !ok {
+				return rgbaColor{}, false
+			}
+		}
+		color.R, color.G, color.B = channels[0], channels[1], channels[2]
+	case "hsl", "hsla", "hsv", "hsb":
+		if len(parts) != 3 && len(parts) != 4 {
+			return rgbaColor{}, false
+		}
+		h, ok1 := parseColorHue(parts[0])
+		s, ok2 := parseColorPercent(parts[1])
+		lv, ok3 := parseColorPercent(parts[2])
+		if !ok1 || !ok2 || !ok3 {
+			return rgbaColor{}, false
+		}
+		if strings.HasPrefix(name, "hsl") {
+			color.R, color.G, color.B = hslToRGB(h, s, lv)
+		} else {
+			color.R, color.G, color.B = hsvToRGB(h, s, lv)
+		}
+	case "cmyk":
+		if len(parts) != 4 {
+			return rgbaColor{}, false
+		}
+		v := make([]float64, 4)
+		for i := range v {
+			if v[i], ok = parseColorPercent(parts[i]); !ok {
+				return rgbaColor{}, false
+			}
+		}
+		k := 1 - v[3]
+		color.R = uint8(math.Round(255 * (1 - v[0]) * k))
+		color.G = uint8(math.Round(255 * (1 - v[1]) * k))
+		color.B = uint8(math.Round(255 * (1 - v[2]) * k))
+		return color, true
+	}
+
+	if len(parts) == 4 {
+		if color.A, ok = parseColorAlpha(parts[3]); !ok {
+			return rgbaColor{}, false
+		}
+	}
+	return color, true
+}
+
+// parseColorChannel 解析 0-255 的颜色分量或百分比。
+func parseColorChannel(s string) (uint8, bool) {
+	if strings.HasSuffix(s, "%") {
+		p, ok := parseColorPercent(s)
+		return uint8(math.Round(p * 255)), ok
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || v > 255 {
+		return 0, false
+	}
+	return uint8(math.Round(v)), true
+}
+
+// parseColorPercent 解析 0-100 的百分比 ("%" 可省略)，返回 0-1。
+func parseColorPercent(s string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || v < 0 || v > 100 {
+		return 0, false
+	}
+	return v / 100, true
+}
+
+// parseColorAlpha 解析 0-1 的不透明度或百分比。
+func parseColorAlpha(s string) (float64, bool) {
+	if strings.HasSuffix(s, "%") {
+		return parseColorPercent(s)
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || v > 1 {
+		return 0, false
+	}
+	return v, true
+}
+
+// parseColorHue 解析色相角度 ("deg" 或 "°" 可省略)，返回 [0, 360)。
+func parseColorHue(s string) (float64, bool) {
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "deg"), "°")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return math.Mod(math.Mod(v, 360)+360, 360), true
+}
+
+// hslToRGB 将 HSL (h 为角度，s 和 l 为 0-1) 转换为 RGB。
+func hslToRGB(h, s, l float64) (uint8, uint8, uint8) {
+	c := (1 - math.Abs(2*l-1)) * s
+	return chromaToRGB(h, c, l-c/2)
+}
+
+// hsvToRGB 将 HSV/HSB (h 为角度，s 和 v 为 0-1) 转换为 RGB。
+func hsvToRGB(h, s, v float64) (uint8, uint8, uint8) {
+	c := v * s
+	return chromaToRGB(h, c, v-c)
+}
+
+// chromaToRGB 是 HSL 和 HSV 转换共用的最后一步: 按色相分配色度 c，再加上 m。
+func chromaToRGB(h, c, m float64) (uint8, uint8, uint8) {
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = c, x
+	case h < 120:
+		r, g = x, c
+	case h < 180:
+		g, b = c, x
+	case h < 240:
+		g, b = x, c
+	case h < 300:
+		r, b = x, c
+	default:
+		r, b = c, x
+	}
+	return uint8(math.Round((r + m) * 255)), uint8(math.Round((g + m) * 255)), uint8(math.Round((b + m) * 255))
+}
+
+// hsl 返回色相 (角度)、饱和度和亮度 (0-1)。
+func (c rgbaColor) hsl() (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(math.Max(r, g), b)
+	min := math.Min(math.Min(r, g), b)
+	h, l = c.hue(), (max+min)/2
+	if max != min {
+		d := max - min
+		if l > 0.5 {
+			s = d / (2 - max - min)
+		} else {
+			s = d / (max + min)
+		}
+	}
+	return h, s, l
+}
+
+// hsv 返回色相 (角度)、饱和度和明度 (0-1)。
+func (c rgbaColor) hsv() (h, s, v float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(math.Max(r, g), b)
+	min := math.Min(math.Min(r, g), b)
+	if max > 0 {
+		s = (max - min) / max
+	}
+	return c.hue(), s, max
+}
+
+// hue 返回 HSL 和 HSV 共用的色相角度。
+func (c rgbaColor) hue() float64 {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	max := math.Max(math.Max(r, g), b)
+	min := math.Min(math.Min(r, g), b)
+	if max == min {
+		return 0
+	}
+	d := max - min
+	var h float64
+	switch max {
+	case r:
+		h = (g - b) / d
+		if g < b {
+			h += 6
+		}
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60
+}
+
+// cmyk 返回印刷四色 (0-1)。
+func (c rgbaColor) cmyk() (cy, m, y, k float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	k = 1 - math.Max(math.Max(r, g), b)
+	if k == 1 {
+		return 0, 0, 0, 1
+	}
+	return (1 - r - k) / (1 - k), (1 - g - k) / (1 - k), (1 - b - k) / (1 - k), k
+}
+
+// oklch 返回 OKLCH 的亮度 (0-1)、色度和色相 (角度)，算法见 https://bottosson.github.io/posts/oklab/。
+func (c rgbaColor) oklch() (l, ch, h float64) {
+	r, g, b := linearChannel(c.R), linearChannel(c.G), linearChannel(c.B)
+	lms := [3]float64{
+		math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b),
+		math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b),
+		math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b),
+	}
+	l = 0.2104542553*lms[0] + 0.7936177850*lms[1] - 0.0040720468*lms[2]
+	a := 1.9779984951*lms[0] - 2.4285922050*lms[1] + 0.4505937099*lms[2]
+	bb := 0.0259040371*lms[0] + 0.7827717662*lms[1] - 0.8086757660*lms[2]
+	ch = math.Hypot(a, bb)
+	if ch < 1e-4 {
+		// 灰色的色相没有意义
+		return l, 0, 0
+	}
+	h = math.Mod(math.Atan2(bb, a)*180/math.Pi+360, 360)
+	return l, ch, h
+}
+
+// linearChannel 将 sRGB 分量转换为线性光强度 (0-1)。
+func linearChannel(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// luminance 返回 WCAG 定义的相对亮度。
+func (c rgbaColor) luminance() float64 {
+	return 0.2126*linearChannel(c.R) + 0.7152*linearChannel(c.G) + 0.0722*linearChannel(c.B)
+}
+
+// over 将半透明颜色叠加在不透明的背景上。
+func (c rgbaColor) over(bg rgbaColor) rgbaColor {
+	return mixColors(bg, rgbaColor{R: c.R, G: c.G, B: c.B, A: 1}, c.A)
+}
+
+// mixColors 按比例 t (0-1) 从 a 向 b 混合，结果不透明度取 a 的值。
+func mixColors(a, b rgbaColor, t float64) rgbaColor {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return rgbaColor{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: a.A}
+}
+
+// contrastRatio 返回两个颜色的 WCAG 对比度 (1-21)。
+func contrastRatio(a, b rgbaColor) float64 {
+	la, lb := a.luminance(), b.luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// colorName 返回与颜色完全相同的 CSS 名称，有多个时取最短的 (e.g. "aqua" 与 "cyan" 取 "aqua")。
+func colorName(c rgbaColor) string {
+	if c.A != 1 {
+		return ""
+	}
+	v := uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+	best := ""
+	for name, value := range cssColorNames {
+		if value == v && (best == "" || len(name) < len(best) || (len(name) == len(best) && name < best)) {
+			best = name
+		}
+	}
+	return best
+}
+
+// hex 返回 "#RRGGBB"，半透明时为 "#RRGGBBAA"。
+func (c rgbaColor) hex() string {
+	if c.A < 1 {
+		return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, uint8(math.Round(c.A*255)))
+	}
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// rgbString 返回 CSS 的 rgb() 或 rgba()。
+func (c rgbaColor) rgbString() string {
+	if c.A < 1 {
+		return fmt.Sprintf("rgba(%d, %d, %d, %s)", c.R, c.G, c.B, colorAlphaText(c.A))
+	}
+	return fmt.Sprintf("rgb(%d, %d, %d)", c.R, c.G, c.B)
+}
+
+// hslString 返回 CSS 的 hsl() 或 hsla()。
+func (c rgbaColor) hslString() string {
+	h, s, l := c.hsl()
+	if c.A < 1 {
+		return fmt.Sprintf("hsla(%.0f, %.0f%%, %.0f%%, %s)", h, s*100, l*100, colorAlphaText(c.A))
+	}
+	return fmt.Sprintf("hsl(%.0f, %.0f%%, %.0f%%)", h, s*100, l*100)
+}
+
+// hsvString 返回 hsv() 写法，设计软件中也称 HSB。
+func (c rgbaColor) hsvString() string {
+	h, s, v := c.hsv()
+	return fmt.Sprintf("hsv(%.0f, %.0f%%, %.0f%%)", h, s*100, v*100)
+}
+
+// cmykString 返回 cmyk() 写法。
+func (c rgbaColor) cmykString() string {
+	cy, m, y, k := c.cmyk()
+	return fmt.Sprintf("cmyk(%.0f%%, %.0f%%, %.0f%%, %.0f%%)", cy*100, m*100, y*100, k*100)
+}
+
+// oklchString 返回 CSS 的 oklch()。
+func (c rgbaColor) oklchString() string {
+	l, ch, h := c.oklch()
+	s := fmt.Sprintf("oklch(%.2f%% %.4f %.2f", l*100, ch, h)
+	if c.A < 1 {
+		s += " / " + colorAlphaText(c.A)
+	}
+	return s + ")"
+}
+
+// uiColorString 返回 Swift 的 UIColor 构造器。
+func (c rgbaColor) uiColorString() string {
+	return fmt.Sprintf("UIColor(red: %.3f, green: %.3f, blue: %.3f, alpha: %.3f)",
+		float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, c.A)
+}
+
+// nsColorString 返回 Swift 的 NSColor 构造器 (sRGB 色彩空间)。
+func (c rgbaColor) nsColorString() string {
+	return fmt.Sprintf("NSColor(srgbRed: %.3f, green: %.3f, blue: %.3f, alpha: %.3f)",
+		float64(c.R)/255, float64(c.G)/255, float64(c.B)/255, c.A)
+}
+
+// androidColor 返回 Android 的 ARGB 颜色整数，分别为 HEX 字面量和有符号十进制值。
+func (c rgbaColor) androidColor() (string, int32) {
+	argb := uint32(math.Round(c.A*255))<<24 | uint32(c.R)<<16 | uint32(c.G)<<8 | uint32(c.B)
+	return fmt.Sprintf("0x%08X", argb), int32(argb)
+}
+
+// colorAlphaText 将不透明度格式化为最多三位小数, e.g. "0.5"。
+func colorAlphaText(a float64) string {
+	return strconv.FormatFloat(math.Round(a*1000)/1000, 'f', -1, 64)
+}
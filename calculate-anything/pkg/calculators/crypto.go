@@ -2,110 +2,182 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
 	"calculate-anything/pkg/api"
 	"calculate-anything/pkg/config"
+	"calculate-anything/pkg/decimal"
+	"calculate-anything/pkg/format"
 	"calculate-anything/pkg/parser"
 	"fmt"
-	"strconv"
+	"strings"
 	"time"
-
-	"github.com/deanishe/awgo"
 )
 
-// 已知的加密货币列表（简化版，用于区分加密货币和法币）
-// 原始项目使用一个巨大的 JSON 文件，这里为了性能和简洁性，只列出常见的。
-var knownCryptos = map[string]bool{
-	"BTC": true, "ETH": true, "XRP": true, "LTC": true, "BCH": true, "ADA": true,
-	"DOT": true, "DOGE": true, "USDT": true, "BNB": true, "SOL": true, "AVAX": true,
+// IsCrypto 检查一个符号是否是加密货币。符号表来自 CoinMarketCap 的
+// /cryptocurrency/map (或打包的快照)；与 ISO 4217 法币代码或数据存储单位 (e.g. BIT, GB)
+// 冲突的符号按法币或存储单位处理，否则 "1 GB to MB" 会被当作加密货币转换。
+func IsCrypto(ctx *Context, symbol string) bool {
+	s := strings.ToUpper(symbol)
+	if IsFiat(s) || IsDataStorageUnit(s) {
+		return false
+	}
+	_, ok := ctx.Cryptos()[s]
+	return ok
+}
+
+// cryptoCalculator 处理加密货币与法币、加密货币之间的转换。
+type cryptoCalculator struct{ baseCalculator }
+
+func init() { Register(&cryptoCalculator{}) }
+
+func (*cryptoCalculator) Name() string  { return "crypto" }
+func (*cryptoCalculator) Priority() int { return 30 }
+
+func (*cryptoCalculator) Examples() []string {
+	return []string{"1 btc to usd", "2 eth to btc"}
 }
 
-// IsCrypto 检查一个符号是否是已知的加密货币。
-func IsCrypto(symbol string) bool {
-	return knownCryptos[symbol]
+// Match 接受源或目标为加密货币的转换查询。
+func (*cryptoCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	p := parser.ParseConversion(query, ctx.Lang, ctx.Format)
+	if p == nil || len(p.Quantities) > 0 {
+		return nil
+	}
+	if IsCrypto(ctx, p.From) || IsCrypto(ctx, p.To) {
+		p.Type = parser.CryptoQuery
+		return p
+	}
+	return nil
 }
 
-// HandleCrypto 处理加密货币转换查询。
-func HandleCrypto(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery) {
+// Compute 执行加密货币转换。所有价格都来自同一张缓存的 USD 价格表，
+// 加密货币之间、加密货币与法币之间 (双向) 的换算都在本地完成。
+func (*cryptoCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	cfg := ctx.Config
 	// 从配置中获取缓存持续时间
 	cacheDuration := time.Duration(cfg.CryptoCurrencyCacheHours) * time.Hour
 
-	// 统一将符号转为大写
-	fromCrypto := p.From
-	toTarget := p.To
-
-	// 场景 1: 目标是另一种加密货币 (加密货币 -> 法币 -> 加密货币)
-	if IsCrypto(toTarget) {
-		// 我们使用一个稳定的法币（如 USD）作为中间转换媒介
-		const intermediateFiat = "USD"
+	// 统一将符号转为大写，法币一侧复用货币符号映射，将 "dollars", "€" 等转换为标准代码
+	from := cryptoOrFiat(ctx, p.From)
+	to := cryptoOrFiat(ctx, p.To)
 
-		// 步骤 1: 获取 "源加密货币 -> USD" 的汇率
-		fromResp, err := api.GetCryptoConversion(wf, cfg.APIKeyCoinMarket, p.Amount, fromCrypto, intermediateFiat, cacheDuration)
-		if err != nil {
-			alfred.ShowError(wf, err)
-			return
+	// 排名靠后、不在价格表中的币种由 GetCryptoPrices 一次补充
+	var symbols []string
+	for _, symbol := range []string{from, to} {
+		if IsCrypto(ctx, symbol) {
+			symbols = append(symbols, symbol)
 		}
-		amountInUSD := fromResp.Data.Quote[intermediateFiat].Price
+	}
+	prices, err := api.GetCryptoPrices(ctx.Cache, cfg.APIKeyCoinMarket, cfg.CryptoListingLimit, cryptoFiats(cfg), symbols, cacheDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	fromUSD, err := priceUSD(ctx, prices, from)
+	if err != nil {
+		return nil, err
+	}
+	toUSD, err := priceUSD(ctx, prices, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最终结果 = (源货币的 USD 总值) / (目标货币的 USD 单价)
+	resultValue, err := p.ExactAmount().Mul(fromUSD).Quo(toUSD, decimal.DivisionPrecision)
+	if err != nil {
+		return nil, fmt.Errorf("%s 的价格为零，无法计算", to)
+	}
+	results := cryptoResults(ctx, prices, p.ExactAmount(), from, resultValue, to)
 
-		// 步骤 2: 获取 "1 单位目标加密货币 -> USD" 的汇率，用于计算最终结果
-		toResp, err := api.GetCryptoConversion(wf, cfg.APIKeyCoinMarket, 1, toTarget, intermediateFiat, cacheDuration)
-		if err != nil {
-			alfred.ShowError(wf, err)
-			return
+	// 行情来自同一张价格表，无需额外请求: 按住 alt 查看源币种的行情摘要，
+	// 开启 crypto_show_market 时为每个加密货币追加行情行
+	for i, symbol := range symbols {
+		quote, ok := prices.Quote(symbol)
+		if !ok {
+			continue
 		}
-		toRateUSD := toResp.Data.Quote[intermediateFiat].Price
-		if toRateUSD == 0 {
-			alfred.ShowError(wf, fmt.Errorf("无法获取 %s 的汇率", toTarget))
-			return
+		if i == 0 && quote.MarketCap > 0 {
+			results[0].Modifiers = append(results[0].Modifiers, Modifier{Key: "alt", Subtitle: marketSummary(ctx.Format, quote), Arg: results[0].Arg})
 		}
+		if cfg.CryptoShowMarket {
+			results = append(results, marketRows(ctx.Format, quote, "USD", 1)...)
+		}
+	}
+	return results, nil
+}
+
+// cryptoOrFiat 返回加密货币的大写符号，或法币的标准代码。
+func cryptoOrFiat(ctx *Context, symbol string) string {
+	if IsCrypto(ctx, symbol) {
+		return strings.ToUpper(symbol)
+	}
+	return mapCurrencySymbol(symbol)
+}
 
-		// 最终结果 = (源加密货币的USD总值) / (目标加密货币的USD单价)
-		resultValue := amountInUSD / toRateUSD
-		displayResult(wf, cfg, p.Amount, fromCrypto, resultValue, toTarget)
-
-	} else {
-		// 场景 2: 目标是法币 (加密货币 -> 法币)
-		// 复用货币符号映射函数，将 "dollars", "€" 等转换为标准代码
-		toFiat := mapCurrencySymbol(toTarget)
-		resp, err := api.GetCryptoConversion(wf, cfg.APIKeyCoinMarket, p.Amount, fromCrypto, toFiat, cacheDuration)
-		if err != nil {
-			alfred.ShowError(wf, err)
-			return
+// cryptoFiats 返回随价格表一起请求的法币：配置的基准货币。
+func cryptoFiats(cfg *config.AppConfig) []string {
+	var fiats []string
+	for _, code := range cfg.BaseCurrencies {
+		if code = mapCurrencySymbol(code); IsFiat(code) {
+			fiats = append(fiats, code)
 		}
+	}
+	return fiats
+}
 
-		quote, ok := resp.Data.Quote[toFiat]
+// priceUSD 返回 1 个 symbol 的 USD 价格。加密货币取自价格表；法币优先使用价格表中
+// 同时请求的报价，其他法币通过 (已缓存的) 法币汇率表换算，无需额外请求 CoinMarketCap。
+func priceUSD(ctx *Context, prices *api.CryptoPrices, symbol string) (decimal.Decimal, error) {
+	if IsCrypto(ctx, symbol) {
+		price, ok := prices.PriceUSD(symbol)
 		if !ok {
-			alfred.ShowError(wf, fmt.Errorf("API 未返回目标货币 '%s' 的价格", toFiat))
-			return
+			return decimal.Decimal{}, fmt.Errorf("无法获取 %s 的价格", symbol)
 		}
+		return decimal.NewFromFloat(price), nil
+	}
+	if price, ok := prices.FiatPriceUSD(symbol); ok {
+		return decimal.NewFromFloat(price), nil
+	}
 
-		displayResult(wf, cfg, p.Amount, fromCrypto, quote.Price, toFiat)
+	cacheDuration := time.Duration(ctx.Config.CurrencyCacheHours) * time.Hour
+	rates, err := api.GetExchangeRates(ctx.Cache, rateProviders(ctx.Config), cacheDuration)
+	if err != nil {
+		return decimal.Decimal{}, err
 	}
+	price, err := api.ConvertCurrency(rates, symbol, "USD", decimal.NewFromInt(1))
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	if price.IsZero() {
+		return decimal.Decimal{}, fmt.Errorf("货币 '%s' 的汇率为零，无法计算", symbol)
+	}
+	return price, nil
 }
 
-// displayResult 格式化加密货币的计算结果并将其添加到 Alfred 反馈中。
-func displayResult(wf *aw.Workflow, cfg *config.AppConfig, fromAmount float64, fromSymbol string, toAmount float64, toSymbol string) {
-	var resultString string
+// cryptoResults 格式化加密货币的计算结果。
+func cryptoResults(ctx *Context, prices *api.CryptoPrices, fromAmount decimal.Decimal, fromSymbol string, toAmount decimal.Decimal, toSymbol string) []Result {
 	// 根据用户配置决定小数位数，-1 表示显示所有小数
-	if cfg.CryptoDecimals == -1 {
-		resultString = strconv.FormatFloat(toAmount, 'f', -1, 64)
-	} else {
-		format := fmt.Sprintf("%%.%df", cfg.CryptoDecimals)
-		resultString = fmt.Sprintf(format, toAmount)
+	decimals := ctx.Config.CryptoDecimals
+	if decimals < 0 {
+		decimals = -1
 	}
-	
-	resultStringUnformatted := strconv.FormatFloat(toAmount, 'f', -1, 64)
+	resultString := ctx.Format.PlainDecimal(toAmount, decimals)
+	resultStringUnformatted := format.CleanDecimal(toAmount)
 
-	title := fmt.Sprintf("%g %s = %s %s", fromAmount, fromSymbol, resultString, toSymbol)
-	subtitle := fmt.Sprintf("复制 '%s'", resultString)
+	title := fmt.Sprintf("%s %s = %s %s", ctx.Format.FormatDecimal(fromAmount, -1), fromSymbol, ctx.Format.FormatDecimal(toAmount, decimals), toSymbol)
+	subtitle := fmt.Sprintf("复制 '%s' · 价格%s更新", resultString, humanizeAge(time.Since(time.Unix(prices.Timestamp, 0))))
+	if prices.Stale {
+		subtitle += " (无法刷新，使用过期缓存)"
+	}
 
-	alfred.AddToWorkflow(wf, []alfred.Result{
+	return []Result{
 		{
+			Value:    toAmount.Float64(),
+			Unit:     toSymbol,
 			Title:    title,
 			Subtitle: subtitle,
 			Arg:      resultString,
-			IconPath: "icon.png", // 可以为加密货币准备一个专用图标
-			Modifiers: []alfred.Modifier{
+			Icon:     "icon.png", // 可以为加密货币准备一个专用图标
+			Modifiers: []Modifier{
 				{
 					Key: "cmd",
 					Subtitle: fmt.Sprintf("复制无格式的值 '%s'", resultStringUnformatted),
@@ -113,5 +185,5 @@ func displayResult(wf *aw.Workflow, cfg *config.AppConfig, fromAmount float64, f
 				},
 			},
 		},
-	})
+	}
 }
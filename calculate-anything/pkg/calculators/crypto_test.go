@@ -0,0 +1,32 @@
+// calculate-anything/pkg/calculators/crypto_test.go
+package calculators
+
+import (
+	"calculate-anything/pkg/api"
+	"testing"
+)
+
+func TestCrypto(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "1 btc to usd", calculator: "crypto", title: "1 BTC = 60,000 USD"},
+		{query: "2 eth to btc", calculator: "crypto", title: "2 ETH = 0.1 BTC"},
+		{query: "btc info", calculator: "cryptoinfo", title: "1 BTC = 60,000 USD"},
+	})
+}
+
+func TestCryptoStorageSymbols(t *testing.T) {
+	// CoinMarketCap 上存在与数据存储单位同名的币种，它们不能抢走存储单位的查询
+	ctx := newTestContext(t)
+	_ = ctx.Cache.StoreJSON("coinmarketcap_map", []api.CryptoAsset{
+		{ID: 1, Name: "Bitcoin", Symbol: "BTC", Slug: "bitcoin", Rank: 1},
+		{ID: 2, Name: "BitDAO", Symbol: "BIT", Slug: "bitdao", Rank: 2},
+		{ID: 3, Name: "Gigabyte", Symbol: "GB", Slug: "gigabyte", Rank: 3},
+		{ID: 4, Name: "Megabyte", Symbol: "MB", Slug: "megabyte", Rank: 4},
+	})
+	checkQueriesIn(t, ctx, []queryTest{
+		{query: "8 bit to B", calculator: "datastorage", title: "8 bit = 1 B"},
+		{query: "1 GB to MB", calculator: "datastorage", title: "1 GB = 1,000 MB"},
+		{query: "500 GB", calculator: "datastorage", title: "500 GB = 500,000,000 KB"},
+		{query: "1 btc to usd", calculator: "crypto", title: "1 BTC = 60,000 USD"},
+	})
+}
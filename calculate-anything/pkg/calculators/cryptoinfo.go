@@ -0,0 +1,130 @@
+// calculate-anything/pkg/calculators/cryptoinfo.go
+package calculators
+
+import (
+	"calculate-anything/pkg/api"
+	"calculate-anything/pkg/format"
+	"calculate-anything/pkg/parser"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cryptoInfoQuery 是 "btc info" 这类行情查询的类型
+const cryptoInfoQuery parser.QueryType = "cryptoinfo"
+
+// cryptoInfoCalculator 列出一个加密货币的行情: 价格、涨跌幅、市值和成交量。
+type cryptoInfoCalculator struct{ baseCalculator }
+
+func init() { Register(&cryptoInfoCalculator{}) }
+
+func (*cryptoInfoCalculator) Name() string  { return "cryptoinfo" }
+func (*cryptoInfoCalculator) Priority() int { return 31 }
+
+func (*cryptoInfoCalculator) Examples() []string {
+	return []string{"btc info"}
+}
+
+// Match 接受 "<加密货币符号> info" 形式的查询。
+func (*cryptoInfoCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	fields := strings.Fields(query)
+	if len(fields) != 2 || !strings.EqualFold(fields[1], "info") || !IsCrypto(ctx, fields[0]) {
+		return nil
+	}
+	return &parser.ParsedQuery{Type: cryptoInfoQuery, Input: query, Amount: 1, From: strings.ToUpper(fields[0])}
+}
+
+// Compute 从缓存的价格表中取出行情，金额以第一个基准货币显示。
+func (*cryptoInfoCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	cfg := ctx.Config
+	cacheDuration := time.Duration(cfg.CryptoCurrencyCacheHours) * time.Hour
+	prices, err := api.GetCryptoPrices(ctx.Cache, cfg.APIKeyCoinMarket, cfg.CryptoListingLimit, cryptoFiats(cfg), []string{p.From}, cacheDuration)
+	if err != nil {
+		return nil, err
+	}
+	quote, ok := prices.Quote(p.From)
+	if !ok || quote.Price == 0 {
+		return nil, fmt.Errorf("无法获取 %s 的行情", p.From)
+	}
+
+	// 价格表以 USD 计价，无法换算到基准货币时直接显示 USD
+	fiat, usdPerFiat := "USD", 1.0
+	if len(cfg.BaseCurrencies) > 0 {
+		base := mapCurrencySymbol(cfg.BaseCurrencies[0])
+		if price, err := priceUSD(ctx, prices, base); err == nil {
+			fiat, usdPerFiat = base, price.Float64()
+		}
+	}
+
+	decimals := cfg.CryptoDecimals
+	if decimals < 0 {
+		decimals = -1
+	}
+	price := quote.Price / usdPerFiat
+	arg := ctx.Format.Plain(price, decimals)
+	name := quote.Name
+	if quote.Rank > 0 {
+		name = fmt.Sprintf("%s #%d", quote.Name, quote.Rank)
+	}
+	subtitle := fmt.Sprintf("%s · 复制 '%s' · 价格%s更新", name, arg, humanizeAge(time.Since(time.Unix(prices.Timestamp, 0))))
+	if prices.Stale {
+		subtitle += " (无法刷新，使用过期缓存)"
+	}
+
+	results := []Result{{
+		Value:    price,
+		Unit:     fiat,
+		Title:    fmt.Sprintf("1 %s = %s %s", quote.Symbol, ctx.Format.Format(price, decimals), fiat),
+		Subtitle: subtitle,
+		Arg:      arg,
+		Icon:     "icon.png",
+	}}
+	return append(results, marketRows(ctx.Format, quote, fiat, usdPerFiat)...), nil
+}
+
+// marketRows 将涨跌幅、市值和成交量转换为结果行，金额以 fiat 显示 (1 fiat = usdPerFiat USD)。
+func marketRows(nf *format.Formatter, quote api.CryptoQuote, fiat string, usdPerFiat float64) []Result {
+	marketCap := quote.MarketCap / usdPerFiat
+	volume := quote.Volume24h / usdPerFiat
+	changes := fmt.Sprintf("1h %s · 24h %s · 7d %s",
+		signedPercent(nf, quote.PercentChange1h), signedPercent(nf, quote.PercentChange24h), signedPercent(nf, quote.PercentChange7d))
+
+	return []Result{
+		{
+			Value:    quote.PercentChange24h,
+			Unit:     "%",
+			Title:    fmt.Sprintf("%s 涨跌幅: %s", quote.Symbol, changes),
+			Subtitle: "过去 1 小时、24 小时和 7 天的价格变化",
+			Arg:      changes,
+		},
+		{
+			Value:    marketCap,
+			Unit:     fiat,
+			Title:    fmt.Sprintf("%s 市值: %s %s", quote.Symbol, nf.Format(marketCap, 0), fiat),
+			Subtitle: "复制市值",
+			Arg:      nf.Plain(marketCap, 0),
+		},
+		{
+			Value:    volume,
+			Unit:     fiat,
+			Title:    fmt.Sprintf("%s 24 小时成交量: %s %s", quote.Symbol, nf.Format(volume, 0), fiat),
+			Subtitle: "复制成交量",
+			Arg:      nf.Plain(volume, 0),
+		},
+	}
+}
+
+// marketSummary 返回一行行情摘要，用作转换结果的修饰键副标题。
+func marketSummary(nf *format.Formatter, quote api.CryptoQuote) string {
+	return fmt.Sprintf("%s 24h %s · 7d %s · 市值 %s USD", quote.Symbol,
+		signedPercent(nf, quote.PercentChange24h), signedPercent(nf, quote.PercentChange7d), nf.Format(quote.MarketCap, 0))
+}
+
+// signedPercent 将涨跌幅格式化为带符号的百分比, e.g. "+1.25%"。
+func signedPercent(nf *format.Formatter, v float64) string {
+	s := nf.Format(v, 2) + "%"
+	if v > 0 {
+		s = "+" + s
+	}
+	return s
+}
@@ -2,20 +2,18 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
 	"calculate-anything/pkg/api"
 	"calculate-anything/pkg/config"
+	"calculate-anything/pkg/decimal"
+	"calculate-anything/pkg/format"
 	"calculate-anything/pkg/parser"
 	"fmt"
-	"strconv"
 	"strings"
 	"time"
-
-	"github.com/deanishe/awgo"
 )
 
 // 货币符号到标准三字母代码的映射表
-// 这个映射也用于 IsCurrency 函数来判断一个词是否是货币
+// 这个映射和 ISO 4217 代码表一起用于 IsCurrency 函数来判断一个词是否是货币
 var currencySymbolMap = map[string]string{
 	"€":   "EUR", "EURO": "EUR", "EUROS": "EUR",
 	"¥":   "JPY", "YEN": "JPY",
@@ -58,9 +56,9 @@ var currencySymbolMap = map[string]string{
 // IsCurrency 检查一个符号或词语是否是已知的货币。
 func IsCurrency(symbol string) bool {
 	s := strings.ToUpper(symbol)
-	// 检查是否是标准三字母代码 (一个简化的检查)
-	if len(s) == 3 {
-		return true // 假设所有三字母大写字符串都是货币代码
+	// 检查是否是 ISO 4217 代码
+	if IsFiat(s) {
+		return true
 	}
 	// 检查是否在我们的符号映射表中
 	_, exists := currencySymbolMap[s]
@@ -78,49 +76,257 @@ func mapCurrencySymbol(symbol string) string {
 }
 
 
-// HandleCurrency 处理货币转换查询。
-func HandleCurrency(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery) {
+// currencyCalculator 处理法币之间的转换。
+type currencyCalculator struct{ baseCalculator }
+
+func init() { Register(&currencyCalculator{}) }
+
+func (*currencyCalculator) Name() string  { return "currency" }
+func (*currencyCalculator) Priority() int { return 40 }
+
+func (*currencyCalculator) Examples() []string {
+	return []string{"100 usd to eur", "100 € in $", "100 usd to eur,gbp,jpy", "100 usd", "100 usd to eur on 2024-03-01", "0.1 usd + 0.2 usd"}
+}
+
+// Match 接受源或目标为货币的转换查询。目标可以省略或有多个,
+// e.g. "100 usd" 转换为所有基准货币，"100 usd to eur,gbp,jpy" 转换为列出的货币。
+// 也接受多个金额的加减, e.g. "0.1 usd + 0.2 usd", "10 usd + 5 eur to gbp"。
+func (*currencyCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	p := parser.ParseConversionTargets(query, ctx.Lang, ctx.Format)
+	if p == nil {
+		return matchCurrencySum(ctx, query)
+	}
+	if len(p.ToUnits) == 0 {
+		// 没有目标时只有源本身像货币还不够，"5 min" 这类物理单位和数据存储单位不应被当作货币
+		if !IsCurrency(p.From) || len(resolveUnit(p.From)) > 0 || IsDataStorageUnit(p.From) {
+			return nil
+		}
+	} else if !IsCurrency(p.From) && !IsCurrency(p.To) {
+		return nil
+	}
+	p.Type = parser.CurrencyQuery
+	return p
+}
+
+// Compute 执行货币转换，每个目标货币生成一个结果。
+func (*currencyCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	cfg := ctx.Config
 	// 从配置中获取缓存持续时间
 	cacheDuration := time.Duration(cfg.CurrencyCacheHours) * time.Hour
-	// 获取汇率数据（可能来自缓存或 API）
-	rates, err := api.GetExchangeRates(wf, cfg.APIKeyFixer, cacheDuration)
+	// 获取汇率数据: 带日期子句时获取当天的历史汇率，
+	// 否则获取最新汇率（可能来自缓存、配置的提供者或过期的缓存）
+	var rates *api.Rates
+	var err error
+	if p.DateText != "" && p.Date.IsZero() {
+		return nil, fmt.Errorf("无效的日期: %s", p.DateText)
+	}
+	if !p.Date.IsZero() {
+		rates, err = api.GetHistoricalRates(ctx.Cache, rateProviders(cfg), p.Date)
+	} else {
+		rates, err = api.GetExchangeRates(ctx.Cache, rateProviders(cfg), cacheDuration)
+	}
 	if err != nil {
-		alfred.ShowError(wf, err)
-		return
+		return nil, err
 	}
 
 	// 将查询中的符号/名称转换为标准代码
 	fromCurrency := mapCurrencySymbol(p.From)
-	toCurrency := mapCurrencySymbol(p.To)
+	amount, err := currencyAmount(rates, p, fromCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	// 多个金额相加时先以第一个货币显示合计
+	var results []Result
+	if len(p.Quantities) > 0 {
+		results = append(results, currencySumResult(ctx, p, amount, fromCurrency))
+	}
+
+	targets := currencyTargets(cfg, fromCurrency, p.ToUnits)
+	if len(targets) == 0 {
+		if len(results) > 0 {
+			return results, nil
+		}
+		return nil, fmt.Errorf("请指定目标货币, e.g. '%s to eur'，或在配置中设置 base_currencies", p.Input)
+	}
+
+	// 多个目标时跳过无效的货币，全部无效才返回错误
+	var firstErr error
+	for _, toCurrency := range targets {
+		result, err := currencyResult(ctx, rates, p, amount, fromCurrency, toCurrency)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, firstErr
+	}
+	return results, nil
+}
+
+// currencyTargets 返回转换的目标货币代码。没有显式目标时使用配置的基准货币，并排除源货币本身。
+func currencyTargets(cfg *config.AppConfig, fromCurrency string, toUnits []string) []string {
+	var targets []string
+	if len(toUnits) > 0 {
+		for _, to := range toUnits {
+			targets = append(targets, mapCurrencySymbol(to))
+		}
+		return targets
+	}
+	for _, base := range cfg.BaseCurrencies {
+		if code := mapCurrencySymbol(base); code != "" && code != fromCurrency {
+			targets = append(targets, code)
+		}
+	}
+	return targets
+}
+
+// matchCurrencySum 接受多个货币金额的加减，所有金额和目标都必须是货币。
+func matchCurrencySum(ctx *Context, query string) *parser.ParsedQuery {
+	p := parser.ParseConversion(query, ctx.Lang, ctx.Format)
+	if p == nil || len(p.Quantities) < 2 {
+		return nil
+	}
+	for _, q := range p.Quantities {
+		if !IsCurrency(q.Unit) {
+			return nil
+		}
+	}
+	for _, to := range p.ToUnits {
+		if !IsCurrency(to) {
+			return nil
+		}
+	}
+	p.Type = parser.CurrencyQuery
+	return p
+}
+
+// currencyAmount 返回以源货币计的金额。多个金额相加时先全部换算为第一个货币再求和。
+func currencyAmount(rates *api.Rates, p *parser.ParsedQuery, fromCurrency string) (decimal.Decimal, error) {
+	if len(p.Quantities) == 0 {
+		return p.ExactAmount(), nil
+	}
+	var total decimal.Decimal
+	for _, q := range p.Quantities {
+		v, err := api.ConvertCurrency(rates, mapCurrencySymbol(q.Unit), fromCurrency, q.Exact)
+		if err != nil {
+			return decimal.Decimal{}, err
+		}
+		total = total.Add(v)
+	}
+	return total, nil
+}
+
+// currencySumResult 显示多个金额以第一个货币计的合计, e.g. "0.1 USD + 0.2 USD = 0.30 USD"。
+func currencySumResult(ctx *Context, p *parser.ParsedQuery, total decimal.Decimal, currency string) Result {
+	var label strings.Builder
+	for i, q := range p.Quantities {
+		switch {
+		case q.Exact.Sign() < 0:
+			label.WriteString(" - ")
+		case i > 0:
+			label.WriteString(" + ")
+		}
+		fmt.Fprintf(&label, "%s %s", ctx.Format.FormatDecimal(q.Exact.Abs(), -1), mapCurrencySymbol(q.Unit))
+	}
+	formatted := ctx.Format.PlainDecimal(total, ctx.Config.CurrencyDecimals)
+	return Result{
+		Value:    total.Float64(),
+		Unit:     currency,
+		Title:    fmt.Sprintf("%s = %s %s", strings.TrimPrefix(label.String(), " "), ctx.Format.FormatDecimal(total, ctx.Config.CurrencyDecimals), currency),
+		Subtitle: fmt.Sprintf("复制 '%s'", formatted),
+		Arg:      formatted,
+	}
+}
+
+// currencyResult 计算一个目标货币的转换结果。
+func currencyResult(ctx *Context, rates *api.Rates, p *parser.ParsedQuery, amount decimal.Decimal, fromCurrency, toCurrency string) (Result, error) {
+	cfg := ctx.Config
 
 	// 执行转换计算
-	resultValue, err := api.ConvertCurrency(rates, fromCurrency, toCurrency, p.Amount)
+	resultValue, err := api.ConvertCurrency(rates, fromCurrency, toCurrency, amount)
 	if err != nil {
-		alfred.ShowError(wf, err)
-		return
-	}
-
-	// 根据用户配置格式化小数位数
-	format := fmt.Sprintf("%%.%df", cfg.CurrencyDecimals)
-	resultStringFormatted := fmt.Sprintf(format, resultValue)
-	resultStringUnformatted := strconv.FormatFloat(resultValue, 'f', -1, 64)
-
-	title := fmt.Sprintf("%g %s = %s %s", p.Amount, fromCurrency, resultStringFormatted, toCurrency)
-	subtitle := fmt.Sprintf("复制 '%s'", resultStringFormatted)
-
-	// 将结果（包括修饰键操作）添加到 Alfred 反馈中
-	alfred.AddToWorkflow(wf, []alfred.Result{
-		{
-			Title:    title,
-			Subtitle: subtitle,
-			Arg:      resultStringFormatted,
-			Modifiers: []alfred.Modifier{
-				{
-					Key:      "cmd",
-					Subtitle: fmt.Sprintf("复制无格式的值 '%s'", resultStringUnformatted),
-					Arg:      resultStringUnformatted,
-				},
+		return Result{}, err
+	}
+
+	// 根据用户配置格式化小数位数、小数点和千位分组，按配置的舍入方式舍入
+	resultStringFormatted := ctx.Format.PlainDecimal(resultValue, cfg.CurrencyDecimals)
+	resultStringUnformatted := format.CleanDecimal(resultValue)
+
+	title := fmt.Sprintf("%s %s = %s %s", ctx.Format.FormatDecimal(amount, -1), fromCurrency, ctx.Format.FormatDecimal(resultValue, cfg.CurrencyDecimals), toCurrency)
+	subtitle := fmt.Sprintf("复制 '%s' · %s", resultStringFormatted, rateAgeText(rates))
+	if !p.Date.IsZero() {
+		// 历史汇率显示汇率的实际日期，它可能早于查询的日期（周末和节假日）
+		subtitle = fmt.Sprintf("复制 '%s' · %s 的汇率，来自 %s", resultStringFormatted, rates.Date, rates.Source)
+	}
+
+	// 返回结果（包括修饰键操作）
+	return Result{
+		Value:    resultValue.Float64(),
+		Unit:     toCurrency,
+		Title:    title,
+		Subtitle: subtitle,
+		Arg:      resultStringFormatted,
+		Modifiers: []Modifier{
+			{
+				Key:      "cmd",
+				Subtitle: fmt.Sprintf("复制无格式的值 '%s'", resultStringUnformatted),
+				Arg:      resultStringUnformatted,
 			},
 		},
-	})
+	}, nil
+}
+
+// rateProviders 按配置的顺序创建汇率提供者，忽略无法识别的名称。
+func rateProviders(cfg *config.AppConfig) []api.RateProvider {
+	var providers []api.RateProvider
+	for _, name := range cfg.CurrencyProviders {
+		switch name {
+		case "fixer":
+			providers = append(providers, &api.FixerProvider{APIKey: cfg.APIKeyFixer})
+		case "ecb":
+			providers = append(providers, &api.ECBProvider{})
+		case "exchangeratehost":
+			providers = append(providers, &api.ExchangeRateHostProvider{
+				URL:           cfg.ExchangeRateHostURL,
+				HistoricalURL: cfg.ExchangeRateHostHistURL,
+				APIKey:        cfg.APIKeyExchangeRateHost,
+			})
+		case "file":
+			providers = append(providers, &api.FileProvider{Path: cfg.CurrencyRatesFile})
+		}
+	}
+	return providers
+}
+
+// rateAgeText 描述汇率的来源和更新时间, e.g. "汇率来自 ECB，3 小时前更新"。
+func rateAgeText(rates *api.Rates) string {
+	text := fmt.Sprintf("汇率来自 %s", rates.Source)
+	if rates.Timestamp != 0 {
+		text += "，" + humanizeAge(rates.Age()) + "更新"
+	} else if rates.Date != "" {
+		text += "，日期 " + rates.Date
+	}
+	if rates.Stale {
+		text += " (所有提供者均失败，使用过期缓存)"
+	}
+	return text
+}
+
+// humanizeAge 将时间间隔转换为 "刚刚"、"5 分钟前"、"3 小时前"、"2 天前" 这类文本。
+func humanizeAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "刚刚"
+	case age < time.Hour:
+		return fmt.Sprintf("%d 分钟前", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%d 小时前", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%d 天前", int(age.Hours()/24))
+	}
 }
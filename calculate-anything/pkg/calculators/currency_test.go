@@ -0,0 +1,32 @@
+// calculate-anything/pkg/calculators/currency_test.go
+package calculators
+
+import "testing"
+
+func TestCurrency(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "100 usd to eur", calculator: "currency", title: "100 USD = 90.91 EUR"},
+		{query: "100 USD in EUR", calculator: "currency", title: "100 USD = 90.91 EUR"},
+		{query: "100 € in $", calculator: "currency", title: "100 EUR = 110.00 USD"},
+		{query: "100 euros to dollars", calculator: "currency", title: "100 EUR = 110.00 USD"},
+		{query: "100 usd to eur,gbp,jpy", calculator: "currency", title: "100 USD = 90.91 EUR"},
+		{query: "100 usd", calculator: "currency", title: "100 USD = 90.91 EUR"},
+		{query: "100 usd to eur on 2024-03-01", calculator: "currency", title: "100 USD = 92.59 EUR"},
+		{query: "100 usd to eur on 2024-02-30", calculator: "currency", err: "无效的日期: 2024-02-30"},
+		{query: "0.1 usd + 0.2 usd", calculator: "currency", title: "0.1 USD + 0.2 USD = 0.30 USD"},
+	})
+}
+
+func TestCurrencyTargets(t *testing.T) {
+	ctx := newTestContext(t)
+	titles := resultTitles(t, ctx, "100 usd to eur,gbp,jpy")
+	want := []string{"100 USD = 90.91 EUR", "100 USD = 77.27 GBP", "100 USD = 14,545.45 JPY"}
+	if len(titles) != len(want) {
+		t.Fatalf("结果为 %q, 期望 %q", titles, want)
+	}
+	for i := range want {
+		if titles[i] != want[i] {
+			t.Errorf("第 %d 条结果为 %q, 期望 %q", i, titles[i], want[i])
+		}
+	}
+}
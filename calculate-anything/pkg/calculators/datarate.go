@@ -0,0 +1,267 @@
+// calculate-anything/pkg/calculators/datarate.go
+package calculators
+
+import (
+	"calculate-anything/pkg/decimal"
+	"calculate-anything/pkg/format"
+	"calculate-anything/pkg/parser"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+var (
+	// 传输速率的换算, e.g. "100 Mbps in MB/s", "12500000 B/s to auto"。
+	// 单位区分大小写: 小写 b 表示比特，大写 B 表示字节，因此直接匹配原始查询
+	dataRateConversionRegex = regexp.MustCompile(`^([\d.,]+)\s*([a-zA-Z]+(?:/s|/sec)?)\s+(?:(?i:to|in|as|into)\s+)?([a-zA-Z]+(?:/s|/sec)?)$`)
+	// 传输时间的估算, e.g. "4.7 GB at 100 Mbps", "1 TB @ 80 MB/s"
+	transferTimeRegex = regexp.MustCompile(`^([\d.,]+)\s*([a-zA-Z]+)(?:\s*@\s*|\s+(?i:at|over|with)\s+)([\d.,]+)\s*([a-zA-Z]+(?:/s|/sec)?)$`)
+)
+
+// storageScaleKeys 是 "auto" 目标可以选择的存储单位，从小到大排列
+var storageScaleKeys = []string{"B", "KB", "MB", "GB", "TB", "PB", "EB", "ZB", "YB"}
+
+// ratePrefixes 是 "auto" 目标可以选择的速率前缀，从小到大排列
+var ratePrefixes = []string{"", "K", "M", "G", "T", "P"}
+
+// scaleUnit 是一个带换算因子的单位，因子以字节 (速率为字节/秒) 计。
+type scaleUnit struct {
+	label  string
+	factor decimal.Decimal
+}
+
+// dataRate 是解析后的传输速率单位。
+type dataRate struct {
+	scaleUnit
+	bits   bool // 是否以比特计, e.g. Mbps
+	binary bool // 是否使用 1024 进制
+}
+
+// matchDataRate 匹配传输速率的换算和传输时间的估算，不匹配时返回 nil。
+func matchDataRate(query string, nf *format.Formatter) *parser.ParsedQuery {
+	query = strings.TrimSpace(query)
+	if m := dataRateConversionRegex.FindStringSubmatch(query); m != nil {
+		amount, err := nf.ParseDecimal(m[1])
+		if err != nil {
+			return nil
+		}
+		if _, ok := parseDataRate(m[2], false); !ok {
+			return nil
+		}
+		if _, ok := parseDataRate(m[3], false); !ok && !strings.EqualFold(m[3], "auto") {
+			return nil
+		}
+		return &parser.ParsedQuery{Type: parser.DataStorageQuery, Input: query, Action: "rate", Amount: amount.Float64(), Exact: amount, From: m[2], To: m[3]}
+	}
+
+	if m := transferTimeRegex.FindStringSubmatch(query); m != nil {
+		size, err1 := nf.ParseDecimal(m[1])
+		rate, err2 := nf.ParseDecimal(m[3])
+		if err1 != nil || err2 != nil || !IsDataStorageUnit(m[2]) {
+			return nil
+		}
+		if _, ok := parseDataRate(m[4], false); !ok {
+			return nil
+		}
+		return &parser.ParsedQuery{
+			Type:   parser.DataStorageQuery,
+			Input:  query,
+			Action: "transfer",
+			Quantities: []parser.Quantity{
+				{Amount: size.Float64(), Exact: size, Unit: m[2]},
+				{Amount: rate.Float64(), Exact: rate, Unit: m[4]},
+			},
+		}
+	}
+	return nil
+}
+
+// parseDataRate 解析传输速率单位, e.g. "Mbps", "Mbit/s", "MB/s", "MBps", "MiB/s"。
+// "b" 和 "bit" 表示比特，"B" 表示字节；全大写的 "MBPS" 按网络习惯视为比特。
+// 比特速率总是十进制 (1 Kbps = 1000 bit/s)，字节速率与存储单位一样受 forceBinary 影响。
+func parseDataRate(unit string, forceBinary bool) (dataRate, bool) {
+	var base string
+	upperPS := false
+	lower := strings.ToLower(unit)
+	switch {
+	case strings.HasSuffix(lower, "/sec"):
+		base = unit[:len(unit)-4]
+	case strings.HasSuffix(lower, "/s"):
+		base = unit[:len(unit)-2]
+	case strings.HasSuffix(lower, "ps") && len(unit) > 2:
+		base, upperPS = unit[:len(unit)-2], strings.HasSuffix(unit, "PS")
+	default:
+		return dataRate{}, false
+	}
+
+	var prefix string
+	var bits bool
+	switch {
+	case strings.HasSuffix(strings.ToLower(base), "bit"):
+		prefix, bits = base[:len(base)-3], true
+	case strings.HasSuffix(base, "b"):
+		prefix, bits = base[:len(base)-1], true
+	case strings.HasSuffix(base, "B"):
+		prefix, bits = base[:len(base)-1], upperPS
+	default:
+		return dataRate{}, false
+	}
+
+	prefix = strings.ToUpper(prefix)
+	binary := strings.HasSuffix(prefix, "I")
+	if !bits && forceBinary {
+		binary = true
+	}
+	u, ok := rateUnit(prefix, bits, binary)
+	return dataRate{scaleUnit: u, bits: bits, binary: binary}, ok
+}
+
+// rateUnit 由前缀 (e.g. "M", "KI") 返回速率单位，复用存储单位表的换算因子。
+func rateUnit(prefix string, bits, binary bool) (scaleUnit, bool) {
+	table := storageUnits(binary)
+	if bits && !strings.HasSuffix(prefix, "I") {
+		// 比特速率的 K, M, G 总是 1000 进制
+		table = decimalUnits
+	}
+	u, ok := table[prefix+"B"]
+	if !ok {
+		return scaleUnit{}, false
+	}
+
+	display := strings.Replace(prefix, "I", "i", 1)
+	switch {
+	case bits && strings.HasSuffix(prefix, "I"):
+		return scaleUnit{label: display + "bit/s", factor: u.Factor.Mul(decimal.New(125, -3))}, true
+	case bits:
+		return scaleUnit{label: display + "bps", factor: u.Factor.Mul(decimal.New(125, -3))}, true
+	}
+	return scaleUnit{label: display + "B/s", factor: u.Factor}, true
+}
+
+// storageScale 返回 "auto" 目标的存储单位序列，二进制模式使用 KiB, MiB 等。
+func storageScale(binary bool) []scaleUnit {
+	scale := make([]scaleUnit, len(storageScaleKeys))
+	for i, key := range storageScaleKeys {
+		if binary && key != "B" {
+			key = key[:1] + "IB"
+			scale[i] = scaleUnit{label: key[:1] + "iB", factor: binaryUnits[key].Factor}
+			continue
+		}
+		scale[i] = scaleUnit{label: key, factor: decimalUnits[key].Factor}
+	}
+	return scale
+}
+
+// rateScale 返回 "auto" 目标的速率单位序列，与源单位同为比特或字节。
+func rateScale(bits, binary bool) []scaleUnit {
+	var scale []scaleUnit
+	for _, prefix := range ratePrefixes {
+		if binary && prefix != "" {
+			prefix += "I"
+		}
+		if u, ok := rateUnit(prefix, bits, binary); ok {
+			scale = append(scale, u)
+		}
+	}
+	return scale
+}
+
+// autoUnit 选择使数值不小于 1 的最大单位, e.g. 123456789 B -> MB。
+func autoUnit(v decimal.Decimal, scale []scaleUnit) (string, decimal.Decimal) {
+	best := scale[0]
+	for _, u := range scale[1:] {
+		if v.Abs().Cmp(u.factor) >= 0 {
+			best = u
+		}
+	}
+	return best.label, best.factor
+}
+
+// dataRateResults 换算传输速率。
+func dataRateResults(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	force := ctx.Config.DataStorageForceBinary
+	from, ok := parseDataRate(p.From, force)
+	if !ok {
+		return nil, fmt.Errorf("未知的传输速率单位: %s", p.From)
+	}
+	bytesPerSecond := p.ExactAmount().Mul(from.factor)
+
+	var to scaleUnit
+	if strings.EqualFold(p.To, "auto") {
+		to.label, to.factor = autoUnit(bytesPerSecond, rateScale(from.bits, from.binary))
+	} else {
+		rate, ok := parseDataRate(p.To, force)
+		if !ok {
+			return nil, fmt.Errorf("未知的传输速率单位: %s", p.To)
+		}
+		to = rate.scaleUnit
+	}
+
+	resultValue, err := bytesPerSecond.Quo(to.factor, decimal.DivisionPrecision)
+	if err != nil {
+		return nil, err
+	}
+	resultString := ctx.Format.PlainDecimal(resultValue, -1)
+	return []Result{{
+		Value:    resultValue.Float64(),
+		Unit:     to.label,
+		Title:    fmt.Sprintf("%s %s = %s %s", ctx.Format.FormatDecimal(p.ExactAmount(), -1), from.label, ctx.Format.FormatDecimal(resultValue, -1), to.label),
+		Subtitle: fmt.Sprintf("复制 '%s'", resultString),
+		Arg:      resultString,
+	}}, nil
+}
+
+// transferTimeResults 估算以给定速率传输一定数据量所需的时间，不计协议开销。
+func transferTimeResults(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	force := ctx.Config.DataStorageForceBinary
+	size, rateQuantity := p.Quantities[0], p.Quantities[1]
+
+	unit, ok := storageUnits(force || isBinaryUnit(size.Unit))[strings.ToUpper(size.Unit)]
+	if !ok {
+		return nil, fmt.Errorf("未知的数据存储单位: %s", size.Unit)
+	}
+	rate, ok := parseDataRate(rateQuantity.Unit, force)
+	if !ok {
+		return nil, fmt.Errorf("未知的传输速率单位: %s", rateQuantity.Unit)
+	}
+
+	bytesPerSecond := rateQuantity.Exact.Mul(rate.factor)
+	if bytesPerSecond.Sign() <= 0 {
+		return nil, fmt.Errorf("传输速率必须大于零")
+	}
+	seconds, err := size.Exact.Mul(unit.Factor).Quo(bytesPerSecond, decimal.DivisionPrecision)
+	if err != nil {
+		return nil, err
+	}
+	secs := seconds.Float64()
+	if secs > 100*365*86400 {
+		return nil, fmt.Errorf("传输时间超过 100 年")
+	}
+	if secs >= 10 {
+		// 估算值精确到秒即可
+		secs = math.Round(secs)
+	}
+
+	rateLabel, rateFactor := autoUnit(bytesPerSecond, rateScale(false, force))
+	rateValue, _ := bytesPerSecond.Quo(rateFactor, decimal.DivisionPrecision)
+	human := formatHumanDuration(secs, ctx.Format)
+	clock := formatClockDuration(secs)
+
+	return []Result{
+		{
+			Value:    secs,
+			Unit:     "s",
+			Title:    fmt.Sprintf("%s %s @ %s %s ≈ %s", ctx.Format.FormatDecimal(size.Exact, -1), size.Unit, ctx.Format.FormatDecimal(rateQuantity.Exact, -1), rate.label, human),
+			Subtitle: fmt.Sprintf("按 %s %s 计算，不计协议开销 · 复制", ctx.Format.FormatDecimal(rateValue, -1), rateLabel),
+			Arg:      human,
+		},
+		{
+			Value:    secs,
+			Unit:     "s",
+			Title:    clock,
+			Subtitle: "时:分:秒 · 复制",
+			Arg:      clock,
+		},
+	}, nil
+}
@@ -2,48 +2,44 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
-	"calculate-anything/pkg/config"
+	"calculate-anything/pkg/decimal"
 	"calculate-anything/pkg/parser"
 	"fmt"
-	"math"
 	"strings"
-
-	"github.com/deanishe/awgo"
 )
 
 // storageUnit 定义了一个数据存储单位及其与“字节(Byte)”的换算因子
 type storageUnit struct {
 	Name   string
-	Factor float64 // 相对于“字节”的换算因子
+	Factor decimal.Decimal // 相对于“字节”的换算因子，精确值
 }
 
 // 十进制单位 (IEC 标准, 1 KB = 1000 Bytes)
 var decimalUnits = map[string]storageUnit{
-	"B":   {Name: "Byte", Factor: 1},
-	"KB":  {Name: "Kilobyte", Factor: math.Pow(1000, 1)},
-	"MB":  {Name: "Megabyte", Factor: math.Pow(1000, 2)},
-	"GB":  {Name: "Gigabyte", Factor: math.Pow(1000, 3)},
-	"TB":  {Name: "Terabyte", Factor: math.Pow(1000, 4)},
-	"PB":  {Name: "Petabyte", Factor: math.Pow(1000, 5)},
-	"EB":  {Name: "Exabyte", Factor: math.Pow(1000, 6)},
-	"ZB":  {Name: "Zettabyte", Factor: math.Pow(1000, 7)},
-	"YB":  {Name: "Yottabyte", Factor: math.Pow(1000, 8)},
-	"BIT": {Name: "Bit", Factor: 1.0 / 8.0},
+	"B":   {Name: "Byte", Factor: decimal.NewFromInt(1)},
+	"KB":  {Name: "Kilobyte", Factor: storagePow(1000, 1)},
+	"MB":  {Name: "Megabyte", Factor: storagePow(1000, 2)},
+	"GB":  {Name: "Gigabyte", Factor: storagePow(1000, 3)},
+	"TB":  {Name: "Terabyte", Factor: storagePow(1000, 4)},
+	"PB":  {Name: "Petabyte", Factor: storagePow(1000, 5)},
+	"EB":  {Name: "Exabyte", Factor: storagePow(1000, 6)},
+	"ZB":  {Name: "Zettabyte", Factor: storagePow(1000, 7)},
+	"YB":  {Name: "Yottabyte", Factor: storagePow(1000, 8)},
+	"BIT": {Name: "Bit", Factor: decimal.New(125, -3)},
 }
 
 // 二进制单位 (JEDEC/传统标准, 1 KiB = 1024 Bytes)
 var binaryUnits = map[string]storageUnit{
-	"B":   {Name: "Byte", Factor: 1},
-	"KIB": {Name: "Kibibyte", Factor: math.Pow(1024, 1)},
-	"MIB": {Name: "Mebibyte", Factor: math.Pow(1024, 2)},
-	"GIB": {Name: "Gibibyte", Factor: math.Pow(1024, 3)},
-	"TIB": {Name: "Tebibyte", Factor: math.Pow(1024, 4)},
-	"PIB": {Name: "Pebibyte", Factor: math.Pow(1024, 5)},
-	"EIB": {Name: "Exbibyte", Factor: math.Pow(1024, 6)},
-	"ZIB": {Name: "Zebibyte", Factor: math.Pow(1024, 7)},
-	"YIB": {Name: "Yobibyte", Factor: math.Pow(1024, 8)},
-	"BIT": {Name: "Bit", Factor: 1.0 / 8.0},
+	"B":   {Name: "Byte", Factor: decimal.NewFromInt(1)},
+	"KIB": {Name: "Kibibyte", Factor: storagePow(1024, 1)},
+	"MIB": {Name: "Mebibyte", Factor: storagePow(1024, 2)},
+	"GIB": {Name: "Gibibyte", Factor: storagePow(1024, 3)},
+	"TIB": {Name: "Tebibyte", Factor: storagePow(1024, 4)},
+	"PIB": {Name: "Pebibyte", Factor: storagePow(1024, 5)},
+	"EIB": {Name: "Exbibyte", Factor: storagePow(1024, 6)},
+	"ZIB": {Name: "Zebibyte", Factor: storagePow(1024, 7)},
+	"YIB": {Name: "Yobibyte", Factor: storagePow(1024, 8)},
+	"BIT": {Name: "Bit", Factor: decimal.New(125, -3)},
 }
 
 // IsDataStorageUnit 检查一个单位字符串是否是已知的数据存储单位。
@@ -54,57 +50,153 @@ func IsDataStorageUnit(unit string) bool {
 	return isDecimal || isBinary
 }
 
-// HandleDataStorage 处理数据存储单位的转换。
-func HandleDataStorage(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery) {
-	from := strings.ToUpper(p.From)
-	to := strings.ToUpper(p.To)
+// dataStorageCalculator 处理数据存储单位的转换。
+type dataStorageCalculator struct{ baseCalculator }
+
+func init() { Register(&dataStorageCalculator{}) }
+
+func (*dataStorageCalculator) Name() string  { return "datastorage" }
+func (*dataStorageCalculator) Priority() int { return 35 }
+
+func (*dataStorageCalculator) Examples() []string {
+	return []string{"1 GB to MiB", "500 MB to GB", "100 Mbps in MB/s", "4.7 GB at 100 Mbps", "123456789 B to auto", "500 GB"}
+}
+
+// Match 接受源或目标为数据存储单位的转换查询、只有源单位的查询 (列出常用单位)，
+// 以及传输速率的换算和传输时间的估算。
+func (*dataStorageCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	if p := matchDataRate(query, ctx.Format); p != nil {
+		return p
+	}
+	p := parser.ParseConversion(query, ctx.Lang, ctx.Format)
+	if p == nil {
+		if p = matchUnitList(ctx, query, IsDataStorageUnit); p != nil {
+			p.Type = parser.DataStorageQuery
+		}
+		return p
+	}
+	if len(p.Quantities) > 0 {
+		return nil
+	}
+	if IsDataStorageUnit(p.From) || IsDataStorageUnit(p.To) {
+		p.Type = parser.DataStorageQuery
+		return p
+	}
+	return nil
+}
+
+// Compute 执行数据存储单位的转换。目标为 "auto" 时选择最易读的单位。
+func (*dataStorageCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	switch p.Action {
+	case "rate":
+		return dataRateResults(ctx, p)
+	case "transfer":
+		return transferTimeResults(ctx, p)
+	case "all":
+		return storageListResults(ctx, p)
+	}
+
+	result, err := storageResult(ctx, p, p.To)
+	if err != nil {
+		return nil, err
+	}
+	return []Result{result}, nil
+}
 
-	var fromUnit, toUnit storageUnit
-	var okFrom, okTo bool
+// storageResult 将查询的数量转换为目标单位 target。
+func storageResult(ctx *Context, p *parser.ParsedQuery, target string) (Result, error) {
+	cfg := ctx.Config
+	from := strings.ToUpper(p.From)
+	to := strings.ToUpper(target)
 
 	// 判断本次转换应该使用二进制还是十进制
 	// 满足以下任一条件即使用二进制：
 	// 1. 用户在配置中强制开启二进制模式
 	// 2. 查询的单位中包含二进制单位 (如 KiB, MiB)
 	useBinary := cfg.DataStorageForceBinary || isBinaryUnit(from) || isBinaryUnit(to)
+	activeUnitMap := storageUnits(useBinary)
 
-	var activeUnitMap map[string]storageUnit
-	if useBinary {
-		// 在二进制模式下，我们让 KB, MB, GB 也按 1024 计算以符合传统用法
-		activeUnitMap = make(map[string]storageUnit)
-		for k, v := range binaryUnits { activeUnitMap[k] = v }
-		activeUnitMap["KB"] = storageUnit{Name: "Kilobyte (binary)", Factor: math.Pow(1024, 1)}
-		activeUnitMap["MB"] = storageUnit{Name: "Megabyte (binary)", Factor: math.Pow(1024, 2)}
-		activeUnitMap["GB"] = storageUnit{Name: "Gigabyte (binary)", Factor: math.Pow(1024, 3)}
-		activeUnitMap["TB"] = storageUnit{Name: "Terabyte (binary)", Factor: math.Pow(1024, 4)}
-		activeUnitMap["PB"] = storageUnit{Name: "Petabyte (binary)", Factor: math.Pow(1024, 5)}
-	} else {
-		activeUnitMap = decimalUnits
+	fromUnit, okFrom := activeUnitMap[from]
+	if !okFrom {
+		return Result{}, fmt.Errorf("未知的数据存储单位: %s", p.From)
 	}
 
-	fromUnit, okFrom = activeUnitMap[from]
-	toUnit, okTo = activeUnitMap[to]
+	// 转换逻辑: Amount -> Bytes -> Target，使用十进制运算，YB 级别的数值也不会出现误差
+	valueInBytes := p.ExactAmount().Mul(fromUnit.Factor)
 
-	if !okFrom {
-		alfred.ShowError(wf, fmt.Errorf("未知的数据存储单位: %s", p.From))
-		return
+	toName := target
+	toUnit, okTo := activeUnitMap[to]
+	if to == "AUTO" {
+		toName, toUnit.Factor = autoUnit(valueInBytes, storageScale(useBinary))
+		okTo = true
 	}
 	if !okTo {
-		alfred.ShowError(wf, fmt.Errorf("未知的数据存储单位: %s", p.To))
-		return
+		return Result{}, fmt.Errorf("未知的数据存储单位: %s", target)
 	}
 
-	// 转换逻辑: Amount -> Bytes -> Target
-	valueInBytes := p.Amount * fromUnit.Factor
-	resultValue := valueInBytes / toUnit.Factor
-	resultString := fmt.Sprintf("%g", resultValue)
+	resultValue, err := valueInBytes.Quo(toUnit.Factor, decimal.DivisionPrecision)
+	if err != nil {
+		return Result{}, err
+	}
+	return storageRow(ctx, p, resultValue, toName), nil
+}
+
+// storageListResults 列出源数量在常用存储单位下的数值，跳过与源单位大小相同的单位。
+// 源单位和每个目标单位各自决定是否按二进制计算，因此 "500 GB" 列出的 GiB 是换算后的数值。
+func storageListResults(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	force := ctx.Config.DataStorageForceBinary
+	fromUnit, ok := storageUnits(force || isBinaryUnit(p.From))[strings.ToUpper(p.From)]
+	if !ok {
+		return nil, fmt.Errorf("未知的数据存储单位: %s", p.From)
+	}
+	valueInBytes := p.ExactAmount().Mul(fromUnit.Factor)
+
+	var results []Result
+	for _, target := range unitPreferences(ctx.Config, dataStorageFamily) {
+		toUnit, ok := storageUnits(force || isBinaryUnit(target))[strings.ToUpper(target)]
+		if !ok || toUnit.Factor.Cmp(fromUnit.Factor) == 0 {
+			continue
+		}
+		resultValue, err := valueInBytes.Quo(toUnit.Factor, decimal.DivisionPrecision)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, storageRow(ctx, p, resultValue, target))
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("没有可以列出的数据存储单位")
+	}
+	return results, nil
+}
 
-	title := fmt.Sprintf("%g %s = %s %s", p.Amount, p.From, resultString, p.To)
+// storageRow 生成 "数量 源单位 = 结果 目标单位" 的结果项。
+func storageRow(ctx *Context, p *parser.ParsedQuery, resultValue decimal.Decimal, toName string) Result {
+	resultString := ctx.Format.PlainDecimal(resultValue, -1)
+	title := fmt.Sprintf("%s %s = %s %s", ctx.Format.FormatDecimal(p.ExactAmount(), -1), p.From, ctx.Format.FormatDecimal(resultValue, -1), toName)
 	subtitle := fmt.Sprintf("复制 '%s'", resultString)
+	return Result{Value: resultValue.Float64(), Unit: toName, Title: title, Subtitle: subtitle, Arg: resultString}
+}
+
+// storageUnits 返回本次转换使用的单位表。二进制模式下 KB, MB, GB 也按 1024 计算以符合传统用法。
+func storageUnits(useBinary bool) map[string]storageUnit {
+	if !useBinary {
+		return decimalUnits
+	}
+	units := make(map[string]storageUnit, len(binaryUnits)+5)
+	for k, v := range binaryUnits {
+		units[k] = v
+	}
+	units["KB"] = storageUnit{Name: "Kilobyte (binary)", Factor: storagePow(1024, 1)}
+	units["MB"] = storageUnit{Name: "Megabyte (binary)", Factor: storagePow(1024, 2)}
+	units["GB"] = storageUnit{Name: "Gigabyte (binary)", Factor: storagePow(1024, 3)}
+	units["TB"] = storageUnit{Name: "Terabyte (binary)", Factor: storagePow(1024, 4)}
+	units["PB"] = storageUnit{Name: "Petabyte (binary)", Factor: storagePow(1024, 5)}
+	return units
+}
 
-	alfred.AddToWorkflow(wf, []alfred.Result{
-		{Title: title, Subtitle: subtitle, Arg: resultString},
-	})
+// storagePow 返回 base 的 n 次幂的精确值。
+func storagePow(base int64, n int) decimal.Decimal {
+	return decimal.NewFromInt(base).Pow(n)
 }
 
 // isBinaryUnit 检查一个单位是否是标准的二进制单位（以 'iB' 结尾）。
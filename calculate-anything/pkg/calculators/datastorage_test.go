@@ -0,0 +1,16 @@
+// calculate-anything/pkg/calculators/datastorage_test.go
+package calculators
+
+import "testing"
+
+func TestDataStorage(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "1 GB to MiB", calculator: "datastorage", title: "1 GB = 1,024 MiB"},
+		{query: "500 MB to GB", calculator: "datastorage", title: "500 MB = 0.5 GB"},
+		{query: "5 gb to mb", calculator: "datastorage", title: "5 gb = 5,000 mb"},
+		{query: "123456789 B to auto", calculator: "datastorage", title: "123,456,789 B = 123.456789 MB"},
+		{query: "100 Mbps in MB/s", calculator: "datastorage", title: "100 Mbps = 12.5 MB/s"},
+		{query: "4.7 GB at 100 Mbps", calculator: "datastorage", title: "4.7 GB @ 100 Mbps ≈ 6 分钟 16 秒"},
+		{query: "500 GB", calculator: "datastorage", title: "500 GB = 500,000,000 KB"},
+	})
+}
@@ -0,0 +1,368 @@
+// calculate-anything/pkg/calculators/dates.go
+package calculators
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 日期表达式的语法 (不区分大小写):
+//
+//	日期项:   now, today, tomorrow, yesterday, friday, next friday, last monday, this sunday,
+//	          next week/month/quarter/year, start of month, end of next quarter, start of year of 2020-06-01,
+//	          2025-01-31, 2025/01/31, 31.01.2025, 01/31/2025, 31 december [2025], dec 31[, 2025],
+//	          以上任意一项后接时刻, e.g. "tomorrow 3pm", "2025-01-31 15:30"
+//	日期表达式: 日期项后接任意个偏移, e.g. "2025-01-31 + 1 month", "now - 2 hours + 30 min"；
+//	          省略日期项时以 now 为基准, e.g. "+3 days"；workdays 偏移按工作日历跳过周末和节假日
+var (
+	// 末尾的一个偏移, e.g. " + 3 days"
+	dateOffsetRegex = regexp.MustCompile(`(?i)\s*([+\-])\s*(\d+)\s*(workdays?|business\s+days?|bdays?|years?|yrs?|y|months?|mos?|weeks?|wks?|w|days?|d|hours?|hrs?|h|minutes?|mins?|seconds?|secs?|s)\s*$`)
+	// 末尾的时刻, e.g. "15:30", "3pm", "at 3:30 pm"
+	timeOfDayRegex = regexp.MustCompile(`(?i)(?:^|\s+)(?:at\s+)?(\d{1,2})(?::(\d{2}))?(?::(\d{2}))?\s*(am|pm)?$`)
+	// 星期, 可带 next/last/this
+	weekdayRegex = regexp.MustCompile(`^(?:(next|last|this)\s+)?(mon|tue|tues|wed|thu|thur|thurs|fri|sat|sun)(?:day|nesday|sday|urday)?$`)
+	// next/last/this 加一个日历单位
+	relativePeriodRegex = regexp.MustCompile(`^(next|last|this)\s+(day|week|month|quarter|year)$`)
+	// start/end of 一个日历单位，可指定所在的日期
+	boundaryRegex = regexp.MustCompile(`^(start|beginning|end)\s+of\s+(?:(this|next|last)\s+)?(day|week|month|quarter|year)(?:\s+of\s+(.+))?$`)
+	// 序数后缀, e.g. "31st"
+	ordinalRegex = regexp.MustCompile(`(\d+)(?:st|nd|rd|th)\b`)
+	// 包含四位数年份
+	yearRegex = regexp.MustCompile(`\b\d{4}\b`)
+	// 日期差 "X - Y" 中的减号两侧必须有空格，以免和 "2025-01-31" 混淆
+	dateMinusRegex = regexp.MustCompile(`\s+-\s+`)
+)
+
+// 带年份的绝对日期格式
+var dateLayouts = []string{
+	"2006-01-02", "2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02T15:04:05",
+	"2006/01/02", "2006/01/02 15:04",
+	"02.01.2006", "2.1.2006", "02.01.2006 15:04",
+	"01/02/2006", "1/2/2006", "01/02/2006 15:04",
+	"2 January 2006", "2 Jan 2006", "January 2 2006", "Jan 2 2006", "January 2, 2006", "Jan 2, 2006",
+}
+
+// 省略年份的日期格式，年份取当年
+var dateLayoutsNoYear = []string{"2 January", "2 Jan", "January 2", "Jan 2"}
+
+// 中文星期名称，按 time.Weekday 排列
+var weekdayNames = [...]string{"星期日", "星期一", "星期二", "星期三", "星期四", "星期五", "星期六"}
+
+var weekdayPrefixes = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// dateOffset 是日期表达式中的一个偏移, e.g. "+ 1 month"。
+type dateOffset struct {
+	amount int
+	unit   string // workday, year, month, week, day, hour, minute, second
+}
+
+// parseDate 解析一个日期表达式，相对日期以 now 及其时区为基准。
+// cal 用于 workdays 偏移，为 nil 时不支持工作日偏移。
+func parseDate(s string, now time.Time, cal *businessCalendar) (time.Time, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+
+	// 从末尾逐个剥离偏移，再按原顺序应用
+	var offsets []dateOffset
+	for {
+		m := dateOffsetRegex.FindStringSubmatchIndex(s)
+		if m == nil {
+			break
+		}
+		amount, _ := strconv.Atoi(s[m[4]:m[5]])
+		if s[m[2]:m[3]] == "-" {
+			amount = -amount
+		}
+		offsets = append([]dateOffset{{amount, normalizeDateUnit(s[m[6]:m[7]])}}, offsets...)
+		s = strings.TrimSpace(s[:m[0]])
+	}
+
+	var t time.Time
+	if s == "" {
+		if len(offsets) == 0 {
+			return time.Time{}, fmt.Errorf("缺少日期")
+		}
+		t = now
+	} else {
+		var ok bool
+		if t, ok = parseDateTerm(s, now); !ok {
+			return time.Time{}, fmt.Errorf("无法识别的日期: %s", s)
+		}
+	}
+
+	for _, o := range offsets {
+		if o.unit != "workday" {
+			t = addDateUnit(t, o.amount, o.unit)
+		} else if cal != nil {
+			var err error
+			if t, err = cal.addWorkdays(t, o.amount); err != nil {
+				return time.Time{}, err
+			}
+		} else {
+			return time.Time{}, fmt.Errorf("这里不支持工作日偏移")
+		}
+	}
+	return t, nil
+}
+
+// parseDateTerm 解析不含偏移的日期项，可带时刻。
+func parseDateTerm(s string, now time.Time) (time.Time, bool) {
+	s = strings.Join(strings.Fields(strings.TrimPrefix(s, "the ")), " ")
+	s = ordinalRegex.ReplaceAllString(s, "$1")
+	if t, ok := parseDateOnly(s, now); ok {
+		return t, true
+	}
+
+	// 末尾带时刻: 单独的数字必须带 am/pm 或冒号，避免把 "jan 2" 中的 2 当作时刻
+	m := timeOfDayRegex.FindStringSubmatchIndex(s)
+	if m == nil || (m[4] < 0 && m[8] < 0) {
+		return time.Time{}, false
+	}
+	hour, _ := strconv.Atoi(s[m[2]:m[3]])
+	minute, second := 0, 0
+	if m[4] >= 0 {
+		minute, _ = strconv.Atoi(s[m[4]:m[5]])
+	}
+	if m[6] >= 0 {
+		second, _ = strconv.Atoi(s[m[6]:m[7]])
+	}
+	if m[8] >= 0 {
+		if hour < 1 || hour > 12 {
+			return time.Time{}, false
+		}
+		hour %= 12
+		if s[m[8]:m[9]] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 || second > 59 {
+		return time.Time{}, false
+	}
+
+	day := startOfDay(now)
+	if rest := strings.TrimSpace(s[:m[0]]); rest != "" {
+		var ok bool
+		if day, ok = parseDateOnly(rest, now); !ok {
+			return time.Time{}, false
+		}
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, day.Location()), true
+}
+
+// parseDateOnly 解析不带单独时刻的日期项。
+func parseDateOnly(s string, now time.Time) (time.Time, bool) {
+	today := startOfDay(now)
+	switch s {
+	case "now":
+		return now, true
+	case "today":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "yesterday":
+		return today.AddDate(0, 0, -1), true
+	case "noon":
+		return today.Add(12 * time.Hour), true
+	case "midnight":
+		return today, true
+	}
+
+	if m := weekdayRegex.FindStringSubmatch(s); m != nil {
+		return weekdayDate(today, weekdayPrefixes[m[2][:3]], m[1]), true
+	}
+
+	if m := relativePeriodRegex.FindStringSubmatch(s); m != nil {
+		return addDateUnit(today, relativeShift(m[1]), m[2]), true
+	}
+
+	if m := boundaryRegex.FindStringSubmatch(s); m != nil {
+		base := today
+		if m[4] != "" {
+			var ok bool
+			if base, ok = parseDateTerm(m[4], now); !ok {
+				return time.Time{}, false
+			}
+		}
+		base = addDateUnit(base, relativeShift(m[2]), m[3])
+		start := startOfPeriod(base, m[3])
+		if m[1] == "end" {
+			// 周期的最后一秒, e.g. "end of month" 是当月最后一天的 23:59:59
+			return addDateUnit(start, 1, m[3]).Add(-time.Second), true
+		}
+		return start, true
+	}
+
+	if t, err := time.Parse(time.RFC3339, strings.ToUpper(s)); err == nil {
+		return t.In(now.Location()), true
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, true
+		}
+	}
+	for _, layout := range dateLayoutsNoYear {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return time.Date(now.Year(), t.Month(), t.Day(), 0, 0, 0, 0, now.Location()), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// weekdayDate 返回相对 today 的某个星期几:
+// 不带修饰时是今天或之后最近的一天，next 是今天之后最近的一天，
+// last 是今天之前最近的一天，this 是本周 (周一至周日) 中的那一天。
+func weekdayDate(today time.Time, wd time.Weekday, modifier string) time.Time {
+	diff := int(wd - today.Weekday())
+	switch modifier {
+	case "next":
+		if diff <= 0 {
+			diff += 7
+		}
+	case "last":
+		if diff >= 0 {
+			diff -= 7
+		}
+	case "this":
+		return startOfPeriod(today, "week").AddDate(0, 0, (int(wd)+6)%7)
+	default:
+		if diff < 0 {
+			diff += 7
+		}
+	}
+	return today.AddDate(0, 0, diff)
+}
+
+// relativeShift 将 next/last/this 转换为偏移的周期数。
+func relativeShift(modifier string) int {
+	switch modifier {
+	case "next":
+		return 1
+	case "last":
+		return -1
+	}
+	return 0
+}
+
+// normalizeDateUnit 将单位的各种写法统一为 workday, year, month, week, day, hour, minute, second。
+func normalizeDateUnit(unit string) string {
+	switch strings.TrimSuffix(strings.Join(strings.Fields(unit), " "), "s") {
+	case "workday", "business day", "bday":
+		return "workday"
+	case "year", "yr", "y":
+		return "year"
+	case "month", "mo":
+		return "month"
+	case "week", "wk", "w":
+		return "week"
+	case "day", "d":
+		return "day"
+	case "hour", "hr", "h":
+		return "hour"
+	case "minute", "min":
+		return "minute"
+	}
+	return "second"
+}
+
+// addDateUnit 给 t 加上 n 个日历单位。加减月份和年份时日期超出目标月份的天数会被截断到月末,
+// e.g. 2025-01-31 + 1 month = 2025-02-28，而不是 time.AddDate 的 2025-03-03。
+func addDateUnit(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case "year":
+		return addMonths(t, 12*n)
+	case "quarter":
+		return addMonths(t, 3*n)
+	case "month":
+		return addMonths(t, n)
+	case "week":
+		return t.AddDate(0, 0, 7*n)
+	case "day":
+		return t.AddDate(0, 0, n)
+	case "hour":
+		return t.Add(time.Duration(n) * time.Hour)
+	case "minute":
+		return t.Add(time.Duration(n) * time.Minute)
+	}
+	return t.Add(time.Duration(n) * time.Second)
+}
+
+// addMonths 加减月份，日期截断到目标月份的最后一天。
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location()).AddDate(0, n, 0)
+	day := t.Day()
+	if last := daysInMonth(first); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// daysInMonth 返回 t 所在月份的天数。
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// startOfDay 返回 t 当天的 00:00。
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfPeriod 返回 t 所在日历周期的开始时刻，周从周一开始 (ISO 8601)。
+func startOfPeriod(t time.Time, unit string) time.Time {
+	day := startOfDay(t)
+	switch unit {
+	case "week":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return day.AddDate(0, 0, 1-day.Day())
+	case "quarter":
+		return time.Date(day.Year(), (day.Month()-1)/3*3+1, 1, 0, 0, 0, 0, day.Location())
+	case "year":
+		return time.Date(day.Year(), 1, 1, 0, 0, 0, 0, day.Location())
+	}
+	return day
+}
+
+// calendarDays 返回从 a 到 b 相差的日历天数，与时刻和夏令时无关。
+func calendarDays(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	// 用 Unix 秒数相减: time.Duration 最多约 292 年，更长的间隔会被截断
+	return int((db.Unix() - da.Unix()) / 86400)
+}
+
+// calendarDiff 将 a 到 b (a <= b) 的间隔拆分为年、月、日。
+func calendarDiff(a, b time.Time) (years, months, days int) {
+	months = (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+	if addMonths(startOfDay(a), months).After(startOfDay(b)) {
+		months--
+	}
+	days = calendarDays(addMonths(a, months), b)
+	return months / 12, months % 12, days
+}
+
+// relativeTimeText 将时间差转换为 "3 天前"、"2 小时后" 这类文本，使用最大的非零单位。
+func relativeTimeText(d time.Duration) string {
+	suffix := "后"
+	if d < 0 {
+		d, suffix = -d, "前"
+	}
+	days := int(d.Hours() / 24)
+	switch {
+	case d < time.Minute:
+		return "刚刚"
+	case d < time.Hour:
+		return fmt.Sprintf("%d 分钟%s", int(d.Minutes()), suffix)
+	case days < 1:
+		return fmt.Sprintf("%d 小时%s", int(d.Hours()), suffix)
+	case days < 31:
+		return fmt.Sprintf("%d 天%s", days, suffix)
+	case days < 365:
+		return fmt.Sprintf("%d 个月%s", days*12/365, suffix)
+	}
+	return fmt.Sprintf("%d 年%s", days/365, suffix)
+}
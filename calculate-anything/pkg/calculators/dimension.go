@@ -0,0 +1,390 @@
+// calculate-anything/pkg/calculators/dimension.go
+package calculators
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 量纲向量中各基本量的下标。角度虽然在 SI 中是无量纲的，
+// 这里仍单独作为一维，以免弧度与纯数字之间发生无意义的转换。
+const (
+	dimLength      = iota // 长度 (m)
+	dimMass               // 质量 (kg)
+	dimTime               // 时间 (s)
+	dimCurrent            // 电流 (A)
+	dimTemperature        // 温度 (K)
+	dimAmount             // 物质的量 (mol)
+	dimLuminosity         // 发光强度 (cd)
+	dimAngle              // 角度 (rad)
+	dimCount
+)
+
+// dimensionSymbols 是各基本量的 SI 单位符号，用于生成未命名量纲的描述。
+var dimensionSymbols = [dimCount]string{"m", "kg", "s", "A", "K", "mol", "cd", "rad"}
+
+// Dimension 是一个量纲向量，每个分量是对应基本量的指数。
+// 例如速度为 {1, 0, -1, ...}（m·s⁻¹），能量为 {2, 1, -2, ...}（kg·m²·s⁻²）。
+type Dimension [dimCount]int8
+
+// Mul 返回两个量纲相乘的结果。
+func (d Dimension) Mul(o Dimension) Dimension {
+	for i := range d {
+		d[i] += o[i]
+	}
+	return d
+}
+
+// Div 返回两个量纲相除的结果。
+func (d Dimension) Div(o Dimension) Dimension {
+	for i := range d {
+		d[i] -= o[i]
+	}
+	return d
+}
+
+// Pow 返回量纲的 n 次幂。
+func (d Dimension) Pow(n int) Dimension {
+	for i := range d {
+		d[i] *= int8(n)
+	}
+	return d
+}
+
+// Inverse 返回量纲的倒数。
+func (d Dimension) Inverse() Dimension {
+	return d.Pow(-1)
+}
+
+// String 返回量纲的 SI 表示, e.g. "kg·m^2·s^-2"。
+func (d Dimension) String() string {
+	var parts []string
+	for _, i := range []int{dimMass, dimLength, dimTime, dimCurrent, dimTemperature, dimAmount, dimLuminosity, dimAngle} {
+		switch d[i] {
+		case 0:
+		case 1:
+			parts = append(parts, dimensionSymbols[i])
+		default:
+			parts = append(parts, fmt.Sprintf("%s^%d", dimensionSymbols[i], d[i]))
+		}
+	}
+	if len(parts) == 0 {
+		return "1"
+	}
+	return strings.Join(parts, "·")
+}
+
+// newDimension 根据 "基本量下标 -> 指数" 的键值对构造量纲。
+func newDimension(exponents ...int) Dimension {
+	var d Dimension
+	for i := 0; i+1 < len(exponents); i += 2 {
+		d[exponents[i]] = int8(exponents[i+1])
+	}
+	return d
+}
+
+// 常用的导出量纲
+var (
+	dimNone         = Dimension{}
+	dimL            = newDimension(dimLength, 1)
+	dimM            = newDimension(dimMass, 1)
+	dimT            = newDimension(dimTime, 1)
+	dimTheta        = newDimension(dimTemperature, 1)
+	dimRad          = newDimension(dimAngle, 1)
+	dimArea         = dimL.Pow(2)
+	dimVolume       = dimL.Pow(3)
+	dimSpeed        = dimL.Div(dimT)
+	dimAcceleration = dimSpeed.Div(dimT)
+	dimForce        = dimM.Mul(dimAcceleration)
+	dimEnergy       = dimForce.Mul(dimL)
+	dimPower        = dimEnergy.Div(dimT)
+	dimPressure     = dimForce.Div(dimArea)
+	dimFrequency    = dimT.Inverse()
+	dimDensity      = dimM.Div(dimVolume)
+)
+
+// dimensionTypes 为常见量纲提供可读的类型名，它同时决定了 Unit.Type 的取值。
+// 注意 N·m（力矩）与焦耳量纲相同，L/100km 的量纲是面积，这是量纲分析的固有结果。
+var dimensionTypes = map[Dimension]string{
+	dimNone:             "dimensionless",
+	dimL:                "length",
+	dimArea:             "area",
+	dimVolume:           "volume",
+	dimM:                "mass",
+	dimT:                "time",
+	dimSpeed:            "speed",
+	dimAcceleration:     "acceleration",
+	dimForce:            "force",
+	dimEnergy:           "energy",
+	dimPower:            "power",
+	dimPressure:         "pressure",
+	dimTheta:            "temperature",
+	dimRad:              "rotation",
+	dimFrequency:        "frequency",
+	dimDensity:          "density",
+	dimL.Pow(-2):        "fuel economy",
+	dimEnergy.Div(dimL): "energy per distance",
+}
+
+// dimensionType 返回量纲对应的类型名，未命名的量纲使用其 SI 表示。
+func dimensionType(d Dimension) string {
+	if name, ok := dimensionTypes[d]; ok {
+		return name
+	}
+	return d.String()
+}
+
+// siPrefix 是一个 SI 词头。
+type siPrefix struct {
+	Symbol string
+	Factor float64
+}
+
+// siPrefixes 按符号长度从长到短排列，以便 "da" 优先于 "d" 匹配。
+var siPrefixes = []siPrefix{
+	{"da", 1e1},
+	{"Y", 1e24}, {"Z", 1e21}, {"E", 1e18}, {"P", 1e15}, {"T", 1e12}, {"G", 1e9}, {"M", 1e6},
+	{"k", 1e3}, {"h", 1e2}, {"d", 1e-1}, {"c", 1e-2}, {"m", 1e-3}, {"μ", 1e-6}, {"µ", 1e-6},
+	{"u", 1e-6}, {"n", 1e-9}, {"p", 1e-12}, {"f", 1e-15}, {"a", 1e-18},
+}
+
+// lowerPrefixes 在大小写不敏感的回退查找中使用，符号先经过 foldUnitCase 处理。
+// m/M 和 p/P 的大小写区分毫与兆、皮与拍，因此保留大小写；其余词头没有歧义，只用小写。
+var lowerPrefixes = []siPrefix{
+	{"da", 1e1},
+	{"M", 1e6}, {"P", 1e15},
+	{"k", 1e3}, {"h", 1e2}, {"d", 1e-1}, {"c", 1e-2}, {"m", 1e-3}, {"μ", 1e-6}, {"u", 1e-6},
+	{"n", 1e-9}, {"p", 1e-12}, {"g", 1e9}, {"t", 1e12},
+}
+
+// foldUnitCase 将符号转为小写，但保留开头的 m/M 或 p/P, e.g. "MHZ" -> "Mhz"。
+func foldUnitCase(s string) string {
+	lower := strings.ToLower(s)
+	if s != "" && strings.ContainsRune("mMpP", rune(s[0])) {
+		return s[:1] + lower[1:]
+	}
+	return lower
+}
+
+// unitFactorRegex 匹配单位表达式中的一个因子: 可选的数字乘数、单位符号和可选的指数,
+// e.g. "100km", "cm3", "s^-2", "m²"。
+var unitFactorRegex = regexp.MustCompile(`^([\d.]*)([^\d^²³⁻]+?)(?:\^?(-?\d+)|([²³]))?$`)
+
+// unitExpressionRegex 判断符号是否需要按表达式解析（包含运算符、数字乘数或指数）。
+var unitExpressionRegex = regexp.MustCompile(`[/*·⋅^²³]|^\d|\D\d+$`)
+
+// resolveUnit 返回符号可能代表的所有单位，按优先级排序。
+// 多个候选说明符号存在歧义 (e.g. "nm" 可能是纳米或牛·米)，由调用方按量纲选择。
+func resolveUnit(symbol string) []Unit {
+	s := strings.TrimSpace(symbol)
+	if s == "" {
+		return nil
+	}
+	if unitExpressionRegex.MatchString(s) {
+		if u, err := parseUnitExpression(s); err == nil {
+			return []Unit{u}
+		}
+	}
+	return resolveSimpleUnit(s)
+}
+
+// resolveSimpleUnit 解析不含运算符的单位符号：先精确匹配（含 SI 词头），
+// 再加上 unitAliases 中的别名，最后才进行大小写不敏感的匹配。
+// 大小写只在没有歧义时忽略，"mW" 总是毫瓦，"Mm" 总是兆米。
+func resolveSimpleUnit(s string) []Unit {
+	var candidates []Unit
+	add := func(u Unit) {
+		for _, c := range candidates {
+			if c.Name == u.Name {
+				return
+			}
+		}
+		candidates = append(candidates, u)
+	}
+
+	if u, ok := lookupPrefixed(s, unitMap, siPrefixes); ok {
+		add(u)
+	}
+	for _, alias := range unitAliases[strings.ToLower(s)] {
+		// 别名指向自身时 (e.g. "°C" 经小写查到 "°C") 已在精确匹配中处理，避免递归
+		if alias == s {
+			continue
+		}
+		// 大小写不同的别名只用于全小写的输入：用户写了 "mW" 时不能按 "mw" -> "MW" 当作兆瓦
+		if s != strings.ToLower(s) && strings.EqualFold(alias, s) && alias[:1] != s[:1] {
+			continue
+		}
+		if u, err := parseUnitExpression(alias); err == nil {
+			add(u)
+		}
+	}
+	if len(candidates) == 0 {
+		if u, ok := unitMapLower[strings.ToLower(s)]; ok {
+			add(u)
+		} else if u, ok := lookupPrefixed(foldUnitCase(s), unitMapLower, lowerPrefixes); ok {
+			add(u)
+		}
+	}
+	return candidates
+}
+
+// lookupPrefixed 在单位表中查找符号，找不到时尝试拆分为 "词头 + 可加词头的单位"。
+func lookupPrefixed(s string, table map[string]Unit, prefixes []siPrefix) (Unit, bool) {
+	if u, ok := table[s]; ok {
+		return u, true
+	}
+	for _, p := range prefixes {
+		rest := strings.TrimPrefix(s, p.Symbol)
+		if rest == s || rest == "" {
+			continue
+		}
+		if base, ok := table[rest]; ok && base.Prefixable {
+			base.Name = p.Symbol + base.Name
+			base.ToSI *= p.Factor
+			return base, true
+		}
+	}
+	return Unit{}, false
+}
+
+// parseUnitExpression 解析由乘除和指数组成的单位表达式，
+// e.g. "km/h", "kWh/100km", "N·m", "g/cm3", "m/s^2"。
+// 第一个 "/" 之后的所有因子都位于分母，因此 "W/m2/K" 等价于 W/(m²·K)。
+func parseUnitExpression(expr string) (Unit, error) {
+	var factors []string
+	var inDenominator []bool
+	denominator := false
+	start := 0
+	runes := []rune(expr)
+	for i, r := range runes {
+		if r == '/' || r == '*' || r == '·' || r == '⋅' {
+			factors = append(factors, string(runes[start:i]))
+			inDenominator = append(inDenominator, denominator)
+			if r == '/' {
+				denominator = true
+			}
+			start = i + 1
+		}
+	}
+	factors = append(factors, string(runes[start:]))
+	inDenominator = append(inDenominator, denominator)
+
+	result := Unit{Name: expr, ToSI: 1}
+	for i, f := range factors {
+		m := unitFactorRegex.FindStringSubmatch(strings.TrimSpace(f))
+		if m == nil {
+			return Unit{}, fmt.Errorf("无效的单位表达式: %s", expr)
+		}
+		multiplier := 1.0
+		if m[1] != "" {
+			v, err := strconv.ParseFloat(m[1], 64)
+			if err != nil || v == 0 {
+				return Unit{}, fmt.Errorf("无效的单位表达式: %s", expr)
+			}
+			multiplier = v
+		}
+		exponent := 1
+		switch {
+		case m[3] != "":
+			exponent, _ = strconv.Atoi(m[3])
+		case m[4] == "²":
+			exponent = 2
+		case m[4] == "³":
+			exponent = 3
+		}
+
+		candidates := resolveSimpleUnit(m[2])
+		if len(candidates) == 0 {
+			return Unit{}, fmt.Errorf("未知的单位: %s", m[2])
+		}
+		base := candidates[0]
+
+		// 单独的单位直接返回，保留温度等单位的仿射偏移
+		if len(factors) == 1 && multiplier == 1 && exponent == 1 {
+			return base, nil
+		}
+		if base.Offset != 0 {
+			return Unit{}, fmt.Errorf("%s 不能参与复合单位运算", m[2])
+		}
+
+		factor := multiplier * math.Pow(base.ToSI, float64(exponent))
+		dim := base.Dim.Pow(exponent)
+		if inDenominator[i] {
+			result.ToSI /= factor
+			result.Dim = result.Dim.Div(dim)
+		} else {
+			result.ToSI *= factor
+			result.Dim = result.Dim.Mul(dim)
+		}
+	}
+	result.Type = dimensionType(result.Dim)
+	return result, nil
+}
+
+// matchUnits 在两个符号的候选单位中选出量纲兼容的一对（相同或互为倒数），
+// 优先选择排序靠前的候选，从而解决 "nm"（纳米/牛·米）、"h"（小时/手）这类歧义。
+func matchUnits(fromSymbol, toSymbol string) (Unit, Unit, error) {
+	fromCandidates := resolveUnit(fromSymbol)
+	toCandidates := resolveUnit(toSymbol)
+	if len(fromCandidates) == 0 {
+		return Unit{}, Unit{}, fmt.Errorf("未知的源单位: %s", fromSymbol)
+	}
+	if len(toCandidates) == 0 {
+		return Unit{}, Unit{}, fmt.Errorf("未知的目标单位: %s", toSymbol)
+	}
+
+	bestScore := -1
+	var bestFrom, bestTo Unit
+	for i, f := range fromCandidates {
+		for j, t := range toCandidates {
+			score := i + j
+			if f.Dim != t.Dim {
+				if f.Dim != t.Dim.Inverse() || f.Offset != 0 || t.Offset != 0 {
+					continue
+				}
+				// 倒数转换 (e.g. L/100km <-> mpg) 优先级低于直接转换
+				score += len(fromCandidates) + len(toCandidates)
+			}
+			if bestScore < 0 || score < bestScore {
+				bestScore, bestFrom, bestTo = score, f, t
+			}
+		}
+	}
+	if bestScore < 0 {
+		return Unit{}, Unit{}, fmt.Errorf("无法在不同类型单位间转换: %s -> %s", fromCandidates[0].Type, toCandidates[0].Type)
+	}
+	return bestFrom, bestTo, nil
+}
+
+// convertUnits 将数值从一个单位转换为另一个单位。
+// 量纲相同时做线性（温度为仿射）转换，量纲互为倒数时做倒数转换。
+func convertUnits(amount float64, from, to Unit) (float64, error) {
+	valueInSI := amount*from.ToSI + from.Offset
+	if from.Dim == to.Dim {
+		result := (valueInSI - to.Offset) / to.ToSI
+		if from.Offset != 0 || to.Offset != 0 {
+			// 经开尔文中转的仿射转换会带来浮点误差, e.g. 0 °C -> 31.9999999999999 °F
+			result = roundSignificant(result, 12)
+		}
+		return result, nil
+	}
+	if from.Dim == to.Dim.Inverse() {
+		if valueInSI == 0 {
+			return 0, fmt.Errorf("无法对 0 做倒数转换")
+		}
+		return 1 / valueInSI / to.ToSI, nil
+	}
+	return 0, fmt.Errorf("无法在不同类型单位间转换: %s -> %s", from.Type, to.Type)
+}
+
+// roundSignificant 将数值舍入到 digits 位有效数字。
+func roundSignificant(v float64, digits int) float64 {
+	r, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', digits, 64), 64)
+	if err != nil {
+		return v
+	}
+	return r
+}
@@ -0,0 +1,406 @@
+// calculate-anything/pkg/calculators/duration.go
+package calculators
+
+import (
+	"calculate-anything/pkg/format"
+	"calculate-anything/pkg/parser"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationQuery 是时长换算和时长运算查询的类型
+const durationQuery parser.QueryType = "duration"
+
+var (
+	// "<时长表达式> in|to|as <目标>", 目标是时间单位或 h:m:s、human 这类格式
+	durationTargetRegex = regexp.MustCompile(`(?i)^(.+?)\s+(?:in|to|as|into)\s+(\S+)$`)
+	// 时长表达式中的运算符，"x" 只有前后都是空白时才算作乘号
+	durationOperatorRegex = regexp.MustCompile(`\s*([+\-*/×÷]|\s[xX]\s)\s*`)
+	// 时钟形式的时长, e.g. "1:30" (时:分), "1:30:00", "0:00:01.5"
+	durationClockRegex = regexp.MustCompile(`^(\d+):([0-5]?\d)(?::([0-5]?\d(?:[.,]\d+)?))?$`)
+	// 时长的一个分量, e.g. "1h", "30 min", "2 days"；多个分量可以直接相连 ("1h30m")
+	durationPartRegex = regexp.MustCompile(`^(\d[\d.,]*|\.\d+)\s*([a-zA-Zµμ\p{Han}]+)\s*(?:and\s+)?`)
+)
+
+// durationUnits 将时长单位 (小写) 映射为秒数。"m" 在时长中总是分钟。
+// 月和年的长度不固定，不属于时长，留给单位换算处理。
+var durationUnits = map[string]float64{
+	"ms": 0.001, "msec": 0.001, "millisecond": 0.001, "milliseconds": 0.001, "毫秒": 0.001,
+	"s": 1, "sec": 1, "secs": 1, "second": 1, "seconds": 1, "秒": 1,
+	"m": 60, "min": 60, "mins": 60, "minute": 60, "minutes": 60, "分": 60, "分钟": 60,
+	"h": 3600, "hr": 3600, "hrs": 3600, "hour": 3600, "hours": 3600, "小时": 3600,
+	"d": 86400, "day": 86400, "days": 86400, "天": 86400,
+	"w": 604800, "wk": 604800, "wks": 604800, "week": 604800, "weeks": 604800, "周": 604800,
+}
+
+// durationUnitSymbols 是目标单位在结果中显示的符号，与 unitMap 中的时间单位一致。
+var durationUnitSymbols = map[float64]string{
+	0.001: "ms", 1: "s", 60: "min", 3600: "h", 86400: "day", 604800: "week",
+}
+
+// 结果的输出格式
+const (
+	durationFormatClock = "clock" // 时:分:秒, e.g. "13:40:00"
+	durationFormatHuman = "human" // 可读文本, e.g. "13 小时 40 分钟"
+)
+
+// durationFormats 是目标中表示输出格式的写法
+var durationFormats = map[string]string{
+	"h:m:s": durationFormatClock, "hh:mm:ss": durationFormatClock, "h:mm:ss": durationFormatClock,
+	"hms": durationFormatClock, "clock": durationFormatClock,
+	"human": durationFormatHuman, "words": durationFormatHuman, "text": durationFormatHuman,
+}
+
+// durationValue 是时长表达式中的一个值: 时长 (秒) 或普通数字。
+type durationValue struct {
+	v   float64
+	dur bool
+}
+
+// durationExpr 是解析后的时长查询。
+type durationExpr struct {
+	input  string  // 去掉目标后的表达式文本
+	target string  // 目标的原始写法，为空表示未指定
+	unit   float64 // 目标单位的秒数，目标是输出格式时为 0
+	format string  // 目标输出格式, 见 durationFormats
+
+	operands  []durationValue
+	operators []string
+	compound  bool // 是否包含时钟形式或多分量时长 ("1h30m", "2 days 4 hours")
+}
+
+// durationCalculator 解析、换算时长并支持时长的加减乘除，
+// e.g. "3h25m * 4", "7384 s in h:m:s", "2 days 4 hours - 90 min"。
+type durationCalculator struct{ baseCalculator }
+
+func init() { Register(&durationCalculator{}) }
+
+func (*durationCalculator) Name() string  { return "duration" }
+func (*durationCalculator) Priority() int { return 18 }
+
+func (*durationCalculator) Examples() []string {
+	return []string{"3h25m * 4", "7384 s in h:m:s", "1:30:00 + 45m", "2 days 4 hours in hours"}
+}
+
+// Match 接受时长运算、多分量时长，以及目标是时间单位或时长格式的换算。
+// 单个时长且没有目标的查询 (e.g. "5 min", "12:30") 不属于这里。
+func (*durationCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	expr, ok := parseDurationExpr(query, ctx.Format)
+	if !ok {
+		return nil
+	}
+	if len(expr.operators) == 0 && expr.target == "" && !expr.compound {
+		return nil
+	}
+	if len(expr.operators) == 0 && expr.target == "" && durationClockRegex.MatchString(expr.input) {
+		// 单独的 "12:30" 更可能是时刻而不是时长
+		return nil
+	}
+	return &parser.ParsedQuery{Type: durationQuery, Input: query, To: expr.target}
+}
+
+// Compute 计算表达式，并以目标单位、时:分:秒和可读文本给出结果。
+func (*durationCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	nf := ctx.Format
+	expr, ok := parseDurationExpr(p.Input, nf)
+	if !ok {
+		return nil, fmt.Errorf("无法解析时长: %s", p.Input)
+	}
+	value, err := expr.eval()
+	if err != nil {
+		return nil, err
+	}
+
+	// 时长相除得到的是倍数, e.g. "1h / 15min" = 4
+	if !value.dur {
+		if expr.target != "" {
+			return nil, fmt.Errorf("结果是数字而不是时长，无法转换为 %s", expr.target)
+		}
+		return []Result{{
+			Value:    value.v,
+			Title:    fmt.Sprintf("%s = %s", expr.input, nf.Format(value.v, -1)),
+			Subtitle: fmt.Sprintf("复制 '%s'", nf.Plain(value.v, -1)),
+			Arg:      nf.Plain(value.v, -1),
+			Icon:     "clock.png",
+		}}, nil
+	}
+
+	secs := value.v
+	if math.IsNaN(secs) || math.Abs(secs) > maxDurationSeconds {
+		return nil, fmt.Errorf("时长超过 100 万年")
+	}
+	clock := formatClockDuration(secs)
+	human := formatHumanDuration(secs, nf)
+	clockRow := Result{
+		Value:    secs,
+		Unit:     "s",
+		Title:    fmt.Sprintf("%s = %s", expr.input, clock),
+		Subtitle: fmt.Sprintf("时:分:秒 · 复制 '%s'", clock),
+		Arg:      clock,
+		Icon:     "clock.png",
+	}
+	humanRow := Result{
+		Value:    secs,
+		Unit:     "s",
+		Title:    fmt.Sprintf("%s = %s", expr.input, human),
+		Subtitle: fmt.Sprintf("复制 '%s'", human),
+		Arg:      human,
+		Icon:     "clock.png",
+	}
+	// time.Duration 最长约 292 年，更长的时长没有 Go 写法
+	if math.Abs(secs)*1e9 <= math.MaxInt64 {
+		humanRow.Modifiers = []Modifier{{
+			Key:      "cmd",
+			Subtitle: fmt.Sprintf("复制 Go 格式 '%s'", formatGoDuration(secs)),
+			Arg:      formatGoDuration(secs),
+		}}
+	}
+
+	// 未指定目标单位时，按时长大小选择小时、分钟或秒
+	unit := expr.unit
+	if unit == 0 {
+		switch abs := math.Abs(secs); {
+		case abs >= 3600:
+			unit = 3600
+		case abs >= 60:
+			unit = 60
+		default:
+			unit = 1
+		}
+	}
+	converted := secs / unit
+	symbol := durationUnitSymbols[unit]
+	unitRow := Result{
+		Value:    converted,
+		Unit:     symbol,
+		Title:    fmt.Sprintf("%s = %s %s", expr.input, nf.Format(roundDuration(converted), -1), symbol),
+		Subtitle: fmt.Sprintf("复制 '%s'", nf.Plain(roundDuration(converted), -1)),
+		Arg:      nf.Plain(roundDuration(converted), -1),
+		Icon:     "clock.png",
+	}
+
+	switch {
+	case expr.unit != 0:
+		return []Result{unitRow, clockRow, humanRow}, nil
+	case expr.format == durationFormatHuman:
+		return []Result{humanRow, clockRow, unitRow}, nil
+	default:
+		return []Result{clockRow, humanRow, unitRow}, nil
+	}
+}
+
+// parseDurationExpr 将查询拆分为表达式和目标，并解析表达式中的每个操作数。
+// 至少要有一个操作数是时长。
+func parseDurationExpr(query string, nf *format.Formatter) (*durationExpr, bool) {
+	expr := &durationExpr{input: strings.TrimSpace(query)}
+	if m := durationTargetRegex.FindStringSubmatch(expr.input); m != nil {
+		target := strings.ToLower(m[2])
+		if f, ok := durationFormats[target]; ok {
+			expr.format = f
+		} else if secs, ok := durationUnits[target]; ok {
+			expr.unit = secs
+		} else {
+			return nil, false
+		}
+		expr.input, expr.target = strings.TrimSpace(m[1]), m[2]
+	}
+
+	// 运算符把表达式切分为操作数，"-" 开头的负数不支持
+	indexes := durationOperatorRegex.FindAllStringSubmatchIndex(expr.input, -1)
+	start := 0
+	for _, idx := range indexes {
+		expr.operators = append(expr.operators, strings.TrimSpace(expr.input[idx[2]:idx[3]]))
+		if !expr.addOperand(expr.input[start:idx[0]], nf) {
+			return nil, false
+		}
+		start = idx[1]
+	}
+	if !expr.addOperand(expr.input[start:], nf) {
+		return nil, false
+	}
+
+	for _, operand := range expr.operands {
+		if operand.dur {
+			return expr, true
+		}
+	}
+	return nil, false
+}
+
+// addOperand 解析一个操作数: 时钟形式的时长、由一个或多个分量组成的时长，或普通数字。
+func (e *durationExpr) addOperand(s string, nf *format.Formatter) bool {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return false
+	}
+
+	if m := durationClockRegex.FindStringSubmatch(s); m != nil {
+		hours, _ := nf.Parse(m[1])
+		minutes, _ := nf.Parse(m[2])
+		var seconds float64
+		if m[3] != "" {
+			// 秒的小数部分可以用 "." 或 ","，与数字格式配置无关
+			v, err := strconv.ParseFloat(strings.ReplaceAll(m[3], ",", "."), 64)
+			if err != nil {
+				return false
+			}
+			seconds = v
+		}
+		e.operands = append(e.operands, durationValue{v: hours*3600 + minutes*60 + seconds, dur: true})
+		e.compound = true
+		return true
+	}
+
+	if v, err := nf.Parse(s); err == nil {
+		e.operands = append(e.operands, durationValue{v: v})
+		return true
+	}
+
+	var total float64
+	parts := 0
+	for rest := s; rest != ""; parts++ {
+		m := durationPartRegex.FindStringSubmatch(rest)
+		if m == nil {
+			return false
+		}
+		amount, err := nf.Parse(m[1])
+		if err != nil {
+			return false
+		}
+		secs, ok := durationUnits[strings.ToLower(m[2])]
+		if !ok {
+			return false
+		}
+		total += amount * secs
+		rest = rest[len(m[0]):]
+	}
+	e.operands = append(e.operands, durationValue{v: total, dur: true})
+	if parts > 1 {
+		e.compound = true
+	}
+	return true
+}
+
+// eval 按先乘除后加减的顺序计算表达式。
+func (e *durationExpr) eval() (durationValue, error) {
+	// 第一遍: 乘除，结果保存为待相加减的项
+	terms := []durationValue{e.operands[0]}
+	var addOps []string
+	for i, op := range e.operators {
+		next := e.operands[i+1]
+		if op == "+" || op == "-" {
+			terms = append(terms, next)
+			addOps = append(addOps, op)
+			continue
+		}
+		last := &terms[len(terms)-1]
+		v, err := combineDurations(*last, op, next)
+		if err != nil {
+			return durationValue{}, err
+		}
+		*last = v
+	}
+
+	// 第二遍: 加减
+	result := terms[0]
+	for i, op := range addOps {
+		v, err := combineDurations(result, op, terms[i+1])
+		if err != nil {
+			return durationValue{}, err
+		}
+		result = v
+	}
+	return result, nil
+}
+
+// combineDurations 计算 a op b。时长只能与时长相加减，只能乘以或除以数字；
+// 两个时长相除得到倍数。
+func combineDurations(a durationValue, op string, b durationValue) (durationValue, error) {
+	switch op {
+	case "+", "-":
+		if !a.dur || !b.dur {
+			return durationValue{}, fmt.Errorf("时长只能与时长相加减")
+		}
+		if op == "-" {
+			return durationValue{v: a.v - b.v, dur: true}, nil
+		}
+		return durationValue{v: a.v + b.v, dur: true}, nil
+	case "*", "×", "x", "X":
+		if a.dur && b.dur {
+			return durationValue{}, fmt.Errorf("时长不能与时长相乘")
+		}
+		return durationValue{v: a.v * b.v, dur: a.dur || b.dur}, nil
+	default: // "/", "÷"
+		if b.v == 0 {
+			return durationValue{}, fmt.Errorf("除数不能为零")
+		}
+		if !a.dur && b.dur {
+			return durationValue{}, fmt.Errorf("数字不能除以时长")
+		}
+		return durationValue{v: a.v / b.v, dur: a.dur && !b.dur}, nil
+	}
+}
+
+// maxDurationSeconds 是可以格式化的最长时长 (100 万年)。
+// formatClockDuration 和 formatHumanDuration 以 int64 毫秒计算，更长的时长会溢出。
+const maxDurationSeconds = 1e6 * 365 * 86400
+
+// formatClockDuration 将秒数格式化为时:分:秒，小时不按天进位，e.g. "52:30:00", "0:00:01.250"。
+func formatClockDuration(secs float64) string {
+	sign := ""
+	if secs < 0 {
+		sign, secs = "-", -secs
+	}
+	ms := int64(math.Round(secs * 1000))
+	s := fmt.Sprintf("%s%d:%02d:%02d", sign, ms/3600000, ms/60000%60, ms/1000%60)
+	if ms%1000 != 0 {
+		s += fmt.Sprintf(".%03d", ms%1000)
+	}
+	return s
+}
+
+// formatHumanDuration 将秒数格式化为可读文本并省略为零的部分, e.g. "2 天 4 小时", "1.25 秒"。
+func formatHumanDuration(secs float64, nf *format.Formatter) string {
+	sign := ""
+	if secs < 0 {
+		sign, secs = "-", -secs
+	}
+	ms := int64(math.Round(secs * 1000))
+	units := []struct {
+		ms   int64
+		name string
+	}{{86400000, "天"}, {3600000, "小时"}, {60000, "分钟"}}
+
+	var parts []string
+	for _, u := range units {
+		if n := ms / u.ms; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, u.name))
+			ms %= u.ms
+		}
+	}
+	if ms > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%s 秒", nf.Format(float64(ms)/1000, -1)))
+	}
+	return sign + strings.Join(parts, " ")
+}
+
+// formatGoDuration 将秒数格式化为 Go 的 time.Duration 写法并去掉末尾为零的部分, e.g. "13h40m"。
+func formatGoDuration(secs float64) string {
+	s := time.Duration(math.Round(secs*1000) * float64(time.Millisecond)).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// roundDuration 将换算后的数值保留 4 位小数，避免 "2.05111111111111 h" 这样的长尾。
+func roundDuration(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
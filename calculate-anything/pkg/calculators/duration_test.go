@@ -0,0 +1,41 @@
+// calculate-anything/pkg/calculators/duration_test.go
+package calculators
+
+import "testing"
+
+func TestDuration(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "3h25m * 4", calculator: "duration", title: "3h25m * 4 = 13:40:00"},
+		{query: "7384 s in h:m:s", calculator: "duration", title: "7384 s = 2:03:04"},
+		{query: "1:30:00 + 45m", calculator: "duration", title: "1:30:00 + 45m = 2:15:00"},
+		{query: "2 days 4 hours in hours", calculator: "duration", title: "2 days 4 hours = 52 h"},
+		{query: "3000000 h in h:m:s", calculator: "duration", title: "3000000 h = 3000000:00:00"},
+		{query: "10000000000 h * 1000000000", calculator: "duration", err: "时长超过 100 万年"},
+	})
+}
+
+func TestDurationGoFormat(t *testing.T) {
+	// time.Duration 最长约 292 年，更长的时长不提供 Go 格式
+	ctx := newTestContext(t)
+	for _, tt := range []struct {
+		query string
+		want  string
+	}{
+		{"2h in human", "2h"},
+		{"3h25m * 4 in human", "13h40m"},
+		{"3000000 h in human", ""},
+	} {
+		out := Dispatch(ctx, tt.query)
+		if out == nil || out.Err != nil || len(out.Results) == 0 {
+			t.Errorf("%q: 没有结果 (%v)", tt.query, out)
+			continue
+		}
+		got := ""
+		if mods := out.Results[0].Modifiers; len(mods) > 0 {
+			got = mods[0].Arg
+		}
+		if got != tt.want {
+			t.Errorf("%q: Go 格式为 %q, 期望 %q", tt.query, got, tt.want)
+		}
+	}
+}
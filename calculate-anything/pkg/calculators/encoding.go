@@ -0,0 +1,200 @@
+// calculate-anything/pkg/calculators/encoding.go
+package calculators
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// 引号中的字符串，可以指定编码, e.g. `"hi"`, `'hi' to base64`
+	quotedTextRegex = regexp.MustCompile(`(?i)^(?:"(.+)"|'(.+)')(?:\s+(?:to|in|as)\s+(hex|base64|b64|utf8|utf-8|bytes|dec|bin|unicode|codepoints))?$`)
+	// 解码, e.g. "aGk= from base64", "68 69 from hex"
+	textDecodeRegex = regexp.MustCompile(`(?i)^(.+?)\s+from\s+(base64|b64|hex)$`)
+	// Unicode 码位, e.g. "U+1F600"
+	codePointRegex = regexp.MustCompile(`(?i)^u\+([0-9a-f]{1,6})$`)
+	// 罗马数字, e.g. "MMXXIV", "roman mcmlxxxiv"
+	romanQueryRegex = regexp.MustCompile(`(?i)^(?:roman\s+)?([mdclxvi]{2,})$`)
+	// 规范写法的罗马数字 (1-3999)
+	romanRegex = regexp.MustCompile(`^M{0,3}(CM|CD|D?C{0,3})(XC|XL|L?X{0,3})(IX|IV|V?I{0,3})$`)
+)
+
+// textEncodings 是字符串的各种编码，按显示顺序排列
+var textEncodings = []struct {
+	names  []string
+	label  string
+	encode func(s string) string
+}{
+	{[]string{"utf8", "utf-8", "bytes", "hex"}, "UTF-8 (HEX)", func(s string) string { return spacedHex([]byte(s)) }},
+	{[]string{"base64", "b64"}, "Base64", func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }},
+	{[]string{"unicode", "codepoints"}, "Unicode 码位", codePoints},
+	{[]string{"dec"}, "UTF-8 (十进制)", func(s string) string { return joinBytes([]byte(s), "%d") }},
+	{[]string{"bin"}, "UTF-8 (二进制)", func(s string) string { return joinBytes([]byte(s), "%08b") }},
+}
+
+// encodeTextResults 将引号中的字符串编码。m 是 quotedTextRegex 的匹配结果，
+// 指定了编码时只返回该编码。
+func encodeTextResults(m []string) ([]Result, error) {
+	text := m[1] + m[2]
+	target := strings.ToLower(m[3])
+
+	var results []Result
+	for _, enc := range textEncodings {
+		if target != "" && !containsString(enc.names, target) {
+			continue
+		}
+		value := enc.encode(text)
+		results = append(results, Result{
+			Title:    value,
+			Subtitle: fmt.Sprintf("%s · %d 个字符, %d 字节 · 复制", enc.label, utf8.RuneCountInString(text), len(text)),
+			Arg:      value,
+		})
+	}
+	return results, nil
+}
+
+// decodeTextResults 将 Base64 或 HEX 解码为 UTF-8 字符串，输入可以带引号, e.g. `"aGk=" from base64`。
+func decodeTextResults(input, encoding string) ([]Result, error) {
+	input = trimQuotes(strings.TrimSpace(input))
+	var data []byte
+	var err error
+	if encoding == "hex" {
+		clean := strings.NewReplacer(" ", "", "0x", "", ":", "").Replace(strings.ToLower(input))
+		data, err = hex.DecodeString(clean)
+	} else {
+		data, err = base64.StdEncoding.DecodeString(input)
+		if err != nil {
+			// 兼容 URL 安全和不带填充的写法
+			data, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(input, "="))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无效的 %s 数据: %w", encoding, err)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("解码结果不是有效的 UTF-8 文本 (HEX: %s)", spacedHex(data))
+	}
+
+	text := string(data)
+	return []Result{
+		{
+			Title:    text,
+			Subtitle: fmt.Sprintf("%d 个字符, %d 字节 · 复制文本", utf8.RuneCountInString(text), len(data)),
+			Arg:      text,
+		},
+		{
+			Title:    codePoints(text),
+			Subtitle: "Unicode 码位 · 复制",
+			Arg:      codePoints(text),
+		},
+	}, nil
+}
+
+// trimQuotes 去掉首尾成对的双引号或单引号。
+func trimQuotes(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// charResults 返回码位对应的字符及其 UTF-8 编码。
+func charResults(n *big.Int) ([]Result, error) {
+	if n.Sign() < 0 || !n.IsInt64() || n.Int64() > unicode.MaxRune {
+		return nil, fmt.Errorf("%s 不是有效的 Unicode 码位", n.String())
+	}
+	r := rune(n.Int64())
+	if !utf8.ValidRune(r) {
+		return nil, fmt.Errorf("U+%04X 不是有效的 Unicode 码位", r)
+	}
+	if !unicode.IsPrint(r) {
+		return nil, fmt.Errorf("U+%04X 是不可打印的控制字符", r)
+	}
+	return []Result{{
+		Value:    float64(r),
+		Title:    fmt.Sprintf("%c · U+%04X", r, r),
+		Subtitle: fmt.Sprintf("字符 · 十进制 %d · UTF-8 %s · 复制字符", r, spacedHex([]byte(string(r)))),
+		Arg:      string(r),
+	}}, nil
+}
+
+// parseRoman 解析规范写法的罗马数字，不区分大小写。
+func parseRoman(s string) (int, bool) {
+	s = strings.ToUpper(s)
+	if s == "" || !romanRegex.MatchString(s) {
+		return 0, false
+	}
+	values := map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+	total := 0
+	for i := 0; i < len(s); i++ {
+		v := values[s[i]]
+		if i+1 < len(s) && v < values[s[i+1]] {
+			total -= v
+		} else {
+			total += v
+		}
+	}
+	return total, true
+}
+
+// toRoman 将 1-3999 的整数转换为罗马数字。
+func toRoman(n *big.Int) (string, bool) {
+	if !n.IsInt64() || n.Int64() < 1 || n.Int64() > 3999 {
+		return "", false
+	}
+	v := int(n.Int64())
+	symbols := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+	var b strings.Builder
+	for _, s := range symbols {
+		for v >= s.value {
+			b.WriteString(s.symbol)
+			v -= s.value
+		}
+	}
+	return b.String(), true
+}
+
+// codePoints 返回字符串中每个字符的码位, e.g. "U+0068 U+0069"。
+func codePoints(s string) string {
+	parts := make([]string, 0, len(s))
+	for _, r := range s {
+		parts = append(parts, fmt.Sprintf("U+%04X", r))
+	}
+	return strings.Join(parts, " ")
+}
+
+// spacedHex 将字节格式化为以空格分隔的大写 HEX, e.g. "68 69"。
+func spacedHex(data []byte) string {
+	return joinBytes(data, "%02X")
+}
+
+// joinBytes 按 format 格式化每个字节并以空格连接。
+func joinBytes(data []byte, format string) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf(format, b)
+	}
+	return strings.Join(parts, " ")
+}
+
+// containsString 报告 list 中是否包含 s。
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+// calculate-anything/pkg/calculators/expression.go
+package calculators
+
+import (
+	"calculate-anything/pkg/parser"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// expressionCalculator 计算算术表达式，e.g. "(12.5 * 4) / 3 + 2^8"。
+// 它的优先级最低，只在其他计算器都不匹配时才尝试。
+type expressionCalculator struct{ baseCalculator }
+
+func init() { Register(&expressionCalculator{}) }
+
+func (*expressionCalculator) Name() string  { return "expression" }
+func (*expressionCalculator) Priority() int { return 90 }
+
+func (*expressionCalculator) Examples() []string {
+	return []string{"(2 + 3) * 4", "sqrt(2) * pi"}
+}
+
+// Match 将查询作为算术表达式解析。
+func (*expressionCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	return parser.ParseArithmetic(query, ctx.Format)
+}
+
+// Compute 对表达式求值。
+func (*expressionCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	if p.Expr == nil {
+		return nil, fmt.Errorf("无效的表达式: %s", p.Input)
+	}
+
+	expression := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(p.Input), "="))
+
+	// 只含四则运算、整数次幂和取整类函数的表达式精确计算，其余回退到 float64
+	if exact, ok := parser.EvalExact(p.Expr); ok {
+		resultString := ctx.Format.PlainDecimal(exact, -1)
+		return []Result{
+			{
+				Value:    exact.Float64(),
+				Title:    fmt.Sprintf("%s = %s", expression, ctx.Format.FormatDecimal(exact, -1)),
+				Subtitle: fmt.Sprintf("复制 '%s'", resultString),
+				Arg:      resultString,
+			},
+		}, nil
+	}
+
+	resultValue, err := p.Expr.Eval()
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(resultValue) || math.IsInf(resultValue, 0) {
+		return nil, fmt.Errorf("结果不是有效的数字")
+	}
+
+	resultString := ctx.Format.Plain(resultValue, -1)
+
+	return []Result{
+		{
+			Value:    resultValue,
+			Title:    fmt.Sprintf("%s = %s", expression, ctx.Format.Format(resultValue, -1)),
+			Subtitle: fmt.Sprintf("复制 '%s'", resultString),
+			Arg:      resultString,
+		},
+	}, nil
+}
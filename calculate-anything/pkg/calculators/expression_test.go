@@ -0,0 +1,19 @@
+// calculate-anything/pkg/calculators/expression_test.go
+package calculators
+
+import "testing"
+
+func TestExpression(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "(2 + 3) * 4", calculator: "expression", title: "(2 + 3) * 4 = 20"},
+		{query: "0.1 + 0.2", calculator: "expression", title: "0.1 + 0.2 = 0.3"},
+		{query: "10 / 3", calculator: "expression", title: "10 / 3 = 3.33333333333333"},
+		{query: "sqrt(2) * pi", calculator: "expression", title: "sqrt(2) * pi = 4.44288293815837"},
+		{query: "2^100", calculator: "expression", title: "2^100 = 1,267,650,600,228,229,401,496,703,205,376"},
+		{query: "1e-5 * 2", calculator: "expression", title: "1e-5 * 2 = 0.00002"},
+		// 超出精确计算范围时回退到 float64
+		{query: "1e-999999999 + 1", calculator: "expression", title: "1e-999999999 + 1 = 1"},
+		{query: "10^-400", calculator: "expression", title: "10^-400 = 0"},
+	})
+	checkNoMatch(t, &expressionCalculator{}, "42", "hello")
+}
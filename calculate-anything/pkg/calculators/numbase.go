@@ -0,0 +1,351 @@
+// calculate-anything/pkg/calculators/numbase.go
+package calculators
+
+import (
+	"calculate-anything/pkg/parser"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numBaseQuery 是进制转换、位运算和编码查询的类型
+const numBaseQuery parser.QueryType = "numbase"
+
+var (
+	// 带前缀的整数字面量, e.g. "0xff", "-0b1010", "0o17", "0xdead_beef"
+	prefixedIntRegex = regexp.MustCompile(`(?i)^-?0(?:x[0-9a-f_]+|b[01_]+|o[0-7_]+)$`)
+	// "255 to bin", "0b1010 in hex", "ff hex to dec", "zz base 36 to dec"
+	numConversionRegex = regexp.MustCompile(`(?i)^(-?[0-9a-z_]+)(?:\s+(bin|binary|oct|octal|dec|decimal|hex|hexadecimal|base\s*\d{1,2}))?\s+(?:to|in|as|into)\s+(bin|binary|oct|octal|dec|decimal|hex|hexadecimal|base\s*\d{1,2}|roman|char|ascii|unicode|twos|signed)$`)
+	// "0xff and 0x0f", "1 shl 4", "12 | 3"
+	numBitwiseRegex = regexp.MustCompile(`(?i)^(-?[0-9a-z_]+)\s*(\band\b|\bor\b|\bxor\b|\bshl\b|\bshr\b|<<|>>|&|\|)\s*(-?[0-9a-z_]+)$`)
+	// "not 5", "~0xff"
+	numNotRegex = regexp.MustCompile(`(?i)^(?:not\s+|~\s*)(-?[0-9a-z_]+)$`)
+)
+
+// numBaseNames 将进制的写法映射为基数
+var numBaseNames = map[string]int{
+	"bin": 2, "binary": 2, "oct": 8, "octal": 8, "dec": 10, "decimal": 10, "hex": 16, "hexadecimal": 16,
+}
+
+// twosComplementWidths 是显示二进制补码的位宽
+var twosComplementWidths = []uint{8, 16, 32, 64}
+
+// numBaseCalculator 处理程序员常用的转换: 2-36 进制 (任意精度)、二进制补码、位运算、
+// 字符码位、字符串的 UTF-8/Base64/HEX 编码以及罗马数字。
+type numBaseCalculator struct{ baseCalculator }
+
+func init() { Register(&numBaseCalculator{}) }
+
+func (*numBaseCalculator) Name() string  { return "numbase" }
+func (*numBaseCalculator) Priority() int { return 8 }
+
+func (*numBaseCalculator) Examples() []string {
+	return []string{"0xff", "255 to bin", "0b1010 in hex", "-1 to hex", "0xff and 0x0f", `"hi" to base64`, "U+1F600", "2024 to roman"}
+}
+
+// Match 接受带前缀的整数、进制转换、位运算、字符串编解码、码位和罗马数字。
+// 结构在这里完整校验，Compute 重新解析并计算。
+func (*numBaseCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	query = strings.TrimSpace(query)
+	p := &parser.ParsedQuery{Type: numBaseQuery, Input: query}
+
+	switch {
+	case prefixedIntRegex.MatchString(query):
+		return p
+	case numConversionRegex.MatchString(query):
+		m := numConversionRegex.FindStringSubmatch(query)
+		if _, ok := parseIntLiteral(m[1], sourceBase(m[2])); ok {
+			return p
+		}
+		if _, ok := parseRoman(m[1]); ok && m[2] == "" {
+			return p
+		}
+	case numBitwiseRegex.MatchString(query):
+		m := numBitwiseRegex.FindStringSubmatch(query)
+		_, ok1 := parseIntLiteral(m[1], 0)
+		_, ok2 := parseIntLiteral(m[3], 0)
+		if ok1 && ok2 {
+			return p
+		}
+	case numNotRegex.MatchString(query):
+		if _, ok := parseIntLiteral(numNotRegex.FindStringSubmatch(query)[1], 0); ok {
+			return p
+		}
+	case quotedTextRegex.MatchString(query), textDecodeRegex.MatchString(query), codePointRegex.MatchString(query):
+		return p
+	case romanQueryRegex.MatchString(query):
+		m := romanQueryRegex.FindStringSubmatch(query)
+		if _, ok := parseRoman(m[1]); ok && (m[1] == strings.ToUpper(m[1]) || strings.HasPrefix(strings.ToLower(query), "roman")) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Compute 按查询的形式分别计算。
+func (*numBaseCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	query := p.Input
+	switch {
+	case prefixedIntRegex.MatchString(query):
+		n, _ := parseIntLiteral(query, 0)
+		return numberResults(n, query), nil
+
+	case numConversionRegex.MatchString(query):
+		m := numConversionRegex.FindStringSubmatch(query)
+		n, ok := parseIntLiteral(m[1], sourceBase(m[2]))
+		if !ok {
+			// 源是罗马数字, e.g. "MMXXIV to dec"
+			v, _ := parseRoman(m[1])
+			n = big.NewInt(int64(v))
+		}
+		return numberTargetResults(n, m[1], strings.ToLower(strings.Join(strings.Fields(m[3]), "")))
+
+	case numBitwiseRegex.MatchString(query):
+		m := numBitwiseRegex.FindStringSubmatch(query)
+		a, _ := parseIntLiteral(m[1], 0)
+		b, _ := parseIntLiteral(m[3], 0)
+		n, err := bitwise(a, strings.ToLower(m[2]), b)
+		if err != nil {
+			return nil, err
+		}
+		return numberResults(n, query), nil
+
+	case numNotRegex.MatchString(query):
+		a, _ := parseIntLiteral(numNotRegex.FindStringSubmatch(query)[1], 0)
+		return numberResults(new(big.Int).Not(a), query), nil
+
+	case quotedTextRegex.MatchString(query):
+		return encodeTextResults(quotedTextRegex.FindStringSubmatch(query))
+	case textDecodeRegex.MatchString(query):
+		m := textDecodeRegex.FindStringSubmatch(query)
+		return decodeTextResults(m[1], strings.ToLower(m[2]))
+	case codePointRegex.MatchString(query):
+		v, err := strconv.ParseInt(codePointRegex.FindStringSubmatch(query)[1], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("无效的码位: %s", query)
+		}
+		return charResults(big.NewInt(v))
+
+	case romanQueryRegex.MatchString(query):
+		v, _ := parseRoman(romanQueryRegex.FindStringSubmatch(query)[1])
+		return numberResults(big.NewInt(int64(v)), query), nil
+	}
+	return nil, fmt.Errorf("无法解析查询: %s", query)
+}
+
+// sourceBase 返回源进制的基数，未指定时为 0 (按前缀判断，默认十进制)。
+func sourceBase(word string) int {
+	word = strings.ToLower(strings.Join(strings.Fields(word), ""))
+	if word == "" {
+		return 0
+	}
+	if base, ok := numBaseNames[word]; ok {
+		return base
+	}
+	base, _ := strconv.Atoi(strings.TrimPrefix(word, "base"))
+	return base
+}
+
+// parseIntLiteral 解析任意精度的整数。base 为 0 时按 0x/0b/0o 前缀判断，没有前缀为十进制；
+// 指定 base 时前缀可以省略但必须与之相符。数字之间可以用 "_" 分组。
+func parseIntLiteral(s string, base int) (*big.Int, bool) {
+	s = strings.ToLower(strings.ReplaceAll(s, "_", ""))
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	prefixBase := 0
+	if len(s) > 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x':
+			prefixBase = 16
+		case 'b':
+			prefixBase = 2
+		case 'o':
+			prefixBase = 8
+		}
+	}
+	if prefixBase != 0 && (base == 0 || base == prefixBase) {
+		base, s = prefixBase, s[2:]
+	}
+	if base == 0 {
+		base = 10
+	}
+	if base < 2 || base > 36 || s == "" {
+		return nil, false
+	}
+
+	n, ok := new(big.Int).SetString(s, base)
+	if !ok {
+		return nil, false
+	}
+	if negative {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+// bitwise 计算 a op b。负数按无限位宽的二进制补码参与运算。
+func bitwise(a *big.Int, op string, b *big.Int) (*big.Int, error) {
+	n := new(big.Int)
+	switch op {
+	case "and", "&":
+		return n.And(a, b), nil
+	case "or", "|":
+		return n.Or(a, b), nil
+	case "xor":
+		return n.Xor(a, b), nil
+	}
+	// 移位
+	if b.Sign() < 0 || !b.IsInt64() || b.Int64() > 4096 {
+		return nil, fmt.Errorf("移位位数必须在 0 到 4096 之间")
+	}
+	if op == "shl" || op == "<<" {
+		return n.Lsh(a, uint(b.Int64())), nil
+	}
+	return n.Rsh(a, uint(b.Int64())), nil
+}
+
+// numberResults 给出整数在常用进制下的写法、二进制补码、对应的字符和罗马数字。
+func numberResults(n *big.Int, label string) []Result {
+	results := []Result{
+		baseRow(n, label, 10),
+		baseRow(n, label, 16),
+		baseRow(n, label, 2),
+		baseRow(n, label, 8),
+	}
+	results = append(results, twosComplementRows(n, false)...)
+	// 只列出 ASCII 字符，其他码位用 "65 to char" 或 "U+1F600" 查询
+	if n.Sign() > 0 && n.IsInt64() && n.Int64() < 0x7F {
+		if rows, err := charResults(n); err == nil {
+			results = append(results, rows[0])
+		}
+	}
+	// 输入本身是罗马数字时不再重复
+	if roman, ok := toRoman(n); ok && !romanQueryRegex.MatchString(label) {
+		results = append(results, Result{Title: roman, Subtitle: "罗马数字 · 复制", Arg: roman})
+	}
+	return results
+}
+
+// numberTargetResults 将整数转换为指定的目标: 进制、罗马数字、字符或二进制补码。
+func numberTargetResults(n *big.Int, label, target string) ([]Result, error) {
+	switch target {
+	case "roman":
+		roman, ok := toRoman(n)
+		if !ok {
+			return nil, fmt.Errorf("罗马数字只支持 1 到 3999")
+		}
+		return []Result{{Value: float64(n.Int64()), Title: fmt.Sprintf("%s = %s", label, roman), Subtitle: "罗马数字 · 复制", Arg: roman}}, nil
+	case "char", "ascii", "unicode":
+		return charResults(n)
+	case "twos", "signed":
+		rows := twosComplementRows(n, true)
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("%s 超出 64 位整数的范围", n.String())
+		}
+		return rows, nil
+	}
+
+	base := sourceBase(target)
+	if base < 2 || base > 36 {
+		return nil, fmt.Errorf("进制必须在 2 到 36 之间")
+	}
+	results := []Result{baseRow(n, label, base)}
+	if n.Sign() < 0 && (base == 2 || base == 16) {
+		results = append(results, twosComplementRows(n, false)...)
+	}
+	return results, nil
+}
+
+// baseRow 生成整数在某个进制下的结果行。二进制、八进制和十六进制带前缀，
+// 二进制在标题中每 4 位分组，复制的值不分组。
+func baseRow(n *big.Int, label string, base int) Result {
+	sign, digits := "", n.Text(base)
+	if n.Sign() < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var value, display, name string
+	switch base {
+	case 2:
+		value, display, name = sign+"0b"+digits, sign+"0b"+groupDigits(digits, 4), "二进制"
+	case 8:
+		value, name = sign+"0o"+digits, "八进制"
+	case 10:
+		value, name = sign+digits, "十进制"
+	case 16:
+		value, name = sign+"0x"+strings.ToUpper(digits), "十六进制"
+	default:
+		value, name = sign+strings.ToUpper(digits), fmt.Sprintf("%d 进制", base)
+	}
+	if display == "" {
+		display = value
+	}
+
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return Result{
+		Value:    f,
+		Title:    fmt.Sprintf("%s = %s", label, display),
+		Subtitle: fmt.Sprintf("%s · 复制 '%s'", name, value),
+		Arg:      value,
+	}
+}
+
+// twosComplementRows 给出整数的二进制补码表示。负数列出能容纳它的每个位宽；
+// 非负数在 all 为 true 时同样列出，否则只在最小位宽的最高位为 1 时给出有符号的解释。
+func twosComplementRows(n *big.Int, all bool) []Result {
+	var results []Result
+	for _, width := range twosComplementWidths {
+		limit := new(big.Int).Lsh(big.NewInt(1), width)
+		half := new(big.Int).Rsh(limit, 1)
+
+		if n.Sign() < 0 || all {
+			if n.Cmp(new(big.Int).Neg(half)) < 0 || n.Cmp(limit) >= 0 {
+				continue
+			}
+			unsigned := new(big.Int).Set(n)
+			if n.Sign() < 0 {
+				unsigned.Add(unsigned, limit)
+			}
+			signed := new(big.Int).Set(unsigned)
+			if unsigned.Cmp(half) >= 0 {
+				signed.Sub(signed, limit)
+			}
+			hex := fmt.Sprintf("0x%0*X", width/4, unsigned)
+			results = append(results, Result{
+				Title:    fmt.Sprintf("int%d: %s", width, hex),
+				Subtitle: fmt.Sprintf("二进制补码 0b%s · 有符号 %s · 无符号 %s", groupDigits(fmt.Sprintf("%0*b", width, unsigned), 4), signed, unsigned),
+				Arg:      hex,
+			})
+			continue
+		}
+
+		// 非负数: 找到能容纳它的最小位宽
+		if n.Cmp(limit) >= 0 {
+			continue
+		}
+		if n.Cmp(half) >= 0 {
+			signed := new(big.Int).Sub(n, limit)
+			results = append(results, Result{
+				Title:    fmt.Sprintf("作为 int%d: %s", width, signed),
+				Subtitle: fmt.Sprintf("按 %d 位二进制补码解释 · 复制", width),
+				Arg:      signed.String(),
+			})
+		}
+		break
+	}
+	return results
+}
+
+// groupDigits 从右向左每 size 位插入一个空格。
+func groupDigits(digits string, size int) string {
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%size == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,20 @@
+// calculate-anything/pkg/calculators/numbase_test.go
+package calculators
+
+import "testing"
+
+func TestNumberBase(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "0xff", calculator: "numbase", title: "0xff = 255"},
+		{query: "255 to bin", calculator: "numbase", title: "255 = 0b1111 1111"},
+		{query: "0b1010 in hex", calculator: "numbase", title: "0b1010 = 0xA"},
+		{query: "-1 to hex", calculator: "numbase", title: "-1 = -0x1"},
+		{query: "0xff and 0x0f", calculator: "numbase", title: "0xff and 0x0f = 15"},
+		{query: "2024 to roman", calculator: "numbase", title: "2024 = MMXXIV"},
+		{query: `"hi" to base64`, calculator: "numbase", title: "aGk="},
+		{query: "aGk= from base64", calculator: "numbase", title: "hi"},
+		{query: `"aGk=" from base64`, calculator: "numbase", title: "hi"},
+		{query: "'68 69' from hex", calculator: "numbase", title: "hi"},
+		{query: "U+1F600", calculator: "numbase", title: "😀 · U+1F600"},
+	})
+}
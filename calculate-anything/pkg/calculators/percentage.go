@@ -2,56 +2,275 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
+	"calculate-anything/pkg/format"
 	"calculate-anything/pkg/parser"
 	"fmt"
-	"github.com/deanishe/awgo"
+	"math"
+	"strings"
 )
 
-// HandlePercentage 处理所有类型的百分比计算。
-func HandlePercentage(wf *aw.Workflow, p *parser.ParsedQuery) {
+// percentageCalculator 处理所有类型的百分比计算。
+type percentageCalculator struct{ baseCalculator }
+
+func init() { Register(&percentageCalculator{}) }
+
+func (*percentageCalculator) Name() string  { return "percentage" }
+func (*percentageCalculator) Priority() int { return 10 }
+
+func (*percentageCalculator) Examples() []string {
+	return []string{"120 + 15%", "15% of 50", "40 as a % of 50", "% change from 80 to 100", "120 after 20% off then +5%",
+		"30 is 15% of what", "1000 at 5% for 10 years", "cagr from 1000 to 2000 in 5 years", "80 with 25% markup", "cost 80 price 100"}
+}
+
+// Match 接受结构固定的百分比查询。
+func (*percentageCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	if p := parser.ParseFixed(query, ctx.Format); p != nil && p.Type == parser.PercentageQuery {
+		return p
+	}
+	return nil
+}
+
+// Compute 根据解析器识别出的动作计算百分比。
+func (*percentageCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
 	var result float64
-	var title, arg string
+	var title string
+	nf := ctx.Format
 
 	// 根据解析器识别出的不同动作，执行相应的计算
 	switch p.Action {
 	// 场景 1: "120 + 30%"
 	case "+":
 		result = p.BaseValue * (1 + p.Percent/100)
-		title = fmt.Sprintf("%g + %g%% = %g", p.BaseValue, p.Percent, result)
-		arg = fmt.Sprintf("%g", result)
+		title = fmt.Sprintf("%s + %s%% = %s", nf.Format(p.BaseValue, -1), nf.Format(p.Percent, -1), nf.Format(result, -1))
 
 	// 场景 2: "120 - 30%"
 	case "-":
 		result = p.BaseValue * (1 - p.Percent/100)
-		title = fmt.Sprintf("%g - %g%% = %g", p.BaseValue, p.Percent, result)
-		arg = fmt.Sprintf("%g", result)
+		title = fmt.Sprintf("%s - %s%% = %s", nf.Format(p.BaseValue, -1), nf.Format(p.Percent, -1), nf.Format(result, -1))
 
 	// 场景 3: "15% of 50"
 	case "of":
 		result = (p.Percent / 100) * p.BaseValue
-		title = fmt.Sprintf("%g%% of %g = %g", p.Percent, p.BaseValue, result)
-		arg = fmt.Sprintf("%g", result)
+		title = fmt.Sprintf("%s%% of %s = %s", nf.Format(p.Percent, -1), nf.Format(p.BaseValue, -1), nf.Format(result, -1))
 
 	// 场景 4: "40 as a % of 50"
 	case "as % of":
 		if p.BaseValue == 0 {
-			alfred.ShowError(wf, fmt.Errorf("不能计算 0 的百分比"))
-			return
+			return nil, fmt.Errorf("不能计算 0 的百分比")
 		}
 		result = (p.Amount / p.BaseValue) * 100
-		title = fmt.Sprintf("%g 是 %g 的 %g%%", p.Amount, p.BaseValue, result)
-		arg = fmt.Sprintf("%g", result)
+		title = fmt.Sprintf("%s 是 %s 的 %s%%", nf.Format(p.Amount, -1), nf.Format(p.BaseValue, -1), nf.Format(result, -1))
+
+	// 以下场景返回多行结果
+	case "change":
+		return percentChangeResults(nf, p.BaseValue, p.Amount)
+	case "chain":
+		return percentChainResults(nf, p.BaseValue, p.Steps), nil
+	case "of what":
+		return percentOfWhatResults(nf, p.Amount, p.Percent)
+	case "compound":
+		return compoundInterestResults(nf, p.BaseValue, p.Percent, p.Periods)
+	case "cagr":
+		return cagrResults(nf, p.BaseValue, p.Amount, p.Periods)
+	case "markup", "margin":
+		return markupMarginResults(nf, p.BaseValue, p.Percent, p.Action)
+	case "cost price":
+		return costPriceResults(nf, p.BaseValue, p.Amount)
 
 	default:
-		alfred.ShowError(wf, fmt.Errorf("未知的百分比操作: %s", p.Action))
-		return
+		return nil, fmt.Errorf("未知的百分比操作: %s", p.Action)
 	}
 
-	// 将计算结果添加到 Alfred 反馈
-	alfred.AddToWorkflow(wf, []alfred.Result{{
+	// 返回计算结果
+	arg := nf.Plain(result, -1)
+	return []Result{{
+		Value:    result,
 		Title:    title,
 		Subtitle: fmt.Sprintf("复制 '%s'", arg),
 		Arg:      arg,
-	}})
+	}}, nil
+}
+
+// percentChangeResults 计算从 from 到 to 的变化率，以及差值、反向变化率和比值。
+func percentChangeResults(nf *format.Formatter, from, to float64) ([]Result, error) {
+	if from == 0 {
+		return nil, fmt.Errorf("无法计算从 0 开始的变化率")
+	}
+	change := (to - from) / from * 100
+	results := []Result{
+		percentRow(nf, change, fmt.Sprintf("从 %s 到 %s: %s%%", nf.Format(from, -1), nf.Format(to, -1), signedNumber(nf, change)), "变化率"),
+		percentRow(nf, to-from, fmt.Sprintf("差值: %s", signedNumber(nf, to-from)), "变化量"),
+	}
+	if to != 0 {
+		back := (from - to) / to * 100
+		results = append(results, percentRow(nf, back, fmt.Sprintf("从 %s 回到 %s: %s%%", nf.Format(to, -1), nf.Format(from, -1), signedNumber(nf, back)), "反向变化率"))
+	}
+	ratio := to / from * 100
+	results = append(results, percentRow(nf, ratio, fmt.Sprintf("%s 是 %s 的 %s%%", nf.Format(to, -1), nf.Format(from, -1), nf.Format(roundResult(ratio), -1)), "比值"))
+	return results, nil
+}
+
+// percentChainResults 依次应用每一步的百分比变化，并给出累计变化和中间值。
+func percentChainResults(nf *format.Formatter, base float64, steps []float64) []Result {
+	value := base
+	trail := []string{nf.Format(base, -1)}
+	labels := make([]string, len(steps))
+	for i, step := range steps {
+		value *= 1 + step/100
+		trail = append(trail, nf.Format(roundResult(value), -1))
+		labels[i] = signedNumber(nf, step) + "%"
+	}
+	expr := fmt.Sprintf("%s %s", nf.Format(base, -1), strings.Join(labels, " "))
+
+	results := []Result{
+		percentRow(nf, value, fmt.Sprintf("%s = %s", expr, nf.Format(roundResult(value), -1)), "连续变化后的结果"),
+	}
+	if base != 0 {
+		total := (value - base) / base * 100
+		results = append(results, percentRow(nf, total, fmt.Sprintf("累计变化: %s%%", signedNumber(nf, total)), "相当于一次性的变化率"))
+	}
+	chain := strings.Join(trail, " → ")
+	results = append(results, Result{
+		Value:    value,
+		Title:    chain,
+		Subtitle: "每一步之后的值",
+		Arg:      chain,
+	})
+	return results
+}
+
+// percentOfWhatResults 反推基数: amount 是 base 的 percent%。
+func percentOfWhatResults(nf *format.Formatter, amount, percent float64) ([]Result, error) {
+	if percent == 0 {
+		return nil, fmt.Errorf("百分比不能为 0")
+	}
+	base := amount / (percent / 100)
+	return []Result{
+		percentRow(nf, base, fmt.Sprintf("%s 是 %s 的 %s%%", nf.Format(amount, -1), nf.Format(roundResult(base), -1), nf.Format(percent, -1)), "基数"),
+		percentRow(nf, base-amount, fmt.Sprintf("其余 %s%%: %s", nf.Format(100-percent, -1), nf.Format(roundResult(base-amount), -1)), "基数减去该部分"),
+	}, nil
+}
+
+// compoundInterestResults 计算按年复利 years 年后的本息，以及利息、按月复利、单利和翻倍时间。
+func compoundInterestResults(nf *format.Formatter, principal, rate, years float64) ([]Result, error) {
+	if years <= 0 {
+		return nil, fmt.Errorf("年数必须大于 0")
+	}
+	if principal == 0 {
+		return nil, fmt.Errorf("本金不能为 0")
+	}
+	final := principal * math.Pow(1+rate/100, years)
+	monthly := principal * math.Pow(1+rate/1200, years*12)
+	simple := principal * (1 + rate/100*years)
+	growth := (final/principal - 1) * 100
+	for _, v := range []float64{final, monthly, growth} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("复利结果超出可计算的范围")
+		}
+	}
+
+	results := []Result{
+		percentRow(nf, final, fmt.Sprintf("%s 按 %s%% 复利 %s 年 = %s", nf.Format(principal, -1), nf.Format(rate, -1), nf.Format(years, -1), nf.Format(roundResult(final), -1)), "按年复利的本息合计"),
+		percentRow(nf, final-principal, fmt.Sprintf("利息: %s", nf.Format(roundResult(final-principal), -1)), fmt.Sprintf("总增长 %s%%", signedNumber(nf, growth))),
+		percentRow(nf, monthly, fmt.Sprintf("按月复利: %s", nf.Format(roundResult(monthly), -1)), "每月计息一次的本息合计"),
+		percentRow(nf, simple, fmt.Sprintf("单利: %s", nf.Format(roundResult(simple), -1)), "不计复利的本息合计"),
+	}
+	if rate > 0 {
+		doubling := math.Log(2) / math.Log(1+rate/100)
+		results = append(results, percentRow(nf, doubling, fmt.Sprintf("翻倍需要 %s 年", nf.Format(roundResult(doubling), -1)), "按年复利"))
+	}
+	return results, nil
+}
+
+// cagrResults 计算从 start 到 end 在 years 年内的年复合增长率。
+func cagrResults(nf *format.Formatter, start, end, years float64) ([]Result, error) {
+	if start <= 0 || end < 0 {
+		return nil, fmt.Errorf("CAGR 需要正的起始值")
+	}
+	if years <= 0 {
+		return nil, fmt.Errorf("年数必须大于 0")
+	}
+	cagr := (math.Pow(end/start, 1/years) - 1) * 100
+	total := (end - start) / start * 100
+	span := fmt.Sprintf("%s → %s, %s 年", nf.Format(start, -1), nf.Format(end, -1), nf.Format(years, -1))
+	return []Result{
+		percentRow(nf, cagr, fmt.Sprintf("CAGR: %s%%", nf.Format(roundResult(cagr), -1)), span),
+		percentRow(nf, total, fmt.Sprintf("总增长: %s%%", signedNumber(nf, total)), span),
+	}, nil
+}
+
+// markupMarginResults 按加价率 (相对成本) 或毛利率 (相对售价) 计算售价，并给出另一种口径的对照。
+func markupMarginResults(nf *format.Formatter, cost, percent float64, kind string) ([]Result, error) {
+	markupPrice := cost * (1 + percent/100)
+	if percent >= 100 && kind == "margin" {
+		return nil, fmt.Errorf("毛利率必须小于 100%%")
+	}
+
+	if kind == "markup" {
+		profit := markupPrice - cost
+		results := []Result{
+			percentRow(nf, markupPrice, fmt.Sprintf("%s 加价 %s%% = %s", nf.Format(cost, -1), nf.Format(percent, -1), nf.Format(roundResult(markupPrice), -1)),
+				fmt.Sprintf("加价率按成本计算 · 利润 %s", nf.Format(roundResult(profit), -1))),
+		}
+		if markupPrice != 0 {
+			margin := profit / markupPrice * 100
+			results = append(results, percentRow(nf, margin, fmt.Sprintf("对应毛利率: %s%%", nf.Format(roundResult(margin), -1)), "利润 / 售价"))
+		}
+		if percent < 100 {
+			marginPrice := cost / (1 - percent/100)
+			results = append(results, percentRow(nf, marginPrice, fmt.Sprintf("若 %s%% 是毛利率: %s", nf.Format(percent, -1), nf.Format(roundResult(marginPrice), -1)), "毛利率按售价计算"))
+		}
+		return results, nil
+	}
+
+	marginPrice := cost / (1 - percent/100)
+	profit := marginPrice - cost
+	results := []Result{
+		percentRow(nf, marginPrice, fmt.Sprintf("%s 毛利率 %s%% = %s", nf.Format(cost, -1), nf.Format(percent, -1), nf.Format(roundResult(marginPrice), -1)),
+			fmt.Sprintf("毛利率按售价计算 · 利润 %s", nf.Format(roundResult(profit), -1))),
+	}
+	if cost != 0 {
+		markup := profit / cost * 100
+		results = append(results, percentRow(nf, markup, fmt.Sprintf("对应加价率: %s%%", nf.Format(roundResult(markup), -1)), "利润 / 成本"))
+	}
+	results = append(results, percentRow(nf, markupPrice, fmt.Sprintf("若 %s%% 是加价率: %s", nf.Format(percent, -1), nf.Format(roundResult(markupPrice), -1)), "加价率按成本计算"))
+	return results, nil
+}
+
+// costPriceResults 由成本和售价计算加价率、毛利率和利润。
+func costPriceResults(nf *format.Formatter, cost, price float64) ([]Result, error) {
+	if cost == 0 || price == 0 {
+		return nil, fmt.Errorf("成本和售价不能为 0")
+	}
+	profit := price - cost
+	return []Result{
+		percentRow(nf, profit/cost*100, fmt.Sprintf("加价率: %s%%", nf.Format(roundResult(profit/cost*100), -1)), fmt.Sprintf("利润 %s / 成本 %s", nf.Format(profit, -1), nf.Format(cost, -1))),
+		percentRow(nf, profit/price*100, fmt.Sprintf("毛利率: %s%%", nf.Format(roundResult(profit/price*100), -1)), fmt.Sprintf("利润 %s / 售价 %s", nf.Format(profit, -1), nf.Format(price, -1))),
+		percentRow(nf, profit, fmt.Sprintf("利润: %s", nf.Format(profit, -1)), "售价 - 成本"),
+	}, nil
+}
+
+// percentRow 生成一行结果，复制保留两位小数的数值。
+func percentRow(nf *format.Formatter, value float64, title, subtitle string) Result {
+	arg := nf.Plain(roundResult(value), -1)
+	return Result{
+		Value:    value,
+		Title:    title,
+		Subtitle: fmt.Sprintf("%s · 复制 '%s'", subtitle, arg),
+		Arg:      arg,
+	}
+}
+
+// signedNumber 格式化带符号的数值，保留两位小数, e.g. "+25", "-16.5"。
+func signedNumber(nf *format.Formatter, v float64) string {
+	s := nf.Format(roundResult(v), -1)
+	if roundResult(v) > 0 {
+		s = "+" + s
+	}
+	return s
+}
+
+// roundResult 保留两位小数，避免复利等计算结果出现很长的小数。
+func roundResult(v float64) float64 {
+	return math.Round(v*100) / 100
 }
@@ -0,0 +1,19 @@
+// calculate-anything/pkg/calculators/percentage_test.go
+package calculators
+
+import "testing"
+
+func TestPercentage(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "120 + 15%", calculator: "percentage", title: "120 + 15% = 138"},
+		{query: "15% of 50", calculator: "percentage", title: "15% of 50 = 7.5"},
+		{query: "40 as a % of 50", calculator: "percentage", title: "40 是 50 的 80%"},
+		{query: "% change from 80 to 100", calculator: "percentage", title: "从 80 到 100: +25%"},
+		{query: "120 after 20% off then +5%", calculator: "percentage", title: "120 -20% +5% = 100.8"},
+		{query: "30 is 15% of what", calculator: "percentage", title: "30 是 200 的 15%"},
+		{query: "1000 at 5% for 10 years", calculator: "percentage", title: "1,000 按 5% 复利 10 年 = 1,628.89"},
+		{query: "1000 at 5% for 100000 years", calculator: "percentage", err: "复利结果超出可计算的范围"},
+		{query: "0 at 5% for 10 years", calculator: "percentage", err: "本金不能为 0"},
+	})
+	checkNoMatch(t, &percentageCalculator{}, "1.000.000 + 5%", "5% of 1.2.3", "10 km")
+}
@@ -2,27 +2,44 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
-	"calculate-anything/pkg/config"
 	"calculate-anything/pkg/parser"
 	"fmt"
 	"strconv"
 	"strings"
-
-	"github.com/deanishe/awgo"
 )
 
 // 1pt (point) 等于 4/3 px (pixel) 是一个标准的 Web 和印刷转换因子
 const ptToPxFactor = 4.0 / 3.0
 
-// HandlePxEmRem 处理 Web 开发单位 px, em, rem, pt 之间的转换。
-func HandlePxEmRem(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery) {
+// pxEmRemCalculator 处理 Web 开发单位 px, em, rem, pt 之间的转换。
+type pxEmRemCalculator struct{ baseCalculator }
+
+func init() { Register(&pxEmRemCalculator{}) }
+
+func (*pxEmRemCalculator) Name() string  { return "pxemrem" }
+func (*pxEmRemCalculator) Priority() int { return 15 }
+
+func (*pxEmRemCalculator) Examples() []string {
+	return []string{"12px to rem", "2rem"}
+}
+
+// Match 接受 px/em/rem/pt 查询，目标单位可省略。
+func (*pxEmRemCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	if p := parser.ParseFixed(query, ctx.Format); p != nil && p.Type == parser.PxEmRemQuery {
+		return p
+	}
+	return nil
+}
+
+// Compute 以 px 为基准执行转换。
+func (*pxEmRemCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	cfg := ctx.Config
+	nf := ctx.Format
 	// 从配置中获取用户设置的基础像素值 (例如 "16px")
 	basePxString := strings.TrimSuffix(strings.ToLower(cfg.PixelsBase), "px")
 	basePx, err := strconv.ParseFloat(strings.TrimSpace(basePxString), 64)
 	if err != nil || basePx == 0 {
-		alfred.ShowError(wf, fmt.Errorf("无效的基础像素配置: %s", cfg.PixelsBase))
-		return
+		return nil, fmt.Errorf("无效的基础像素配置: %s", cfg.PixelsBase)
 	}
 
 	// 步骤 1: 将所有输入值统一转换为 px，作为计算的基准
@@ -36,8 +53,7 @@ func HandlePxEmRem(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery
 	case "pt":
 		valueInPx = p.Amount * ptToPxFactor
 	default:
-		alfred.ShowError(wf, fmt.Errorf("未知的源单位: %s", p.From))
-		return
+		return nil, fmt.Errorf("未知的源单位: %s", p.From)
 	}
 
 	// 场景 1: 如果用户明确指定了目标单位 (e.g., "2rem to pt")
@@ -53,17 +69,17 @@ func HandlePxEmRem(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery
 		case "pt":
 			resultValue = valueInPx / ptToPxFactor
 		default:
-			alfred.ShowError(wf, fmt.Errorf("未知的目标单位: %s", p.To))
-			return
+			return nil, fmt.Errorf("未知的目标单位: %s", p.To)
 		}
-		resultString := fmt.Sprintf("%g", resultValue)
-		title := fmt.Sprintf("%g%s = %s%s", p.Amount, fromUnit, resultString, toUnit)
-		alfred.AddToWorkflow(wf, []alfred.Result{{
+		resultString := nf.Plain(resultValue, -1)
+		title := fmt.Sprintf("%s%s = %s%s", nf.Format(p.Amount, -1), fromUnit, nf.Format(resultValue, -1), toUnit)
+		return []Result{{
+			Value:    resultValue,
+			Unit:     toUnit,
 			Title:    title,
 			Subtitle: fmt.Sprintf("复制 '%s'", resultString),
 			Arg:      resultString,
-		}})
-		return
+		}}, nil
 	}
 
 	// 场景 2: 如果用户只输入了一个值 (e.g., "12px" or "2rem")，则显示所有可能的转换
@@ -71,22 +87,28 @@ func HandlePxEmRem(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery
 	emValue := valueInPx / basePx
 	ptValue := valueInPx / ptToPxFactor
 
-	results := []alfred.Result{
+	results := []Result{
 		{
-			Title:    fmt.Sprintf("%g px", pxValue),
-			Subtitle: fmt.Sprintf("基础字号: %gpx | 复制 'px' 值", basePx),
-			Arg:      fmt.Sprintf("%g", pxValue),
+			Value:    pxValue,
+			Unit:     "px",
+			Title:    fmt.Sprintf("%s px", nf.Format(pxValue, -1)),
+			Subtitle: fmt.Sprintf("基础字号: %spx | 复制 'px' 值", nf.Format(basePx, -1)),
+			Arg:      nf.Plain(pxValue, -1),
 		},
 		{
-			Title:    fmt.Sprintf("%g em/rem", emValue),
-			Subtitle: fmt.Sprintf("基础字号: %gpx | 复制 'em/rem' 值", basePx),
-			Arg:      fmt.Sprintf("%g", emValue),
+			Value:    emValue,
+			Unit:     "em",
+			Title:    fmt.Sprintf("%s em/rem", nf.Format(emValue, -1)),
+			Subtitle: fmt.Sprintf("基础字号: %spx | 复制 'em/rem' 值", nf.Format(basePx, -1)),
+			Arg:      nf.Plain(emValue, -1),
 		},
 		{
-			Title:    fmt.Sprintf("%g pt", ptValue),
-			Subtitle: fmt.Sprintf("基础字号: %gpx | 复制 'pt' 值", basePx),
-			Arg:      fmt.Sprintf("%g", ptValue),
+			Value:    ptValue,
+			Unit:     "pt",
+			Title:    fmt.Sprintf("%s pt", nf.Format(ptValue, -1)),
+			Subtitle: fmt.Sprintf("基础字号: %spx | 复制 'pt' 值", nf.Format(basePx, -1)),
+			Arg:      nf.Plain(ptValue, -1),
 		},
 	}
-	alfred.AddToWorkflow(wf, results)
+	return results, nil
 }
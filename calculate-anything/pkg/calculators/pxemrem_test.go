@@ -0,0 +1,11 @@
+// calculate-anything/pkg/calculators/pxemrem_test.go
+package calculators
+
+import "testing"
+
+func TestPxEmRem(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "12px to rem", calculator: "pxemrem", title: "12px = 0.75rem"},
+		{query: "2rem", calculator: "pxemrem", title: "32 px"},
+	})
+}
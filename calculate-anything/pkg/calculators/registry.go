@@ -0,0 +1,147 @@
+// calculate-anything/pkg/calculators/registry.go
+package calculators
+
+import (
+	"calculate-anything/pkg/api"
+	"calculate-anything/pkg/config"
+	"calculate-anything/pkg/format"
+	"calculate-anything/pkg/i18n"
+	"calculate-anything/pkg/parser"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Context 携带计算器在一次查询中需要的全部依赖。
+// 它不引用 *aw.Workflow，因此计算器可以脱离 Alfred 使用。
+type Context struct {
+	Cache   api.Cache // 汇率等网络数据的缓存，通常是 wf.Cache
+	Config  *config.AppConfig
+	Lang    *i18n.LanguagePack
+	Format  *format.Formatter // 按用户配置解析和格式化数字
+	DataDir string            // workflow 的数据目录，存放节假日等用户文件
+
+	cryptos api.CryptoMap // 延迟加载的加密货币符号表，见 Cryptos
+}
+
+// Cryptos 返回加密货币符号表，在一次查询中只加载一次。
+// 无法获取时返回空表，此时所有符号都不会被当作加密货币。
+func (ctx *Context) Cryptos() api.CryptoMap {
+	if ctx.cryptos == nil {
+		cacheDuration := time.Duration(ctx.Config.CryptoCurrencyCacheHours) * time.Hour
+		cryptos, err := api.GetCryptoMap(ctx.Cache, ctx.Config.APIKeyCoinMarket, cacheDuration)
+		if err != nil {
+			cryptos = api.CryptoMap{}
+		}
+		ctx.cryptos = cryptos
+	}
+	return ctx.cryptos
+}
+
+// Calculator 是所有计算器需要实现的接口。
+// 每个计算器在自己文件的 init 函数中调用 Register 完成注册，
+// 分发、关键字触发和帮助信息都由注册表驱动，新增计算器无需修改 cmd 包。
+type Calculator interface {
+	// Name 返回计算器的名称。
+	Name() string
+	// Priority 决定匹配顺序，数值越小越先尝试。
+	Priority() int
+	// Keyword 返回触发关键字 (e.g. "time")。设置了关键字的计算器
+	// 只在查询以 "关键字 " 开头时被调用，此时 Match 收到的是去掉关键字后的输入。
+	Keyword() string
+	// Examples 返回示例查询，用于无法解析时的帮助信息。
+	Examples() []string
+	// Match 尝试解析查询，不属于该计算器时返回 nil。
+	Match(ctx *Context, query string) *parser.ParsedQuery
+	// Compute 执行计算并返回结构化结果，不直接操作 Alfred。
+	Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error)
+}
+
+// baseCalculator 提供 Calculator 接口的默认实现，供具体计算器嵌入。
+type baseCalculator struct{}
+
+// Keyword 默认没有触发关键字。
+func (baseCalculator) Keyword() string { return "" }
+
+// registry 保存所有已注册的计算器，按优先级排序。
+var registry []Calculator
+
+// Register 注册一个计算器，通常在计算器所在文件的 init 函数中调用。
+func Register(c Calculator) {
+	registry = append(registry, c)
+	sort.SliceStable(registry, func(i, j int) bool {
+		return registry[i].Priority() < registry[j].Priority()
+	})
+}
+
+// Calculators 返回按优先级排序的全部计算器。
+func Calculators() []Calculator {
+	return registry
+}
+
+// Dispatch 为查询找到合适的计算器并执行计算。
+// 先检查关键字触发，再按优先级依次尝试匹配；没有计算器匹配或计算失败时，
+// 尝试纠正查询中的单位和货币名称。仍然没有任何结果时返回 nil。
+func Dispatch(ctx *Context, query string) *Output {
+	trimmed := strings.TrimSpace(query)
+	lower := strings.ToLower(trimmed)
+
+	// 步骤 1: 关键字触发，如 "time +3 days", "vat 100"
+	for _, c := range registry {
+		kw := c.Keyword()
+		if kw == "" || !strings.HasPrefix(lower, kw+" ") {
+			continue
+		}
+		input := strings.TrimSpace(trimmed[len(kw):])
+		if p := c.Match(ctx, input); p != nil {
+			return run(ctx, c, input, p)
+		}
+	}
+
+	// 步骤 2: 按优先级匹配没有关键字的计算器
+	var out *Output
+	if c, p := matchCalculator(ctx, trimmed); c != nil {
+		if out = run(ctx, c, trimmed, p); out.Err == nil {
+			return out
+		}
+	}
+
+	// 步骤 3: 查询中可能有全称、复数或拼错的单位和货币名称, e.g. "5 km to miles"
+	if corrected := suggestCorrections(ctx, trimmed); corrected != nil {
+		return corrected
+	}
+	return out
+}
+
+// matchCalculator 按优先级找到第一个能解析查询的无关键字计算器，没有时返回 nil。
+func matchCalculator(ctx *Context, query string) (Calculator, *parser.ParsedQuery) {
+	for _, c := range registry {
+		if c.Keyword() != "" {
+			continue
+		}
+		if p := c.Match(ctx, query); p != nil {
+			return c, p
+		}
+	}
+	return nil, nil
+}
+
+// run 执行计算器并将结果或错误包装为 Output。
+func run(ctx *Context, c Calculator, input string, p *parser.ParsedQuery) *Output {
+	results, err := c.Compute(ctx, p)
+	if err != nil {
+		results = nil
+	}
+	return &Output{Calculator: c.Name(), Query: input, Results: results, Err: err}
+}
+
+// Examples 汇总所有计算器的第一个示例查询，用于帮助信息。
+func Examples() []string {
+	var examples []string
+	for _, c := range registry {
+		if ex := c.Examples(); len(ex) > 0 {
+			examples = append(examples, ex[0])
+		}
+	}
+	return examples
+}
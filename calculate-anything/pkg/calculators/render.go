@@ -0,0 +1,40 @@
+// calculate-anything/pkg/calculators/render.go
+package calculators
+
+import (
+	"calculate-anything/pkg/alfred"
+
+	aw "github.com/deanishe/awgo"
+)
+
+// Render 将计算结果转换为 Alfred 的反馈项。错误显示为警告项。
+func Render(wf *aw.Workflow, out *Output) {
+	if out.Err != nil {
+		alfred.ShowError(wf, out.Err)
+		return
+	}
+	alfred.AddToWorkflow(wf, ToAlfred(out.Results))
+}
+
+// ToAlfred 将结构化结果转换为 alfred.Result。
+func ToAlfred(results []Result) []alfred.Result {
+	items := make([]alfred.Result, 0, len(results))
+	for _, r := range results {
+		item := alfred.Result{
+			Title:        r.Title,
+			Subtitle:     r.Subtitle,
+			Arg:          r.Arg,
+			IconPath:     r.Icon,
+			Autocomplete: r.Autocomplete,
+		}
+		for _, m := range r.Modifiers {
+			item.Modifiers = append(item.Modifiers, alfred.Modifier{
+				Key:      m.Key,
+				Subtitle: m.Subtitle,
+				Arg:      m.Arg,
+			})
+		}
+		items = append(items, item)
+	}
+	return items
+}
@@ -0,0 +1,31 @@
+// calculate-anything/pkg/calculators/result.go
+package calculators
+
+// Result 是计算器返回的一条结构化结果。
+// 它只描述计算结果本身，不依赖 Alfred，由 Render 转换为 Alfred 的反馈项。
+type Result struct {
+	Value        float64    // 数值结果 (e.g., 6.2137 in "10 km = 6.2137 mi")，非数值结果为 0
+	Unit         string     // 数值结果的单位或货币 (e.g., "mi")
+	Title        string     // 格式化后的标题
+	Subtitle     string     // 格式化后的副标题
+	Arg          string     // 选中结果时复制或传递的值
+	Icon         string     // 图标路径，为空时使用默认图标
+	Modifiers    []Modifier // 修饰键对应的替代结果
+	Autocomplete string     // 不为空时选中结果不会执行，而是在 Alfred 中将查询替换为该文本
+}
+
+// Modifier 是按住修饰键（如 Cmd, Opt）时显示的替代结果。
+type Modifier struct {
+	Key      string
+	Subtitle string
+	Arg      string
+}
+
+// Output 是一次查询的完整计算结果。
+// Err 不为 nil 时表示计算器匹配了查询但计算失败，Results 此时为空。
+type Output struct {
+	Calculator string   // 处理该查询的计算器名称
+	Query      string   // 计算器实际收到的查询（关键字触发时已去掉关键字）
+	Results    []Result // 计算结果
+	Err        error    // 计算过程中的错误
+}
@@ -0,0 +1,401 @@
+// calculate-anything/pkg/calculators/suggest.go
+package calculators
+
+import (
+	"calculate-anything/pkg/i18n"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSuggestions 是 "您是不是要找…" 最多给出的候选查询数
+const maxSuggestions = 3
+
+var (
+	// 以数量开头的查询，只有这类查询中的单位和货币名称会被纠正
+	suggestionQueryRegex = regexp.MustCompile(`^\s*([\d.,]*\d[\d.,]*)\s*(\S.*)$`)
+	// 需要识别的词: 至少三个字母，更短的词 (e.g. "on") 保持原样
+	suggestionWordRegex = regexp.MustCompile(`^\p{L}{3,}$`)
+	// 候选名称: 至少两个字母，因此 "kg"、"km"、"ft" 这类符号也可以作为候选
+	suggestionNameRegex = regexp.MustCompile(`^\p{L}{2,}$`)
+)
+
+// queryConnectors 是转换查询中除停用词外的连接词，它们不是单位名称。
+var queryConnectors = map[string]bool{"into": true, "over": true, "with": true, "auto": true, "and": true}
+
+// unitNamePrefixes 是常用 SI 词头的全称，用于生成 "kilometer"、"milligram" 这类名称。
+var unitNamePrefixes = []struct{ name, symbol string }{
+	{"kilo", "k"}, {"centi", "c"}, {"milli", "m"}, {"micro", "μ"}, {"nano", "n"}, {"mega", "M"}, {"giga", "G"},
+}
+
+// extraUnitNames 补充单位表名称之外的写法: 英式拼写、不规则复数和常用的别称。
+var extraUnitNames = map[string]string{
+	"metre": "m", "kilometre": "km", "centimetre": "cm", "millimetre": "mm",
+	"liter": "L", "milliliter": "mL", "millilitre": "mL",
+	"feet": "ft", "degree": "deg", "celsius": "°C", "tonne": "t",
+}
+
+// currencyNames 是常见货币的英文名称 (单数)，复数在匹配时去掉词尾的 "s" 处理。
+var currencyNames = map[string]string{
+	"franc": "CHF", "yuan": "CNY", "renminbi": "CNY", "rupee": "INR", "peso": "MXN", "won": "KRW",
+	"ruble": "RUB", "rouble": "RUB", "krona": "SEK", "krone": "NOK", "real": "BRL", "lira": "TRY",
+	"rand": "ZAR", "zloty": "PLN", "forint": "HUF", "baht": "THB", "ringgit": "MYR", "dirham": "AED",
+	"shekel": "ILS", "rupiah": "IDR", "dong": "VND", "hryvnia": "UAH", "koruna": "CZK",
+}
+
+// unitName 是一个可以被纠正为标准符号的名称, e.g. "kilometer" -> "km", "franc" -> "CHF"。
+type unitName struct {
+	name   string // 小写的名称
+	symbol string // 查询中使用的标准符号
+}
+
+// nameMatch 是一个候选符号及其与输入词的编辑距离。
+type nameMatch struct {
+	symbol   string
+	distance int
+	swapped  bool // 只是字母顺序不同 (e.g. "mhp" 与 "mph")，距离相同时优先
+	related  bool // 与查询中另一侧的单位或货币同类，距离相同时优先于 swapped
+}
+
+// knownNames 是内置的名称表，按优先级排列: 单位、数据存储单位、货币名称、货币代码、单位符号和数据存储单位符号。
+var knownNames []unitName
+
+func init() {
+	// 同一名称只保留第一次出现的符号，e.g. "l" 与 "L" 都叫 Litre
+	added := map[string]bool{}
+	add := func(name, symbol string) {
+		name = strings.ToLower(name)
+		if suggestionNameRegex.MatchString(name) && !added[name] {
+			added[name] = true
+			knownNames = append(knownNames, unitName{name: name, symbol: symbol})
+		}
+	}
+
+	for _, name := range sortedKeys(extraUnitNames) {
+		add(name, extraUnitNames[name])
+	}
+	// 单位表的名称，去掉 "(US)" 这类说明；可加词头的单位同时生成带词头的全称
+	symbols := make([]string, 0, len(unitMap))
+	for symbol := range unitMap {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		name, _, _ := strings.Cut(unitMap[symbol].Name, " (")
+		add(name, symbol)
+		if unitMap[symbol].Prefixable {
+			for _, p := range unitNamePrefixes {
+				add(p.name+strings.ToLower(name), p.symbol+symbol)
+			}
+		}
+	}
+	for _, table := range []map[string]storageUnit{decimalUnits, binaryUnits} {
+		for _, key := range sortedKeys(table) {
+			add(table[key].Name, storageSymbol(key))
+		}
+	}
+
+	for _, name := range sortedKeys(currencyNames) {
+		add(name, currencyNames[name])
+	}
+	for _, name := range sortedKeys(currencySymbolMap) {
+		add(name, currencySymbolMap[name])
+	}
+	for _, code := range sortedKeys(fiatCurrencies) {
+		add(code, code)
+	}
+
+	// 单位符号本身和带常用词头的符号，用于纠正 "mhp"、"kgg" 这类拼写错误
+	for _, symbol := range symbols {
+		add(symbol, symbol)
+		if unitMap[symbol].Prefixable {
+			for _, p := range unitNamePrefixes {
+				add(p.symbol+symbol, p.symbol+symbol)
+			}
+		}
+	}
+	for _, alias := range sortedKeys(unitAliases) {
+		add(alias, alias)
+	}
+	for _, table := range []map[string]storageUnit{decimalUnits, binaryUnits} {
+		for _, key := range sortedKeys(table) {
+			add(key, storageSymbol(key))
+		}
+	}
+}
+
+// suggestCorrections 纠正查询中无法识别的单位和货币名称。
+// 所有名称都是全称、复数或本地化名称的精确匹配时，直接计算改写后的查询；
+// 否则返回 "您是不是要找…" 的结果项，选中后在 Alfred 中替换为改写后的查询。
+// 没有可以纠正的名称时返回 nil。
+func suggestCorrections(ctx *Context, query string) *Output {
+	m := suggestionQueryRegex.FindStringSubmatch(query)
+	if m == nil {
+		return nil
+	}
+	words := strings.Fields(m[2])
+	// 已经识别的词 (关键字换成对应的符号)，候选符号与其中的单位或货币同类时优先，
+	// e.g. "5 kgg to lb" 纠正为 kg 而不是货币 KGS
+	var unknown []int
+	var known []string
+	for i, word := range words {
+		if suggestionWordRegex.MatchString(word) && !isKnownWord(ctx, word) {
+			unknown = append(unknown, i)
+			continue
+		}
+		if ctx.Lang != nil {
+			if symbol, ok := ctx.Lang.Keywords[strings.ToLower(word)]; ok {
+				word = symbol
+			}
+		}
+		known = append(known, word)
+	}
+	related := func(symbol string) bool {
+		for _, other := range known {
+			if sameFamily(ctx, symbol, other) {
+				return true
+			}
+		}
+		return false
+	}
+
+	corrections := map[int][]nameMatch{}
+	exact := true
+	for _, i := range unknown {
+		matches := resolveName(words[i], ctx.Lang, related)
+		if len(matches) == 0 {
+			continue
+		}
+		corrections[i] = matches
+		// 多个符号同样精确时 (e.g. "pound" 既是英镑也是磅) 需要用户选择
+		if matches[0].distance > 0 || (len(matches) > 1 && matches[1].distance == 0) {
+			exact = false
+		}
+	}
+	if len(corrections) == 0 {
+		return nil
+	}
+
+	if exact {
+		rewritten := rewriteQuery(m[1], words, corrections, 0)
+		if c, p := matchCalculator(ctx, rewritten); c != nil {
+			if out := run(ctx, c, rewritten, p); out.Err == nil {
+				return out
+			}
+		}
+	}
+
+	// 第 k 个候选查询使用每个词的第 k 个候选符号，只保留改写后能算出结果的查询,
+	// 并在副标题中预览结果
+	var results []Result
+	seen := map[string]bool{}
+	for k := 0; k < maxSuggestions; k++ {
+		rewritten := rewriteQuery(m[1], words, corrections, k)
+		if seen[rewritten] {
+			continue
+		}
+		seen[rewritten] = true
+		c, p := matchCalculator(ctx, rewritten)
+		if c == nil {
+			continue
+		}
+		out := run(ctx, c, rewritten, p)
+		if out.Err != nil || len(out.Results) == 0 {
+			continue
+		}
+		results = append(results, Result{
+			Title:        fmt.Sprintf("您是不是要找 '%s'?", rewritten),
+			Subtitle:     fmt.Sprintf("%s · 按 ↩ 替换查询", out.Results[0].Title),
+			Arg:          rewritten,
+			Autocomplete: rewritten,
+		})
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	return &Output{Calculator: "suggest", Query: query, Results: results}
+}
+
+// rewriteQuery 用第 k 个候选符号 (不足时用最后一个) 替换无法识别的词。
+func rewriteQuery(amount string, words []string, corrections map[int][]nameMatch, k int) string {
+	rewritten := make([]string, len(words))
+	for i, word := range words {
+		rewritten[i] = word
+		if matches, ok := corrections[i]; ok {
+			j := k
+			if j >= len(matches) {
+				j = len(matches) - 1
+			}
+			rewritten[i] = matches[j].symbol
+		}
+	}
+	return amount + " " + strings.Join(rewritten, " ")
+}
+
+// isKnownWord 报告一个词是否已经能被某个计算器识别，这类词不需要纠正。
+func isKnownWord(ctx *Context, word string) bool {
+	lower := strings.ToLower(word)
+	if queryConnectors[lower] {
+		return true
+	}
+	if ctx.Lang != nil {
+		if _, ok := ctx.Lang.Keywords[lower]; ok {
+			return true
+		}
+		if containsString(ctx.Lang.StopWords, lower) {
+			return true
+		}
+	}
+	return len(resolveUnit(word)) > 0 || IsCurrency(word) || IsDataStorageUnit(word) || IsCrypto(ctx, word)
+}
+
+// sameFamily 报告两个符号是否可以互相转换: 同类的物理单位、两种货币 (含加密货币) 或两个数据存储单位。
+func sameFamily(ctx *Context, symbol, other string) bool {
+	if IsPhysicalUnitPair(symbol, other) {
+		return true
+	}
+	money := func(s string) bool { return IsCurrency(s) || IsCrypto(ctx, s) }
+	return (money(symbol) && money(other)) || (IsDataStorageUnit(symbol) && IsDataStorageUnit(other))
+}
+
+// resolveName 在语言包的关键字和内置名称表中查找与 word 相近的名称，
+// 按编辑距离排序返回最多 maxSuggestions 个候选符号，距离相同时 related 的符号优先。
+// 复数形式 ("miles", "inches") 按单数匹配。
+func resolveName(word string, lang *i18n.LanguagePack, related func(symbol string) bool) []nameMatch {
+	lower := strings.ToLower(word)
+	forms := []string{lower}
+	if strings.HasSuffix(lower, "es") {
+		forms = append(forms, strings.TrimSuffix(lower, "es"))
+	}
+	if strings.HasSuffix(lower, "s") {
+		forms = append(forms, strings.TrimSuffix(lower, "s"))
+	}
+	limit := maxTypos(lower)
+
+	// 本地化名称优先于内置名称，e.g. es_ES 的 "kilometros"
+	names := knownNames
+	if lang != nil && len(lang.Keywords) > 0 {
+		names = make([]unitName, 0, len(lang.Keywords)+len(knownNames))
+		for _, name := range sortedKeys(lang.Keywords) {
+			names = append(names, unitName{name: name, symbol: lang.Keywords[name]})
+		}
+		names = append(names, knownNames...)
+	}
+
+	var matches []nameMatch
+	index := map[string]int{} // 符号在 matches 中的位置
+	for _, n := range names {
+		match := nameMatch{symbol: n.symbol, distance: limit + 1}
+		for _, form := range forms {
+			if d := editDistance(form, n.name); d < match.distance {
+				match.distance, match.swapped = d, d > 0 && sameLetters(form, n.name)
+			}
+		}
+		if match.distance > limit {
+			continue
+		}
+		if i, ok := index[n.symbol]; ok {
+			if match.distance < matches[i].distance {
+				matches[i] = match
+			}
+			continue
+		}
+		index[n.symbol] = len(matches)
+		matches = append(matches, match)
+	}
+
+	for i := range matches {
+		matches[i].related = related != nil && related(matches[i].symbol)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		if matches[i].related != matches[j].related {
+			return matches[i].related
+		}
+		return matches[i].swapped && !matches[j].swapped
+	})
+	if len(matches) > maxSuggestions {
+		matches = matches[:maxSuggestions]
+	}
+	return matches
+}
+
+// maxTypos 返回一个词允许的拼写错误数。少于八个字母的词只允许一处错误，
+// 以免普通的短词被纠正为毫不相关的单位。
+func maxTypos(word string) int {
+	if utf8.RuneCountInString(word) < 8 {
+		return 1
+	}
+	return 2
+}
+
+// editDistance 返回两个字符串的编辑距离 (Damerau-Levenshtein 的 OSA 变体)，
+// 相邻字符的交换算作一次编辑，因此 "kilomteer" 与 "kilometer" 的距离为 1。
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	// d[i][j] 是 s[:i] 与 t[:j] 之间的距离
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			best := d[i-1][j-1] + cost
+			if v := d[i-1][j] + 1; v < best {
+				best = v
+			}
+			if v := d[i][j-1] + 1; v < best {
+				best = v
+			}
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] && d[i-2][j-2]+1 < best {
+				best = d[i-2][j-2] + 1
+			}
+			d[i][j] = best
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// sameLetters 报告两个词是否由相同的字母组成，只是顺序不同。
+func sameLetters(a, b string) bool {
+	x, y := []rune(a), []rune(b)
+	if len(x) != len(y) {
+		return false
+	}
+	sort.Slice(x, func(i, j int) bool { return x[i] < x[j] })
+	sort.Slice(y, func(i, j int) bool { return y[i] < y[j] })
+	return string(x) == string(y)
+}
+
+// storageSymbol 将存储单位表的键转换为常用写法, e.g. "KIB" -> "KiB", "BIT" -> "bit"。
+func storageSymbol(key string) string {
+	switch {
+	case key == "BIT":
+		return "bit"
+	case isBinaryUnit(key):
+		return key[:1] + "iB"
+	}
+	return key
+}
+
+// sortedKeys 返回 map 的键，按字母顺序排列，使名称表的顺序固定。
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
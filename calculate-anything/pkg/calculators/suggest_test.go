@@ -0,0 +1,19 @@
+// calculate-anything/pkg/calculators/suggest_test.go
+package calculators
+
+import "testing"
+
+func TestSuggestCorrections(t *testing.T) {
+	checkQueries(t, []queryTest{
+		// 全称精确匹配时直接计算
+		{query: "5 kilometre to mile", calculator: "units", title: "5 km = 3.10685596118667 mi"},
+		{query: "10 mhp to kmh", calculator: "suggest", title: "您是不是要找 '10 mph to kmh'?"},
+		// 两个字母的符号也是候选，与另一侧同类的优先于货币代码 KGS
+		{query: "5 kgg to lb", calculator: "suggest", title: "您是不是要找 '5 kg to lb'?"},
+		{query: "10 kmm to mi", calculator: "suggest", title: "您是不是要找 '10 km to mi'?"},
+		{query: "3 gb to mbb", calculator: "suggest", title: "您是不是要找 '3 gb to MB'?"},
+		{query: "100 usd to eurr", calculator: "suggest", title: "您是不是要找 '100 usd to EUR'?"},
+		// 没有可用的纠正时返回原来的错误
+		{query: "5 xyzzy to lb", calculator: "units", err: "未知的源单位"},
+	})
+}
@@ -2,28 +2,50 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
-	"calculate-anything/pkg/config"
 	"calculate-anything/pkg/parser"
+	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/deanishe/awgo"
 )
 
-// 正则表达式用于匹配不同类型的时间查询
+// 正则表达式用于匹配不同类型的时间查询，日期表达式本身的语法见 dates.go
 var (
-	// 匹配 "time +15 days", "time now - 2 hours"
-	timeRelativeRegex = regexp.MustCompile(`(?i)^\s*(now|today)?\s*([+\-])\s*(\d+)\s*(year|month|day|week|hr|min|s)s?\b`)
-	// 匹配 10 位 Unix 时间戳
-	timestampRegex = regexp.MustCompile(`^\s*(\d{10})\s*$`)
+	// 匹配 Unix 时间戳: 9-11 位为秒，12-14 位为毫秒，15-17 位为微秒，18-19 位为纳秒
+	timestampRegex = regexp.MustCompile(`^\s*(-?\d{9,19})\s*$`)
+	// 匹配 "2025-01-31 15:00 to epoch", "now in unix ms"
+	toEpochRegex = regexp.MustCompile(`(?i)^(.+?)\s+(?:to|in|as)\s+(?:epoch|unix|timestamp)(?:\s+(ms|millis|milliseconds))?$`)
+	// 匹配 "days until 31 december", "since 2020-01-01", "workdays until friday"
+	dateUntilRegex = regexp.MustCompile(`(?i)^(?:(days|weeks|workdays|business\s+days)\s+)?(until|till|since)\s+(.+)$`)
+	// 匹配 "days between 2025-01-01 and 2025-03-01", "workdays between 2025-03-01 and 2025-04-15"
+	dateBetweenRegex = regexp.MustCompile(`(?i)^(?:(days|weeks|workdays|business\s+days)\s+)?between\s+(.+?)\s+and\s+(.+)$`)
+	// 匹配 "weekday of 2025-01-31", "week of next friday"
+	dateInfoRegex = regexp.MustCompile(`(?i)^(weekday|day|week|week number|iso week)\s+of\s+(.+)$`)
 )
 
-// HandleTime 处理所有与时间相关的查询。
-func HandleTime(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery) {
+// timeCalculator 处理所有与时间相关的查询，由 "time" 关键字触发。
+type timeCalculator struct{ baseCalculator }
+
+func init() { Register(&timeCalculator{}) }
+
+func (*timeCalculator) Name() string    { return "time" }
+func (*timeCalculator) Priority() int   { return 60 }
+func (*timeCalculator) Keyword() string { return "time" }
+
+func (*timeCalculator) Examples() []string {
+	return []string{"time +3 days", "time next friday", "time days until 31 december", "time 2025-03-01 - 2025-01-01", "time +10 workdays", "time 1577836800"}
+}
+
+// Match 接受关键字之后的任意输入，具体格式在 Compute 中校验。
+func (*timeCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	return &parser.ParsedQuery{Type: parser.TimeQuery, Input: query}
+}
+
+// Compute 解析时间戳或日期表达式并计算结果。
+func (*timeCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	cfg := ctx.Config
 	// 加载用户配置的时区，如果失败则使用 UTC
 	loc, err := time.LoadLocation(cfg.Timezone)
 	if err != nil {
@@ -31,68 +53,244 @@ func HandleTime(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery) {
 		// 记录失败不是关键，避免依赖 Logger()
 	}
 	now := time.Now().In(loc)
-	input := p.Input
-
-	// --- 场景 1: 尝试解析时间戳 ---
-	matches := timestampRegex.FindStringSubmatch(input)
-	if len(matches) == 2 {
-		ts, _ := strconv.ParseInt(matches[1], 10, 64)
-		t := time.Unix(ts, 0).In(loc)
-		// 使用用户配置的日期格式进行格式化
-		resultString := t.Format(cfg.DateFormat)
-		title := fmt.Sprintf("时间戳转换结果: %s", resultString)
-		alfred.AddToWorkflow(wf, []alfred.Result{
-			{Title: title, Subtitle: "复制日期", Arg: resultString, IconPath: "clock.png"},
-		})
-		return
-	}
-
-	// --- 场景 2: 尝试解析相对时间 ---
-	matches = timeRelativeRegex.FindStringSubmatch(input)
-	if len(matches) == 5 {
-		operator := matches[2]
-		amount, _ := strconv.Atoi(matches[3])
-		unit := strings.ToLower(matches[4])
-
-		var futureTime time.Time
-		if operator == "-" {
-			amount = -amount // 如果是减号，则数量为负
+	input := strings.TrimSpace(p.Input)
+	cal := loadBusinessCalendar(cfg, ctx.DataDir)
+
+	results, err := computeTime(ctx, input, now, cal)
+	if err != nil {
+		return nil, err
+	}
+	// 节假日文件有问题时计算仍按周末进行，但需要让用户知道
+	if cal.used && cal.err != nil {
+		results = append(results, Result{Title: "工作日计算未包含节假日", Subtitle: cal.err.Error(), Icon: "clock.png"})
+	}
+	return results, nil
+}
+
+// computeTime 按顺序尝试各种时间查询。
+func computeTime(ctx *Context, input string, now time.Time, cal *businessCalendar) ([]Result, error) {
+	loc := now.Location()
+
+	// --- 场景 1: 尝试解析时间戳，位数决定精度 ---
+	if m := timestampRegex.FindStringSubmatch(input); m != nil {
+		if t, precision, ok := parseTimestamp(m[1]); ok {
+			return timestampResults(ctx, t.In(loc), precision, now), nil
 		}
+	}
+	// 反向转换: 日期 -> 时间戳
+	if m := toEpochRegex.FindStringSubmatch(input); m != nil {
+		t, err := parseDate(m[1], now, cal)
+		if err != nil {
+			return nil, err
+		}
+		first := "epoch"
+		if m[2] != "" {
+			first = "epoch ms"
+		}
+		return dateResults(ctx, t, first), nil
+	}
+
+	// --- 场景 2: 时区转换, e.g. "time 3pm PST in Tokyo" ---
+	if c := parseZoneConversion(input, zoneRef{loc.String(), loc}, now); c != nil {
+		return zoneResults(ctx, c)
+	}
 
-		// 根据单位进行相应的日期/时间增减
-		switch unit {
-		case "year":
-			futureTime = now.AddDate(amount, 0, 0)
-		case "month":
-			futureTime = now.AddDate(0, amount, 0)
-		case "week":
-			futureTime = now.AddDate(0, 0, amount*7)
-		case "day":
-			futureTime = now.AddDate(0, 0, amount)
-		case "hr":
-			futureTime = now.Add(time.Duration(amount) * time.Hour)
-		case "min":
-			futureTime = now.Add(time.Duration(amount) * time.Minute)
-		case "s":
-			futureTime = now.Add(time.Duration(amount) * time.Second)
-		default:
-			alfred.ShowError(wf, fmt.Errorf("未知的时间单位: %s", unit))
-			return
+	// --- 场景 3: 两个日期之间的间隔 ---
+	if m := dateUntilRegex.FindStringSubmatch(input); m != nil {
+		target, err := parseDate(m[3], now, cal)
+		if err != nil {
+			return nil, err
+		}
+		// 目标只是日期时按日历天计算，不受当前时刻影响
+		ref := now
+		if target.Equal(startOfDay(target)) {
+			ref = startOfDay(now)
+		}
+		if strings.EqualFold(m[2], "since") {
+			return dateDiffResults(ctx, target, ref, m[1], cal)
+		}
+		// 省略年份的日期已经过去时指下一年的这一天, e.g. 12 月 31 日之后的 "until 1 january"
+		if target.Before(startOfDay(now)) && !yearRegex.MatchString(m[3]) {
+			target = addDateUnit(target, 1, "year")
 		}
+		return dateDiffResults(ctx, ref, target, m[1], cal)
+	}
+	if m := dateBetweenRegex.FindStringSubmatch(input); m != nil {
+		from, err := parseDate(m[2], now, cal)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseDate(m[3], now, cal)
+		if err != nil {
+			return nil, err
+		}
+		return dateDiffResults(ctx, from, to, m[1], cal)
+	}
+	// "X - Y": 减号两侧都是日期时计算差值，否则是 "now - 2 hours" 这类偏移
+	for _, idx := range dateMinusRegex.FindAllStringIndex(input, -1) {
+		to, err1 := parseDate(input[:idx[0]], now, cal)
+		from, err2 := parseDate(input[idx[1]:], now, cal)
+		if err1 == nil && err2 == nil {
+			return dateDiffResults(ctx, from, to, "", cal)
+		}
+	}
 
-		resultString := futureTime.Format(cfg.DateFormat)
-		title := fmt.Sprintf("结果: %s", resultString)
-		subtitle := "复制日期到剪贴板"
+	// --- 场景 4: 单个日期表达式，显示日期、星期和周数 ---
+	first := ""
+	if m := dateInfoRegex.FindStringSubmatch(input); m != nil {
+		first, input = strings.ToLower(m[1]), m[2]
+	}
+	t, err := parseDate(input, now, cal)
+	if err == nil {
+		return dateResults(ctx, t, first), nil
+	}
+	// 工作日偏移超出范围时直接报告原因，而不是通用的帮助信息
+	if errors.Is(err, errWorkdayRange) {
+		return nil, err
+	}
+
+	// 如果所有解析都失败，返回带有帮助信息的错误
+	return nil, fmt.Errorf("无效的时间查询，请尝试 'time +3 days', 'time next friday', 'time end of month', 'time days until 31 december' 或 'time 1577836800'")
+}
+
+// dateResults 返回日期本身、星期几和 ISO 周数三行结果。
+// first 为 "week" 系列时周数排在最前，为 "weekday"/"day" 时星期排在最前。
+func dateResults(ctx *Context, t time.Time, first string) []Result {
+	formatted := t.Format(ctx.Config.DateFormat)
+	year, week := t.ISOWeek()
+	isoWeek := fmt.Sprintf("%d-W%02d", year, week)
+
+	date := Result{Title: fmt.Sprintf("结果: %s", formatted), Subtitle: "复制日期到剪贴板", Arg: formatted, Icon: "clock.png"}
+	weekday := Result{
+		Value:    float64(t.Weekday()),
+		Title:    weekdayNames[t.Weekday()],
+		Subtitle: fmt.Sprintf("%s 是%s", t.Format("2006-01-02"), weekdayNames[t.Weekday()]),
+		Arg:      weekdayNames[t.Weekday()],
+		Icon:     "clock.png",
+	}
+	isoWeekRow := Result{
+		Value:    float64(week),
+		Title:    fmt.Sprintf("ISO 周: %s", isoWeek),
+		Subtitle: fmt.Sprintf("第 %d 周 · 当年第 %d 天", week, t.YearDay()),
+		Arg:      isoWeek,
+		Icon:     "clock.png",
+	}
+
+	seconds := strconv.FormatInt(t.Unix(), 10)
+	millis := strconv.FormatInt(t.UnixMilli(), 10)
+	epoch := Result{Value: float64(t.Unix()), Unit: "s", Title: fmt.Sprintf("Unix 时间戳: %s", seconds), Subtitle: "秒 · 复制时间戳", Arg: seconds, Icon: "clock.png"}
+	epochMs := Result{Value: float64(t.UnixMilli()), Unit: "ms", Title: fmt.Sprintf("Unix 时间戳 (毫秒): %s", millis), Subtitle: "毫秒 · 复制时间戳", Arg: millis, Icon: "clock.png"}
+
+	switch first {
+	case "weekday", "day":
+		return []Result{weekday, date, isoWeekRow, epoch, epochMs}
+	case "week", "week number", "iso week":
+		return []Result{isoWeekRow, date, weekday, epoch, epochMs}
+	case "epoch":
+		return []Result{epoch, epochMs, date, weekday, isoWeekRow}
+	case "epoch ms":
+		return []Result{epochMs, epoch, date, weekday, isoWeekRow}
+	}
+	return []Result{date, weekday, isoWeekRow, epoch, epochMs}
+}
+
+// parseTimestamp 按位数识别时间戳的精度并转换为时间。
+func parseTimestamp(s string) (time.Time, string, bool) {
+	ts, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	switch digits := len(strings.TrimPrefix(s, "-")); {
+	case digits <= 11:
+		return time.Unix(ts, 0), "秒", true
+	case digits <= 14:
+		return time.UnixMilli(ts), "毫秒", true
+	case digits <= 17:
+		return time.UnixMicro(ts), "微秒", true
+	}
+	return time.Unix(0, ts), "纳秒", true
+}
+
+// timestampResults 返回时间戳对应的时间: 按配置格式、ISO 8601 (RFC 3339)、RFC 1123 和相对时间，每行都可以复制。
+func timestampResults(ctx *Context, t time.Time, precision string, now time.Time) []Result {
+	formatted := t.Format(ctx.Config.DateFormat)
+	iso := t.Format(time.RFC3339Nano)
+	rfc1123 := t.Format(time.RFC1123)
+	relative := relativeTimeText(t.Sub(now))
+
+	return []Result{
+		{Title: fmt.Sprintf("时间戳转换结果: %s", formatted), Subtitle: fmt.Sprintf("%s级时间戳 · 复制日期", precision), Arg: formatted, Icon: "clock.png"},
+		{Title: fmt.Sprintf("ISO 8601: %s", iso), Subtitle: "RFC 3339 · 复制", Arg: iso, Icon: "clock.png"},
+		{Title: fmt.Sprintf("RFC 1123: %s", rfc1123), Subtitle: "复制", Arg: rfc1123, Icon: "clock.png"},
+		{Title: relative, Subtitle: "相对于现在 · 复制", Arg: relative, Icon: "clock.png"},
+	}
+}
 
-		alfred.AddToWorkflow(wf, []alfred.Result{
-			{Title: title, Subtitle: subtitle, Arg: resultString, IconPath: "clock.png"},
-		})
-		return
+// dateDiffResults 返回 from 到 to 的间隔: 天数、周和天、年月日，带时刻时还有小时和分钟。
+// unit 为 "weeks" 时周数排在最前，为 "workdays" 时在最前加上按工作日历统计的工作日数。
+func dateDiffResults(ctx *Context, from, to time.Time, unit string, cal *businessCalendar) ([]Result, error) {
+	nf := ctx.Format
+	unit = strings.ToLower(strings.Join(strings.Fields(unit), " "))
+	sign := ""
+	if to.Before(from) {
+		from, to, sign = to, from, "-"
 	}
-	
-	// --- 其他场景: 如 "start of year", "days until 31 december" ---
-	// 这需要更复杂的自然语言日期解析，超出了当前范围，但可以在此扩展。
+	days := calendarDays(from, to)
+	span := fmt.Sprintf("%s → %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
 
-	// 如果所有解析都失败，显示帮助信息
-	wf.NewItem("无效的时间查询").Subtitle("请尝试 'time +3 days', 'time -2 months', 或 'time 1577836800'").Valid(false)
+	results := []Result{{
+		Value:    float64(days),
+		Unit:     "day",
+		Title:    fmt.Sprintf("%s%s 天", sign, nf.Format(float64(days), 0)),
+		Subtitle: span,
+		Arg:      sign + strconv.Itoa(days),
+		Icon:     "clock.png",
+	}}
+	if days >= 7 {
+		weeks := fmt.Sprintf("%s%d 周 %d 天", sign, days/7, days%7)
+		row := Result{Value: float64(days) / 7, Unit: "week", Title: weeks, Subtitle: span, Arg: weeks, Icon: "clock.png"}
+		if unit == "weeks" {
+			results = append([]Result{row}, results...)
+		} else {
+			results = append(results, row)
+		}
+	}
+	if y, m, d := calendarDiff(from, to); y > 0 || m > 0 {
+		var parts []string
+		for _, part := range []struct {
+			n    int
+			unit string
+		}{{y, "年"}, {m, "个月"}, {d, "天"}} {
+			if part.n > 0 {
+				parts = append(parts, fmt.Sprintf("%d %s", part.n, part.unit))
+			}
+		}
+		ymd := sign + strings.Join(parts, " ")
+		results = append(results, Result{Title: ymd, Subtitle: span, Arg: ymd, Icon: "clock.png"})
+	}
+	if !from.Equal(startOfDay(from)) || !to.Equal(startOfDay(to)) {
+		d := to.Sub(from)
+		hm := fmt.Sprintf("%s%d 小时 %d 分钟", sign, int(d.Hours()), int(d.Minutes())%60)
+		results = append(results, Result{Value: d.Hours(), Unit: "hour", Title: hm, Subtitle: span, Arg: hm, Icon: "clock.png"})
+	}
+	if unit == "workdays" || unit == "business days" {
+		count, skipped, err := cal.workdaysBetween(from, to)
+		if err != nil {
+			return nil, err
+		}
+		subtitle := span + " · 含首尾两天"
+		if len(skipped) > 0 {
+			subtitle += " · 跳过节假日: " + strings.Join(skipped, ", ")
+		}
+		row := Result{
+			Value:    float64(count),
+			Unit:     "workday",
+			Title:    fmt.Sprintf("%s%s 个工作日", sign, nf.Format(float64(count), 0)),
+			Subtitle: subtitle,
+			Arg:      sign + strconv.Itoa(count),
+			Icon:     "clock.png",
+		}
+		results = append([]Result{row}, results...)
+	}
+	return results, nil
 }
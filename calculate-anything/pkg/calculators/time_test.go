@@ -0,0 +1,65 @@
+// calculate-anything/pkg/calculators/time_test.go
+package calculators
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTime(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "time 2025-03-01 - 2025-01-01", calculator: "time", title: "59 天"},
+		{query: "time 1577836800", calculator: "time", title: "时间戳转换结果: 2020-01-01 01:00:00"},
+		{query: "time 2025-03-03 + 5 workdays", calculator: "time", title: "结果: 2025-03-10 00:00:00"},
+		{query: "time 2025-03-03 + 12 workdays", calculator: "time", title: "结果: 2025-03-19 00:00:00"},
+		{query: "time 2025-03-07 - 5 workdays", calculator: "time", title: "结果: 2025-02-28 00:00:00"},
+		{query: "time workdays between 2025-03-03 and 2025-03-14", calculator: "time", title: "10 个工作日"},
+		{query: "time +99999999 workdays", calculator: "time", err: "偏移不能超过"},
+		{query: "time workdays between 2025-01-01 and 3025-01-01", calculator: "time", err: "统计的范围不能超过"},
+	})
+}
+
+func TestBusinessCalendar(t *testing.T) {
+	cal := &businessCalendar{
+		weekend:  map[time.Weekday]bool{time.Saturday: true, time.Sunday: true},
+		holidays: map[string]string{"2025-04-18": "Good Friday"},
+		annual:   map[string]string{"12-25": "Christmas", "12-26": "Boxing Day"},
+	}
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	addTests := []struct {
+		from string
+		n    int
+		want string
+	}{
+		{"2025-04-17", 1, "2025-04-21"},
+		{"2025-04-22", -2, "2025-04-17"},
+		{"2025-12-24", 1, "2025-12-29"},
+		{"2025-01-01", 257, "2025-12-31"},
+		{"2025-12-31", -257, "2025-01-01"},
+		{"2025-01-01", 260, "2026-01-05"},
+	}
+	for _, tt := range addTests {
+		got, err := cal.addWorkdays(day(tt.from), tt.n)
+		if err != nil || got.Format("2006-01-02") != tt.want {
+			t.Errorf("addWorkdays(%s, %d) = %s, %v, want %s", tt.from, tt.n, got.Format("2006-01-02"), err, tt.want)
+		}
+	}
+
+	count, skipped, err := cal.workdaysBetween(day("2025-01-01"), day("2025-12-31"))
+	if err != nil || count != 258 || len(skipped) != 3 {
+		t.Errorf("workdaysBetween(2025) = %d, %v, %v, want 258 with 3 holidays", count, skipped, err)
+	}
+
+	// 每年的每一天都是节假日时没有工作日，必须报错而不是一直查找
+	for d := day("2024-01-01"); d.Year() == 2024; d = d.AddDate(0, 0, 1) {
+		cal.annual[d.Format("01-02")] = "holiday"
+	}
+	if _, err := cal.addWorkdays(day("2025-01-01"), 1); !errors.Is(err, errWorkdayRange) {
+		t.Errorf("addWorkdays without workdays: err = %v, want errWorkdayRange", err)
+	}
+}
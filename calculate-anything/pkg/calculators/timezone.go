@@ -0,0 +1,317 @@
+// calculate-anything/pkg/calculators/timezone.go
+package calculators
+
+import (
+	"calculate-anything/pkg/parser"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timezoneQuery 是时区转换查询的类型
+const timezoneQuery parser.QueryType = "timezone"
+
+var (
+	// 匹配 "3pm PST in Tokyo", "now in Berlin, NYC", "in london" (省略时间表示现在)
+	zoneConversionRegex = regexp.MustCompile(`(?i)^(?:(.*?)\s+)?(?:in|to)\s+(.+)$`)
+	// 目标时区之间的分隔符
+	zoneListSeparator = regexp.MustCompile(`(?i)\s*,\s*|\s+and\s+`)
+	// UTC 偏移, e.g. "UTC+2", "GMT-05:30", "+0530"
+	utcOffsetRegex = regexp.MustCompile(`(?i)^(?:utc|gmt)?\s*([+\-])(\d{1,2})(?::?(\d{2}))?$`)
+)
+
+// 明确区分标准时间和夏令时的缩写是固定偏移 (秒)，"3pm PST" 在夏天也是 UTC-8。
+// 不区分的通用缩写 (PT, ET, ...) 见 cityZones，按所在地区的夏令时规则换算。
+var zoneAbbreviations = map[string]int{
+	"UTC": 0, "GMT": 0, "Z": 0, "WET": 0, "WEST": 3600, "BST": 3600, "IST": 19800,
+	"CET": 3600, "CEST": 7200, "EET": 7200, "EEST": 10800, "MSK": 10800,
+	"EST": -18000, "EDT": -14400, "CST": -21600, "CDT": -18000, "MST": -25200, "MDT": -21600,
+	"PST": -28800, "PDT": -25200, "AKST": -32400, "AKDT": -28800, "HST": -36000,
+	"AST": -14400, "ADT": -10800, "NST": -12600, "NDT": -9000, "BRT": -10800, "ART": -10800,
+	"GST": 14400, "PKT": 18000, "ICT": 25200, "WIB": 25200, "SGT": 28800, "HKT": 28800, "AWST": 28800,
+	"JST": 32400, "KST": 32400, "ACST": 34200, "ACDT": 37800, "AEST": 36000, "AEDT": 39600,
+	"NZST": 43200, "NZDT": 46800, "SAST": 7200, "WAT": 3600, "EAT": 10800,
+}
+
+// cityZone 是城市名或通用时区缩写对应的 IANA 时区。
+type cityZone struct {
+	name string // 显示名称
+	zone string // IANA 时区名
+}
+
+// cityZones 按小写名称索引，包含常用城市、别名和不区分夏令时的通用缩写。
+var cityZones = map[string]cityZone{
+	// 通用缩写，遵循当地的夏令时规则
+	"pt": {"Pacific Time", "America/Los_Angeles"}, "mt": {"Mountain Time", "America/Denver"},
+	"ct": {"Central Time", "America/Chicago"}, "et": {"Eastern Time", "America/New_York"},
+	"uk": {"UK", "Europe/London"},
+
+	// 北美
+	"new york": {"New York", "America/New_York"}, "nyc": {"New York", "America/New_York"},
+	"boston": {"Boston", "America/New_York"}, "washington": {"Washington", "America/New_York"},
+	"miami": {"Miami", "America/New_York"}, "atlanta": {"Atlanta", "America/New_York"},
+	"toronto": {"Toronto", "America/Toronto"}, "montreal": {"Montreal", "America/Toronto"},
+	"chicago": {"Chicago", "America/Chicago"}, "dallas": {"Dallas", "America/Chicago"},
+	"houston": {"Houston", "America/Chicago"}, "austin": {"Austin", "America/Chicago"},
+	"mexico city": {"Mexico City", "America/Mexico_City"}, "denver": {"Denver", "America/Denver"},
+	"phoenix": {"Phoenix", "America/Phoenix"}, "los angeles": {"Los Angeles", "America/Los_Angeles"},
+	"la": {"Los Angeles", "America/Los_Angeles"}, "san francisco": {"San Francisco", "America/Los_Angeles"},
+	"sf": {"San Francisco", "America/Los_Angeles"}, "seattle": {"Seattle", "America/Los_Angeles"},
+	"vancouver": {"Vancouver", "America/Vancouver"}, "anchorage": {"Anchorage", "America/Anchorage"},
+	"honolulu": {"Honolulu", "Pacific/Honolulu"},
+
+	// 南美
+	"sao paulo": {"São Paulo", "America/Sao_Paulo"}, "são paulo": {"São Paulo", "America/Sao_Paulo"},
+	"rio de janeiro": {"Rio de Janeiro", "America/Sao_Paulo"}, "buenos aires": {"Buenos Aires", "America/Argentina/Buenos_Aires"},
+	"santiago": {"Santiago", "America/Santiago"}, "lima": {"Lima", "America/Lima"},
+	"bogota": {"Bogotá", "America/Bogota"}, "caracas": {"Caracas", "America/Caracas"},
+
+	// 欧洲
+	"london": {"London", "Europe/London"}, "dublin": {"Dublin", "Europe/Dublin"},
+	"lisbon": {"Lisbon", "Europe/Lisbon"}, "madrid": {"Madrid", "Europe/Madrid"},
+	"barcelona": {"Barcelona", "Europe/Madrid"}, "paris": {"Paris", "Europe/Paris"},
+	"brussels": {"Brussels", "Europe/Brussels"}, "amsterdam": {"Amsterdam", "Europe/Amsterdam"},
+	"berlin": {"Berlin", "Europe/Berlin"}, "munich": {"Munich", "Europe/Berlin"},
+	"frankfurt": {"Frankfurt", "Europe/Berlin"}, "hamburg": {"Hamburg", "Europe/Berlin"},
+	"zurich": {"Zurich", "Europe/Zurich"}, "geneva": {"Geneva", "Europe/Zurich"},
+	"vienna": {"Vienna", "Europe/Vienna"}, "rome": {"Rome", "Europe/Rome"},
+	"milan": {"Milan", "Europe/Rome"}, "prague": {"Prague", "Europe/Prague"},
+	"warsaw": {"Warsaw", "Europe/Warsaw"}, "budapest": {"Budapest", "Europe/Budapest"},
+	"copenhagen": {"Copenhagen", "Europe/Copenhagen"}, "oslo": {"Oslo", "Europe/Oslo"},
+	"stockholm": {"Stockholm", "Europe/Stockholm"}, "helsinki": {"Helsinki", "Europe/Helsinki"},
+	"athens": {"Athens", "Europe/Athens"}, "bucharest": {"Bucharest", "Europe/Bucharest"},
+	"kyiv": {"Kyiv", "Europe/Kyiv"}, "kiev": {"Kyiv", "Europe/Kyiv"},
+	"istanbul": {"Istanbul", "Europe/Istanbul"}, "moscow": {"Moscow", "Europe/Moscow"},
+
+	// 非洲和中东
+	"cairo": {"Cairo", "Africa/Cairo"}, "lagos": {"Lagos", "Africa/Lagos"},
+	"nairobi": {"Nairobi", "Africa/Nairobi"}, "johannesburg": {"Johannesburg", "Africa/Johannesburg"},
+	"cape town": {"Cape Town", "Africa/Johannesburg"}, "casablanca": {"Casablanca", "Africa/Casablanca"},
+	"tel aviv": {"Tel Aviv", "Asia/Jerusalem"}, "jerusalem": {"Jerusalem", "Asia/Jerusalem"},
+	"dubai": {"Dubai", "Asia/Dubai"}, "abu dhabi": {"Abu Dhabi", "Asia/Dubai"},
+	"riyadh": {"Riyadh", "Asia/Riyadh"}, "doha": {"Doha", "Asia/Qatar"}, "tehran": {"Tehran", "Asia/Tehran"},
+
+	// 亚洲和大洋洲
+	"karachi": {"Karachi", "Asia/Karachi"}, "mumbai": {"Mumbai", "Asia/Kolkata"},
+	"delhi": {"Delhi", "Asia/Kolkata"}, "new delhi": {"New Delhi", "Asia/Kolkata"},
+	"bangalore": {"Bangalore", "Asia/Kolkata"}, "bengaluru": {"Bengaluru", "Asia/Kolkata"},
+	"kolkata": {"Kolkata", "Asia/Kolkata"}, "dhaka": {"Dhaka", "Asia/Dhaka"},
+	"kathmandu": {"Kathmandu", "Asia/Kathmandu"}, "bangkok": {"Bangkok", "Asia/Bangkok"},
+	"hanoi": {"Hanoi", "Asia/Ho_Chi_Minh"}, "ho chi minh city": {"Ho Chi Minh City", "Asia/Ho_Chi_Minh"},
+	"jakarta": {"Jakarta", "Asia/Jakarta"}, "singapore": {"Singapore", "Asia/Singapore"},
+	"kuala lumpur": {"Kuala Lumpur", "Asia/Kuala_Lumpur"}, "manila": {"Manila", "Asia/Manila"},
+	"hong kong": {"Hong Kong", "Asia/Hong_Kong"}, "beijing": {"Beijing", "Asia/Shanghai"},
+	"shanghai": {"Shanghai", "Asia/Shanghai"}, "shenzhen": {"Shenzhen", "Asia/Shanghai"},
+	"taipei": {"Taipei", "Asia/Taipei"}, "seoul": {"Seoul", "Asia/Seoul"},
+	"tokyo": {"Tokyo", "Asia/Tokyo"}, "osaka": {"Osaka", "Asia/Tokyo"},
+	"perth": {"Perth", "Australia/Perth"}, "adelaide": {"Adelaide", "Australia/Adelaide"},
+	"brisbane": {"Brisbane", "Australia/Brisbane"}, "sydney": {"Sydney", "Australia/Sydney"},
+	"melbourne": {"Melbourne", "Australia/Melbourne"}, "auckland": {"Auckland", "Pacific/Auckland"},
+	"wellington": {"Wellington", "Pacific/Auckland"},
+}
+
+// zoneRef 是解析后的时区和它的显示名称。
+type zoneRef struct {
+	name string
+	loc  *time.Location
+}
+
+// resolveZone 将 IANA 时区名、时区缩写、UTC 偏移或城市名解析为时区。
+func resolveZone(s string) (zoneRef, bool) {
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		return zoneRef{}, false
+	}
+	lower := strings.ToLower(s)
+
+	if city, ok := cityZones[lower]; ok {
+		if loc, err := time.LoadLocation(city.zone); err == nil {
+			return zoneRef{city.name, loc}, true
+		}
+	}
+	if offset, ok := zoneAbbreviations[strings.ToUpper(s)]; ok {
+		return zoneRef{strings.ToUpper(s), time.FixedZone(strings.ToUpper(s), offset)}, true
+	}
+	if m := utcOffsetRegex.FindStringSubmatch(s); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes, _ := strconv.Atoi(m[3])
+		if hours > 14 || minutes > 59 {
+			return zoneRef{}, false
+		}
+		offset := hours*3600 + minutes*60
+		if m[1] == "-" {
+			offset = -offset
+		}
+		name := "UTC" + time.Unix(0, 0).In(time.FixedZone("", offset)).Format("-07:00")
+		return zoneRef{name, time.FixedZone(name, offset)}, true
+	}
+	// IANA 名称区分大小写，按 "America/New_York" 的形式规范化后再试一次
+	if strings.Contains(s, "/") {
+		for _, name := range []string{s, canonicalZoneName(lower)} {
+			if loc, err := time.LoadLocation(name); err == nil {
+				return zoneRef{name, loc}, true
+			}
+		}
+	}
+	return zoneRef{}, false
+}
+
+// canonicalZoneName 将 "america/new_york" 规范化为 "America/New_York"。
+func canonicalZoneName(s string) string {
+	b := []byte(s)
+	upper := true
+	for i, c := range b {
+		if upper && c >= 'a' && c <= 'z' {
+			b[i] = c - 'a' + 'A'
+		}
+		upper = c == '/' || c == '_' || c == '-'
+	}
+	return string(b)
+}
+
+// zoneConversion 是解析后的时区转换: 在 source 时区的时间 t，转换到各 targets。
+// unknown 是第一个无法解析的目标时区，不为空时转换失败。
+type zoneConversion struct {
+	t       time.Time
+	source  zoneRef
+	targets []zoneRef
+	unknown string
+}
+
+// parseZoneConversion 解析 "<时间> [源时区] in <时区>[, <时区>...]"。
+// 有目标无法解析为时区时，只有明确写出源时区 (e.g. "3pm PST in Atlantis") 才返回转换并记录
+// unknown，否则返回 nil，以免误认 "100 usd in eur" 或 "3pm in nm" 这类查询。
+func parseZoneConversion(input string, local zoneRef, now time.Time) *zoneConversion {
+	m := zoneConversionRegex.FindStringSubmatch(strings.TrimSpace(input))
+	if m == nil {
+		return nil
+	}
+
+	var targets []zoneRef
+	unknown := ""
+	for _, name := range zoneListSeparator.Split(strings.TrimSpace(m[2]), -1) {
+		zone, ok := resolveZone(name)
+		if !ok {
+			if unknown == "" {
+				unknown = name
+			}
+			continue
+		}
+		targets = append(targets, zone)
+	}
+
+	// 源时区写在时间之后，最多三个词 (e.g. "3pm new york")；没有时使用配置的时区
+	words := strings.Fields(m[1])
+	maxZoneWords := len(words)
+	if maxZoneWords > 3 {
+		maxZoneWords = 3
+	}
+	minZoneWords := 0
+	if unknown != "" {
+		minZoneWords = 1
+	}
+	for k := maxZoneWords; k >= minZoneWords; k-- {
+		source := local
+		if k > 0 {
+			zone, ok := resolveZone(strings.Join(words[len(words)-k:], " "))
+			if !ok {
+				continue
+			}
+			source = zone
+		}
+		expr := strings.Join(words[:len(words)-k], " ")
+		if expr == "" {
+			return &zoneConversion{now.In(source.loc), source, targets, unknown}
+		}
+		if t, err := parseDate(expr, now.In(source.loc), nil); err == nil {
+			return &zoneConversion{t, source, targets, unknown}
+		}
+	}
+	return nil
+}
+
+// zoneResults 为每个目标时区生成一行结果，跨日时注明相差的天数。
+func zoneResults(ctx *Context, c *zoneConversion) ([]Result, error) {
+	if c.unknown != "" {
+		return nil, fmt.Errorf("未知的时区: %s", c.unknown)
+	}
+	source := c.t.In(c.source.loc)
+	results := make([]Result, 0, len(c.targets))
+	for _, target := range c.targets {
+		t := c.t.In(target.loc)
+		formatted := t.Format(ctx.Config.DateFormat)
+
+		title := fmt.Sprintf("%s: %s %s", target.name, formatted, t.Format("MST"))
+		if days := calendarDays(source, t); days != 0 {
+			title += fmt.Sprintf(" (%+d 天)", days)
+		}
+		_, srcOffset := source.Zone()
+		_, dstOffset := t.Zone()
+		subtitle := fmt.Sprintf("UTC%s · %s · 复制时间", t.Format("-07:00"), zoneOffsetText(dstOffset-srcOffset, c.source.name))
+
+		results = append(results, Result{
+			Value:    float64(t.Unix()),
+			Title:    title,
+			Subtitle: subtitle,
+			Arg:      formatted,
+			Icon:     "clock.png",
+		})
+	}
+	return results, nil
+}
+
+// zoneOffsetText 描述目标时区与源时区的时差, e.g. "比 PST 快 17 小时", "比 London 慢 5 小时 30 分钟"。
+func zoneOffsetText(seconds int, source string) string {
+	if seconds == 0 {
+		return fmt.Sprintf("与 %s 时间相同", source)
+	}
+	word := "快"
+	if seconds < 0 {
+		word, seconds = "慢", -seconds
+	}
+	text := fmt.Sprintf("比 %s %s %d 小时", source, word, seconds/3600)
+	if minutes := seconds % 3600 / 60; minutes != 0 {
+		text += fmt.Sprintf(" %d 分钟", minutes)
+	}
+	return text
+}
+
+// localZone 返回配置的时区，无法加载时使用 UTC。
+func localZone(ctx *Context) zoneRef {
+	if loc, err := time.LoadLocation(ctx.Config.Timezone); err == nil {
+		return zoneRef{loc.String(), loc}
+	}
+	return zoneRef{"UTC", time.UTC}
+}
+
+// timezoneCalculator 在时区之间转换时间，不需要 "time" 关键字。
+// 它排在单位转换之前，否则 "3pm PST in Tokyo" 中的 "3pm" 会被当作 3 皮米。
+type timezoneCalculator struct{ baseCalculator }
+
+func init() { Register(&timezoneCalculator{}) }
+
+func (*timezoneCalculator) Name() string  { return "timezone" }
+func (*timezoneCalculator) Priority() int { return 12 }
+
+func (*timezoneCalculator) Examples() []string {
+	return []string{"3pm PST in Tokyo", "now in Berlin, NYC"}
+}
+
+// Match 接受源时间可解析、所有目标都是时区的查询。
+func (*timezoneCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	if parseZoneConversion(query, localZone(ctx), time.Now()) == nil {
+		return nil
+	}
+	return &parser.ParsedQuery{Type: timezoneQuery, Input: query}
+}
+
+// Compute 将时间转换到每个目标时区。
+func (*timezoneCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	c := parseZoneConversion(p.Input, localZone(ctx), time.Now())
+	if c == nil {
+		return nil, fmt.Errorf("无法解析时区转换: %s", p.Input)
+	}
+	return zoneResults(ctx, c)
+}
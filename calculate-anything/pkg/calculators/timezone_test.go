@@ -0,0 +1,31 @@
+// calculate-anything/pkg/calculators/timezone_test.go
+package calculators
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTimezone(t *testing.T) {
+	checkQueries(t, []queryTest{
+		// PST 是固定的 UTC-8，下午 3 点在东京已是第二天早上
+		{query: "2024-03-01 3pm PST in Tokyo", calculator: "timezone", title: "Tokyo: 2024-03-02 08:00:00 JST (+1 天)"},
+		{query: "2024-03-01 12:00 in utc+5:30", calculator: "timezone", title: "UTC+05:30: 2024-03-01 16:30:00 UTC+05:30"},
+		{query: "2024-03-01 9:30 utc+5:30 in London", calculator: "timezone", title: "London: 2024-03-01 04:00:00 GMT"},
+		{query: "3pm PST in Atlantis", calculator: "timezone", err: "未知的时区: Atlantis"},
+		{query: "noon PST in Tokyo, Atlantis", calculator: "timezone", err: "未知的时区: Atlantis"},
+	})
+	checkNoMatch(t, &timezoneCalculator{}, "100 usd in eur", "3pm in nm", "now in Atlantis", "10 cst to usd")
+}
+
+func TestTimezoneNow(t *testing.T) {
+	ctx := newTestContext(t)
+	if titles := resultTitles(t, ctx, "3pm PST in Tokyo"); len(titles) != 1 || !strings.HasSuffix(titles[0], " 08:00:00 JST (+1 天)") {
+		t.Errorf("3pm PST in Tokyo: 结果为 %q", titles)
+	}
+
+	titles := resultTitles(t, ctx, "now in Berlin, NYC")
+	if len(titles) != 2 || !strings.HasPrefix(titles[0], "Berlin: ") || !strings.HasPrefix(titles[1], "New York: ") {
+		t.Errorf("now in Berlin, NYC: 结果为 %q", titles)
+	}
+}
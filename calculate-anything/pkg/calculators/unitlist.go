@@ -0,0 +1,167 @@
+// calculate-anything/pkg/calculators/unitlist.go
+package calculators
+
+import (
+	"calculate-anything/pkg/config"
+	"calculate-anything/pkg/parser"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// dataStorageFamily 是数据存储单位在单位列表中的类型名。
+const dataStorageFamily = "data storage"
+
+// defaultUnitPreferences 是只输入源单位时 (e.g. "10 km", "500 GB") 列出的目标单位，
+// 键为 Unit.Type，按常用程度排列。用户可以通过 unit_preferences 配置逐个类型覆盖。
+var defaultUnitPreferences = map[string][]string{
+	"length":              {"m", "km", "cm", "mm", "mi", "yd", "ft", "in", "nmi"},
+	"area":                {"m2", "km2", "cm2", "ha", "acre", "ft2", "mi2"},
+	"volume":              {"L", "mL", "m3", "gal", "qt", "pt", "floz", "ukgal"},
+	"mass":                {"kg", "g", "mg", "t", "lb", "oz", "st"},
+	"time":                {"s", "ms", "min", "h", "day", "week", "month", "year"},
+	"speed":               {"km/h", "m/s", "mph", "kn", "ft/s"},
+	"acceleration":        {"m/s2", "ft/s2"},
+	"force":               {"N", "kN", "lbf", "kgf"},
+	"energy":              {"J", "kJ", "cal", "kcal", "Wh", "kWh", "eV"},
+	"power":               {"W", "kW", "MW", "hp"},
+	"pressure":            {"Pa", "kPa", "MPa", "bar", "mbar", "atm", "psi"},
+	"temperature":         {"°C", "°F", "K"},
+	"rotation":            {"deg", "rad"},
+	"frequency":           {"Hz", "kHz", "MHz", "GHz"},
+	"density":             {"kg/m3", "g/cm3", "g/mL", "lb/ft3"},
+	"fuel economy":        {"L/100km", "mpg", "km/L"},
+	"energy per distance": {"kWh/100km", "Wh/km"},
+	dataStorageFamily:     {"KB", "MB", "GB", "TB", "MiB", "GiB", "B", "bit"},
+}
+
+// unitPreferences 返回某个类型要列出的单位，用户配置优先于默认列表。
+func unitPreferences(cfg *config.AppConfig, family string) []string {
+	if units, ok := cfg.UnitPreferences[family]; ok {
+		return units
+	}
+	return defaultUnitPreferences[family]
+}
+
+// unitFamily 返回源单位所属的类型。源单位本身出现在某个类型的列表中时以列表为准,
+// 因此量纲为面积的 "L/100km" 会归入 "fuel economy"，列出 mpg 而不是平方米。
+func unitFamily(cfg *config.AppConfig, symbol string, unit Unit) string {
+	if containsFold(unitPreferences(cfg, unit.Type), symbol) {
+		return unit.Type
+	}
+	families := make([]string, 0, len(defaultUnitPreferences)+len(cfg.UnitPreferences))
+	for family := range defaultUnitPreferences {
+		families = append(families, family)
+	}
+	for family := range cfg.UnitPreferences {
+		if _, ok := defaultUnitPreferences[family]; !ok {
+			families = append(families, family)
+		}
+	}
+	sort.Strings(families)
+	for _, family := range families {
+		if family != dataStorageFamily && containsFold(unitPreferences(cfg, family), symbol) {
+			return family
+		}
+	}
+	return unit.Type
+}
+
+// matchUnitList 匹配只有数量和源单位的查询 (e.g. "10 km")，accept 判断源单位是否属于调用方。
+func matchUnitList(ctx *Context, query string, accept func(symbol string) bool) *parser.ParsedQuery {
+	p := parser.ParseConversionTargets(query, ctx.Lang, ctx.Format)
+	if p == nil || len(p.ToUnits) > 0 || p.DateText != "" || !accept(p.From) {
+		return nil
+	}
+	p.Action = "all"
+	return p
+}
+
+// unitListResults 列出源数量在同类型常用单位下的数值，跳过源单位本身。
+// 没有为该类型配置列表时，列出 SI 单位和 unitMap 中量纲相同的单位。
+func unitListResults(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	candidates := resolveUnit(p.From)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("未知的单位: %s", p.From)
+	}
+	from := candidates[0]
+	targets := unitPreferences(ctx.Config, unitFamily(ctx.Config, p.From, from))
+	if len(targets) == 0 {
+		targets = sameDimensionSymbols(from.Dim)
+	}
+
+	nf := ctx.Format
+	var results []Result
+	listed := []Unit{from}
+	for _, symbol := range targets {
+		to, ok := compatibleUnit(symbol, from)
+		if !ok || containsUnit(listed, to) {
+			continue
+		}
+		resultValue, err := convertUnits(p.Amount, from, to)
+		if err != nil {
+			continue
+		}
+		listed = append(listed, to)
+		resultString := nf.Plain(resultValue, -1)
+		results = append(results, Result{
+			Value:    resultValue,
+			Unit:     symbol,
+			Title:    fmt.Sprintf("%s %s = %s %s", nf.Format(p.Amount, -1), p.From, nf.Format(resultValue, -1), symbol),
+			Subtitle: fmt.Sprintf("复制 '%s'", resultString),
+			Arg:      resultString,
+		})
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("没有可以列出的 %s 单位", from.Type)
+	}
+	return results, nil
+}
+
+// compatibleUnit 从符号的候选单位中选出可以与 from 互相转换的一个。
+func compatibleUnit(symbol string, from Unit) (Unit, bool) {
+	for _, c := range resolveUnit(symbol) {
+		if c.Dim == from.Dim || c.Dim == from.Dim.Inverse() {
+			return c, true
+		}
+	}
+	return Unit{}, false
+}
+
+// containsUnit 报告列表中是否已有换算规则相同的单位, e.g. "l" 与 "L"。
+func containsUnit(units []Unit, u Unit) bool {
+	for _, c := range units {
+		if c.Dim == u.Dim && c.ToSI == u.ToSI && c.Offset == u.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+// sameDimensionSymbols 返回量纲对应的 SI 表示，以及 unitMap 中量纲相同的单位，按大小排列。
+func sameDimensionSymbols(d Dimension) []string {
+	var symbols []string
+	for symbol, u := range unitMap {
+		if u.Dim == d {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := unitMap[symbols[i]], unitMap[symbols[j]]
+		if a.ToSI != b.ToSI {
+			return a.ToSI < b.ToSI
+		}
+		return symbols[i] < symbols[j]
+	})
+	return append([]string{d.String()}, symbols...)
+}
+
+// containsFold 报告列表中是否有与 s 相同的字符串 (不区分大小写)。
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,179 +2,470 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
+	"calculate-anything/pkg/format"
 	"calculate-anything/pkg/parser"
 	"fmt"
-	"strconv"
+	"math"
 	"strings"
-
-	"github.com/deanishe/awgo"
 )
 
 // Unit 定义了一个物理单位及其换算到国际标准单位（SI）的规则。
+// 单位用 SI 基本量纲向量表示，因此任意单位的乘积与商 (e.g. "kWh/100km") 都能正确换算。
 type Unit struct {
-	Name   string
-	Type   string                // 单位类型, e.g., "length", "mass"
-	ToSI   float64               // 乘以该因子可转换为 SI 单位
-	FromSI func(float64) float64 // 从 SI 单位转换回来的函数（主要用于温度这类非线性转换）
+	Name       string
+	Type       string    // 单位类型，由量纲推导, e.g., "length", "speed"
+	Dim        Dimension // SI 基本量纲向量
+	ToSI       float64   // 乘以该因子可转换为 SI 单位
+	Offset     float64   // 仿射偏移（仅用于温度）: SI = value*ToSI + Offset
+	Prefixable bool      // 是否允许加 SI 词头 (k, M, μ, n…)
 }
 
-// unitMap 包含了所有支持的物理单位，数据来源于 README.md 文件。
+// unitMap 包含了所有基础单位，键区分大小写。带词头的单位 (km, mg, kWh, MPa…) 和
+// 复合单位 (km/h, g/cm3, N·m…) 不再逐一列出，而是由 resolveUnit 通过单位代数推导。
 var unitMap = map[string]Unit{
 	// --- 长度 (SI: 米 'm') ---
-	"m":   {Name: "Meter", Type: "length", ToSI: 1.0},
-	"km":  {Name: "Kilometer", Type: "length", ToSI: 1000.0},
-	"dm":  {Name: "Decimeter", Type: "length", ToSI: 0.1},
-	"cm":  {Name: "Centimeter", Type: "length", ToSI: 0.01},
-	"mm":  {Name: "Milimeter", Type: "length", ToSI: 0.001},
-	"μm":  {Name: "Micrometer", Type: "length", ToSI: 1e-6},
-	"nm":  {Name: "Nanometer", Type: "length", ToSI: 1e-9},
-	"pm":  {Name: "Picometer", Type: "length", ToSI: 1e-12},
-	"in":  {Name: "Inch", Type: "length", ToSI: 0.0254},
-	"ft":  {Name: "Foot", Type: "length", ToSI: 0.3048},
-	"yd":  {Name: "Yard", Type: "length", ToSI: 0.9144},
-	"mi":  {Name: "Mile", Type: "length", ToSI: 1609.34},
-	"nmi": {Name: "Nautical Mile", Type: "length", ToSI: 1852.0},
-	"h":   {Name: "Hand", Type: "length", ToSI: 0.1016},
-	"ly":  {Name: "Lightyear", Type: "length", ToSI: 9.461e+15},
-	"au":  {Name: "Astronomical Unit", Type: "length", ToSI: 1.496e+11},
-	"pc":  {Name: "Parsec", Type: "length", ToSI: 3.086e+16},
+	"m":    {Name: "Meter", Dim: dimL, ToSI: 1.0, Prefixable: true},
+	"in":   {Name: "Inch", Dim: dimL, ToSI: 0.0254},
+	"ft":   {Name: "Foot", Dim: dimL, ToSI: 0.3048},
+	"yd":   {Name: "Yard", Dim: dimL, ToSI: 0.9144},
+	"mi":   {Name: "Mile", Dim: dimL, ToSI: 1609.344},
+	"nmi":  {Name: "Nautical Mile", Dim: dimL, ToSI: 1852.0},
+	"hand": {Name: "Hand", Dim: dimL, ToSI: 0.1016},
+	"ly":   {Name: "Lightyear", Dim: dimL, ToSI: 9.4607e+15},
+	"au":   {Name: "Astronomical Unit", Dim: dimL, ToSI: 1.495978707e+11},
+	"pc":   {Name: "Parsec", Dim: dimL, ToSI: 3.0857e+16, Prefixable: true},
 
 	// --- 面积 (SI: 平方米 'm2') ---
-	"m2":  {Name: "Square Meter", Type: "area", ToSI: 1.0},
-	"km2": {Name: "Square Kilometer", Type: "area", ToSI: 1e6},
-	"cm2": {Name: "Square Centimeter", Type: "area", ToSI: 1e-4},
-	"mm2": {Name: "Square Milimeter", Type: "area", ToSI: 1e-6},
-	"ft2": {Name: "Square Foot", Type: "area", ToSI: 0.092903},
-	"mi2": {Name: "Square Mile", Type: "area", ToSI: 2.59e+6},
-	"ha":  {Name: "Hectare", Type: "area", ToSI: 10000},
+	"ha":   {Name: "Hectare", Dim: dimArea, ToSI: 10000},
+	"acre": {Name: "Acre", Dim: dimArea, ToSI: 4046.8564224},
 
 	// --- 体积 (SI: 立方米 'm3') ---
-	"l":     {Name: "Litre", Type: "volume", ToSI: 0.001},
-	"ml":    {Name: "Mililitre", Type: "volume", ToSI: 1e-6},
-	"m3":    {Name: "Cubic Meter", Type: "volume", ToSI: 1.0},
-	"kl":    {Name: "Kilolitre", Type: "volume", ToSI: 1.0},
-	"hl":    {Name: "Hectolitre", Type: "volume", ToSI: 0.1},
-	"qt":    {Name: "Quart", Type: "volume", ToSI: 0.000946353},
-	"pt":    {Name: "Pint (US)", Type: "volume", ToSI: 0.000473176},
-	"ukpt":  {Name: "Pint (UK)", Type: "volume", ToSI: 0.000568261},
-	"gal":   {Name: "Gallon (US)", Type: "volume", ToSI: 0.00378541},
-	"ukgal": {Name: "Gallon (UK)", Type: "volume", ToSI: 0.00454609},
-	"floz":  {Name: "Fluid ounce", Type: "volume", ToSI: 2.95735e-5},
+	"l":     {Name: "Litre", Dim: dimVolume, ToSI: 0.001, Prefixable: true},
+	"L":     {Name: "Litre", Dim: dimVolume, ToSI: 0.001, Prefixable: true},
+	"qt":    {Name: "Quart", Dim: dimVolume, ToSI: 0.000946353},
+	"pt":    {Name: "Pint (US)", Dim: dimVolume, ToSI: 0.000473176},
+	"ukpt":  {Name: "Pint (UK)", Dim: dimVolume, ToSI: 0.000568261},
+	"gal":   {Name: "Gallon (US)", Dim: dimVolume, ToSI: 0.003785411784},
+	"ukgal": {Name: "Gallon (UK)", Dim: dimVolume, ToSI: 0.00454609},
+	"floz":  {Name: "Fluid ounce", Dim: dimVolume, ToSI: 2.95735e-5},
 
 	// --- 重量 (SI: 千克 'kg') ---
-	"kg":  {Name: "Kilogram", Type: "mass", ToSI: 1.0},
-	"g":   {Name: "Gram", Type: "mass", ToSI: 0.001},
-	"mg":  {Name: "Miligram", Type: "mass", ToSI: 1e-6},
-	"n":   {Name: "Newton", Type: "mass", ToSI: 0.10197}, // 在地球重力下
-	"st":  {Name: "Stone", Type: "mass", ToSI: 6.35029},
-	"lb":  {Name: "Pound", Type: "mass", ToSI: 0.453592},
-	"oz":  {Name: "Ounce", Type: "mass", ToSI: 0.0283495},
-	"t":   {Name: "Metric Tonne", Type: "mass", ToSI: 1000.0},
-	"ukt": {Name: "UK Long Ton", Type: "mass", ToSI: 1016.05},
-	"ust": {Name: "US Short Ton", Type: "mass", ToSI: 907.185},
-
-	// --- 速度 (SI: 米每秒 'mps') ---
-	"mps": {Name: "Meters Per Second", Type: "speed", ToSI: 1.0},
-	"kph": {Name: "Kilometers Per Hour", Type: "speed", ToSI: 1.0 / 3.6},
-	"mph": {Name: "Miles Per Hour", Type: "speed", ToSI: 0.44704},
-	"fps": {Name: "Feet Per Second", Type: "speed", ToSI: 0.3048},
+	"g":   {Name: "Gram", Dim: dimM, ToSI: 0.001, Prefixable: true},
+	"t":   {Name: "Metric Tonne", Dim: dimM, ToSI: 1000.0},
+	"st":  {Name: "Stone", Dim: dimM, ToSI: 6.35029},
+	"lb":  {Name: "Pound", Dim: dimM, ToSI: 0.45359237},
+	"oz":  {Name: "Ounce", Dim: dimM, ToSI: 0.028349523125},
+	"ukt": {Name: "UK Long Ton", Dim: dimM, ToSI: 1016.05},
+	"ust": {Name: "US Short Ton", Dim: dimM, ToSI: 907.185},
+
+	// --- 速度 (SI: 米每秒 'm/s') ---
+	"kn": {Name: "Knot", Dim: dimSpeed, ToSI: 1852.0 / 3600},
 
 	// --- 旋转 (SI: 弧度 'rad') ---
-	"deg": {Name: "Degrees", Type: "rotation", ToSI: 0.0174533},
-	"rad": {Name: "Radian", Type: "rotation", ToSI: 1.0},
-
-	// --- 温度 (SI: 开尔文 'k') ---
-	"k": {Name: "Kelvin", Type: "temperature", FromSI: func(k float64) float64 { return k }},
-	"c": {Name: "Centigrade", Type: "temperature", FromSI: func(k float64) float64 { return k - 273.15 }},
-	"f": {Name: "Fahrenheit", Type: "temperature", FromSI: func(k float64) float64 { return (k-273.15)*9/5 + 32 }},
-
-	// --- 压力 (SI: 帕斯卡 'pa') ---
-	"pa":   {Name: "Pascal", Type: "pressure", ToSI: 1.0},
-	"kpa":  {Name: "Kilopascal", Type: "pressure", ToSI: 1000.0},
-	"mpa":  {Name: "Megapascal", Type: "pressure", ToSI: 1e6},
-	"bar":  {Name: "Bar", Type: "pressure", ToSI: 100000.0},
-	"mbar": {Name: "Milibar", Type: "pressure", ToSI: 100.0},
-	"psi":  {Name: "Pound-force Per Square Inch", Type: "pressure", ToSI: 6894.76},
+	"rad": {Name: "Radian", Dim: dimRad, ToSI: 1.0, Prefixable: true},
+	"deg": {Name: "Degrees", Dim: dimRad, ToSI: math.Pi / 180},
+
+	// --- 温度 (SI: 开尔文 'K') ---
+	"K":  {Name: "Kelvin", Dim: dimTheta, ToSI: 1.0, Prefixable: true},
+	"°C": {Name: "Centigrade", Dim: dimTheta, ToSI: 1.0, Offset: 273.15},
+	"°F": {Name: "Fahrenheit", Dim: dimTheta, ToSI: 5.0 / 9.0, Offset: 273.15 - 32*5.0/9.0},
+
+	// --- 压力 (SI: 帕斯卡 'Pa') ---
+	"Pa":  {Name: "Pascal", Dim: dimPressure, ToSI: 1.0, Prefixable: true},
+	"bar": {Name: "Bar", Dim: dimPressure, ToSI: 100000.0, Prefixable: true},
+	"atm": {Name: "Atmosphere", Dim: dimPressure, ToSI: 101325.0},
+	"psi": {Name: "Pound-force Per Square Inch", Dim: dimPressure, ToSI: 6894.76},
 
 	// --- 时间 (SI: 秒 's') ---
-	"s":    {Name: "Second", Type: "time", ToSI: 1.0},
-	"year": {Name: "Year", Type: "time", ToSI: 3.154e+7},
-	"month":{Name: "Month", Type: "time", ToSI: 2.628e+6},
-	"week": {Name: "Week", Type: "time", ToSI: 604800.0},
-	"day":  {Name: "Day", Type: "time", ToSI: 86400.0},
-	"hr":   {Name: "Hour", Type: "time", ToSI: 3600.0},
-	"min":  {Name: "Minute", Type: "time", ToSI: 60.0},
-	"ms":   {Name: "Milisecond", Type: "time", ToSI: 0.001},
-	"μs":   {Name: "Microsecond", Type: "time", ToSI: 1e-6},
-	"ns":   {Name: "Nanosecond", Type: "time", ToSI: 1e-9},
-
-	// --- 能量/功率 (SI: 焦耳 'j') ---
-	"j":    {Name: "Joule", Type: "energy", ToSI: 1.0},
-	"kj":   {Name: "Kilojoule", Type: "energy", ToSI: 1000.0},
-	"mj":   {Name: "Megajoule", Type: "energy", ToSI: 1e6},
-	"cal":  {Name: "Calorie", Type: "energy", ToSI: 4.184},
-	// "nm" 与长度单位冲突，需要在解析器层面做更智能的区分，此处暂时注释
-	// "nm":   {Name: "Newton Meter", Type: "energy", ToSI: 1.0},
-	"ftlb": {Name: "Foot Pound", Type: "energy", ToSI: 1.35582},
-	"whr":  {Name: "Watt Hour", Type: "energy", ToSI: 3600.0},
-	"kwhr": {Name: "Kilowatt Hour", Type: "energy", ToSI: 3.6e+6},
-	"mwhr": {Name: "Megawatt Hour", Type: "energy", ToSI: 3.6e+9},
-	"mev":  {Name: "Mega Electron Volt", Type: "energy", ToSI: 1.6022e-13},
-}
-
-// HandleUnits 处理物理单位的转换。
-func HandleUnits(wf *aw.Workflow, p *parser.ParsedQuery) {
-	// 将单位符号转为小写以匹配 unitMap
-	fromUnit, okFrom := unitMap[strings.ToLower(p.From)]
-	toUnit, okTo := unitMap[strings.ToLower(p.To)]
-
-	if !okFrom {
-		alfred.ShowError(wf, fmt.Errorf("未知的源单位: %s", p.From))
-		return
-	}
-	if !okTo {
-		alfred.ShowError(wf, fmt.Errorf("未知的目标单位: %s", p.To))
-		return
-	}
-
-	// 确保两个单位属于同一类型（例如，不能将长度转换为质量）
-	if fromUnit.Type != toUnit.Type {
-		alfred.ShowError(wf, fmt.Errorf("无法在不同类型单位间转换: %s -> %s", fromUnit.Type, toUnit.Type))
-		return
-	}
-
-	var resultValue float64
-	// 温度是特例，它的转换不是简单的乘法，需要特殊处理
-	if fromUnit.Type == "temperature" {
-		var valueInKelvin float64
-		// 步骤 1: 将输入温度统一转换为开尔文（Kelvin）
-		switch fromUnit.Name {
-		case "Kelvin":
-			valueInKelvin = p.Amount
-		case "Centigrade":
-			valueInKelvin = p.Amount + 273.15
-		case "Fahrenheit":
-			valueInKelvin = (p.Amount-32)*5/9 + 273.15
+	"s":     {Name: "Second", Dim: dimT, ToSI: 1.0, Prefixable: true},
+	"min":   {Name: "Minute", Dim: dimT, ToSI: 60.0},
+	"h":     {Name: "Hour", Dim: dimT, ToSI: 3600.0},
+	"day":   {Name: "Day", Dim: dimT, ToSI: 86400.0},
+	"week":  {Name: "Week", Dim: dimT, ToSI: 604800.0},
+	"month": {Name: "Month", Dim: dimT, ToSI: 2.628e+6},
+	"year":  {Name: "Year", Dim: dimT, ToSI: 3.154e+7},
+
+	// --- 频率 (SI: 赫兹 'Hz') ---
+	"Hz": {Name: "Hertz", Dim: dimFrequency, ToSI: 1.0, Prefixable: true},
+
+	// --- 力 (SI: 牛顿 'N') ---
+	"N":   {Name: "Newton", Dim: dimForce, ToSI: 1.0, Prefixable: true},
+	"lbf": {Name: "Pound-force", Dim: dimForce, ToSI: 4.4482216152605},
+	"kgf": {Name: "Kilogram-force", Dim: dimForce, ToSI: 9.80665},
+
+	// --- 能量 (SI: 焦耳 'J') ---
+	"J":   {Name: "Joule", Dim: dimEnergy, ToSI: 1.0, Prefixable: true},
+	"cal": {Name: "Calorie", Dim: dimEnergy, ToSI: 4.184, Prefixable: true},
+	"Wh":  {Name: "Watt Hour", Dim: dimEnergy, ToSI: 3600.0, Prefixable: true},
+	"eV":  {Name: "Electron Volt", Dim: dimEnergy, ToSI: 1.602176634e-19, Prefixable: true},
+
+	// --- 功率 (SI: 瓦特 'W') ---
+	"W":  {Name: "Watt", Dim: dimPower, ToSI: 1.0, Prefixable: true},
+	"hp": {Name: "Horsepower", Dim: dimPower, ToSI: 745.699872},
+}
+
+// unitAliases 将小写的别名映射到单位表达式。一个别名可以对应多个含义，
+// 由 matchUnits 根据量纲选择，例如 "nm" 既是纳米也是牛·米，"h" 既是小时也是手。
+// 全小写输入时 "m" 开头的兆（mega）单位也需要在这里显式列出，否则会被当作毫（milli）；
+// 输入中写出了大小写时按原样解析，不使用这些别名。
+var unitAliases = map[string][]string{
+	"nm":    {"N·m"},
+	"h":     {"hand"},
+	"hr":    {"h"},
+	"hour":  {"h"},
+	"sec":   {"s"},
+	"d":     {"day"},
+	"wk":    {"week"},
+	"yr":    {"year"},
+	"c":     {"°C"},
+	"°c":    {"°C"},
+	"f":     {"°F"},
+	"°f":    {"°F"},
+	"k":     {"K"},
+	"mps":   {"m/s"},
+	"kph":   {"km/h"},
+	"kmh":   {"km/h"},
+	"mph":   {"mi/h"},
+	"fps":   {"ft/s"},
+	"knot":  {"kn"},
+	"mpg":   {"mi/gal"},
+	"ftlb":  {"ft·lbf"},
+	"whr":   {"Wh"},
+	"kwhr":  {"kWh"},
+	"mwhr":  {"MWh"},
+	"mwh":   {"MWh"},
+	"mj":    {"MJ"},
+	"mpa":   {"MPa"},
+	"mev":   {"MeV"},
+	"mw":    {"MW"},
+	"mhz":   {"MHz"},
+	"ac":    {"acre"},
+	"tonne": {"t"},
+}
+
+// unitMapLower 是 unitMap 的小写索引，用于大小写不敏感的回退查找。
+var unitMapLower = map[string]Unit{}
+
+func init() {
+	// 根据量纲推导每个单位的类型，并建立小写索引
+	for symbol, unit := range unitMap {
+		unit.Type = dimensionType(unit.Dim)
+		unitMap[symbol] = unit
+		if _, exists := unitMapLower[strings.ToLower(symbol)]; !exists {
+			unitMapLower[strings.ToLower(symbol)] = unit
+		}
+	}
+}
+
+// IsPhysicalUnitPair 检查两个符号是否是可以互相转换的物理单位。
+func IsPhysicalUnitPair(from, to string) bool {
+	_, _, err := matchUnits(from, to)
+	return err == nil
+}
+
+// compoundChains 定义了复合格式化时使用的单位链，按从大到小排列。
+// 例如长度 1.6 m 在 {"ft", "in"} 链下格式化为 "5 ft 3 in"。
+var compoundChains = [][]string{
+	{"ft", "in"},
+	{"m", "cm"},
+	{"lb", "oz"},
+	{"kg", "g"},
+	{"day", "h", "min", "s"},
+}
+
+// unitsCalculator 处理物理单位的转换。
+type unitsCalculator struct{ baseCalculator }
+
+func init() { Register(&unitsCalculator{}) }
+
+func (*unitsCalculator) Name() string  { return "units" }
+func (*unitsCalculator) Priority() int { return 20 }
+
+func (*unitsCalculator) Examples() []string {
+	return []string{"10km in mi", "100 km/h to m/s", "5ft 3in to cm", "8 L/100km to mpg", "10 km"}
+}
+
+// Match 接受复合数量查询、量纲兼容的物理单位转换，以及只有源单位的查询 (列出常用单位)。
+// 前两者优先于货币判断，避免 "mph"、"m/s" 这类三个字符的单位被当作货币代码。
+func (*unitsCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	p := parser.ParseConversion(query, ctx.Lang, ctx.Format)
+	if p == nil {
+		return matchUnitList(ctx, query, func(symbol string) bool {
+			// "5 pm" 是时刻而不是 5 皮米
+			if strings.EqualFold(symbol, "am") || strings.EqualFold(symbol, "pm") {
+				return false
+			}
+			return len(resolveUnit(symbol)) > 0
+		})
+	}
+	if len(p.Quantities) > 0 || len(p.ToUnits) > 1 {
+		// "100 usd to eur gbp" 也会被解析为复合目标，只接受源是物理单位的查询
+		if len(resolveUnit(p.From)) > 0 {
+			return p
+		}
+		return nil
+	}
+	if IsPhysicalUnitPair(p.From, p.To) || isUnitQuery(ctx, p.From, p.To) {
+		return p
+	}
+	return nil
+}
+
+// isUnitQuery 报告无法直接转换的两个符号是否仍是单位转换查询: 至少一侧是物理单位，
+// 且两侧都不是货币、加密货币、数据存储单位或停用词, e.g. "1 km to kg"、"5 kgg to lb"。
+// 这类查询由 Compute 报告类型不同或未知的单位，拼写错误再由 Dispatch 纠正。
+func isUnitQuery(ctx *Context, from, to string) bool {
+	if len(resolveUnit(from)) == 0 && len(resolveUnit(to)) == 0 {
+		return false
+	}
+	for _, symbol := range []string{from, to} {
+		if IsCurrency(symbol) || IsCrypto(ctx, symbol) || IsDataStorageUnit(symbol) {
+			return false
 		}
-		// 步骤 2: 从开尔文转换为目标温度单位
-		resultValue = toUnit.FromSI(valueInKelvin)
-	} else {
-		// 对于其他所有线性单位，使用标准转换流程: Amount -> SI -> Target
-		valueInSI := p.Amount * fromUnit.ToSI
-		resultValue = valueInSI / toUnit.ToSI
+		// 还没有输入目标单位, e.g. "10 km to"
+		if ctx.Lang != nil && containsString(ctx.Lang.StopWords, strings.ToLower(symbol)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compute 执行物理单位的转换。
+func (*unitsCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	// 没有目标单位 ("10 km") 时列出同类型的常用单位
+	if p.Action == "all" {
+		return unitListResults(ctx, p)
+	}
+
+	// 复合数量 ("5ft 3in to cm") 或复合目标 ("1.6 m to ft in") 交给专门的处理函数
+	if len(p.Quantities) > 0 || len(p.ToUnits) > 1 {
+		return computeCompoundUnits(p, ctx.Format)
 	}
 
-	resultString := strconv.FormatFloat(resultValue, 'f', -1, 64)
+	// 按量纲在候选单位中选出兼容的一对，同时解决 "nm"、"h" 这类符号歧义
+	fromUnit, toUnit, err := matchUnits(p.From, p.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// 转换流程: Amount -> SI -> Target（温度为仿射转换，L/100km <-> mpg 为倒数转换）
+	resultValue, err := convertUnits(p.Amount, fromUnit, toUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	resultString := ctx.Format.Plain(resultValue, -1)
 
-	title := fmt.Sprintf("%g %s = %s %s", p.Amount, p.From, resultString, p.To)
+	title := fmt.Sprintf("%s %s = %s %s", ctx.Format.Format(p.Amount, -1), p.From, ctx.Format.Format(resultValue, -1), p.To)
 	subtitle := fmt.Sprintf("复制 '%s'", resultString)
 
-	alfred.AddToWorkflow(wf, []alfred.Result{
+	results := []Result{
 		{
+			Value:    resultValue,
+			Unit:     p.To,
 			Title:    title,
 			Subtitle: subtitle,
 			Arg:      resultString,
 		},
-	})
+	}
+
+	// 如果目标单位属于某个复合单位链，额外给出复合格式的结果, e.g. "1.6 m = 5 ft 3 in"
+	if fromUnit.Dim == toUnit.Dim && fromUnit.Offset == 0 {
+		if chain := findCompoundChain(toUnit); chain != nil {
+			if compound, ok := formatCompound(p.Amount*fromUnit.ToSI, chain, ctx.Format); ok {
+				results = append(results, compoundResult(fmt.Sprintf("%s %s", ctx.Format.Format(p.Amount, -1), p.From), compound))
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// computeCompoundUnits 处理同一类型的复合或求和数量，例如 "5ft 3in to cm"、"2h 30min + 45min"。
+// 所有数量先按 unitMap 的 SI 因子求和，再转换为目标单位。
+func computeCompoundUnits(p *parser.ParsedQuery, nf *format.Formatter) ([]Result, error) {
+	quantities := p.Quantities
+	if len(quantities) == 0 {
+		quantities = []parser.Quantity{{Amount: p.Amount, Unit: p.From}}
+	}
+	targets := p.ToUnits
+	if len(targets) == 0 && p.To != "" {
+		targets = []string{p.To}
+	}
+
+	// 找出所有数量和目标单位共同的量纲，从而解决 "2h 30min" 中 "h" 的歧义
+	symbols := append(quantitySymbols(quantities), targets...)
+	candidates := make([][]Unit, len(symbols))
+	for i, symbol := range symbols {
+		candidates[i] = resolveUnit(symbol)
+		if len(candidates[i]) == 0 {
+			return nil, fmt.Errorf("未知的单位: %s", symbol)
+		}
+	}
+	units, ok := pickCommonDimension(candidates)
+	if !ok {
+		return nil, fmt.Errorf("无法在不同类型单位间计算: %s", strings.Join(symbols, ", "))
+	}
+	if units[0].Offset != 0 || units[0].Dim == dimTheta {
+		return nil, fmt.Errorf("温度不支持复合计算")
+	}
+
+	// 步骤 1: 将所有数量换算为 SI 单位并求和，同时记录最大的输入单位作为默认目标
+	var totalSI float64
+	largest := 0
+	for i, q := range quantities {
+		totalSI += q.Amount * units[i].ToSI
+		if units[i].ToSI > units[largest].ToSI {
+			largest = i
+		}
+	}
+	targetUnits := units[len(quantities):]
+	if len(targets) == 0 {
+		targets = []string{quantities[largest].Unit}
+		targetUnits = []Unit{units[largest]}
+	}
+
+	input := formatQuantities(quantities, nf)
+	var results []Result
+
+	// 单一目标: 给出总量在该单位下的数值
+	if len(targets) == 1 {
+		resultValue := totalSI / targetUnits[0].ToSI
+		resultString := nf.Plain(resultValue, -1)
+		results = append(results, Result{
+			Value:    resultValue,
+			Unit:     targets[0],
+			Title:    fmt.Sprintf("%s = %s %s", input, nf.Format(resultValue, -1), targets[0]),
+			Subtitle: fmt.Sprintf("复制 '%s'", resultString),
+			Arg:      resultString,
+		})
+	}
+
+	// 复合格式: 显式给出多个目标单位时使用它们，否则使用包含目标单位的单位链
+	chain := targets
+	if len(chain) == 1 {
+		chain = findCompoundChain(targetUnits[0])
+	}
+	if chain != nil {
+		if compound, ok := formatCompound(totalSI, chain, nf); ok || len(targets) > 1 {
+			results = append(results, compoundResult(input, compound))
+		}
+	}
+
+	return results, nil
+}
+
+// compoundResult 生成复合格式结果对应的 Alfred 结果项。
+func compoundResult(input, compound string) Result {
+	return Result{
+		Title:    fmt.Sprintf("%s = %s", input, compound),
+		Subtitle: fmt.Sprintf("复制 '%s'", compound),
+		Arg:      compound,
+	}
+}
+
+// pickCommonDimension 为每个符号从候选单位中选择一个，使所有单位的量纲相同。
+// 以第一个符号的候选顺序为优先级。
+func pickCommonDimension(candidates [][]Unit) ([]Unit, bool) {
+	for _, first := range candidates[0] {
+		picked := []Unit{first}
+		for _, cands := range candidates[1:] {
+			for _, c := range cands {
+				if c.Dim == first.Dim {
+					picked = append(picked, c)
+					break
+				}
+			}
+		}
+		if len(picked) == len(candidates) {
+			return picked, true
+		}
+	}
+	return nil, false
+}
+
+// findCompoundChain 返回包含指定单位的复合单位链，没有则返回 nil。
+func findCompoundChain(unit Unit) []string {
+	for _, chain := range compoundChains {
+		for _, symbol := range chain {
+			for _, c := range resolveUnit(symbol) {
+				if c.Dim == unit.Dim && c.ToSI == unit.ToSI && c.Offset == unit.Offset {
+					return chain
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// formatCompound 将 SI 数值按单位链拆分，例如 1.6 m -> "5 ft 3 in"。
+// 最小单位四舍五入为整数（不足 1 时保留小数）；结果只有一个分量时 ok 为 false。
+func formatCompound(valueSI float64, chain []string, nf *format.Formatter) (string, bool) {
+	candidates := make([][]Unit, len(chain))
+	for i, symbol := range chain {
+		candidates[i] = resolveUnit(symbol)
+		if len(candidates[i]) == 0 {
+			return "", false
+		}
+	}
+	units, ok := pickCommonDimension(candidates)
+	if !ok {
+		return "", false
+	}
+
+	sign := ""
+	if valueSI < 0 {
+		sign = "-"
+		valueSI = -valueSI
+	}
+
+	smallest := units[len(units)-1].ToSI
+	remaining := math.Round(valueSI / smallest)
+	if remaining == 0 {
+		remaining = valueSI / smallest
+	}
+
+	var parts []string
+	for i, unit := range units {
+		ratio := math.Round(unit.ToSI/smallest*1e6) / 1e6
+		count := remaining
+		if i < len(units)-1 {
+			count = math.Floor(remaining/ratio + 1e-9)
+		}
+		remaining -= count * ratio
+		if count != 0 {
+			parts = append(parts, fmt.Sprintf("%s %s", nf.Format(count, -1), chain[i]))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("0 %s", chain[len(chain)-1]), false
+	}
+	return sign + strings.Join(parts, " "), len(parts) > 1
+}
+
+// formatQuantities 将复合数量格式化为可读文本, e.g. "2 h + 30 min - 15 min"。
+func formatQuantities(quantities []parser.Quantity, nf *format.Formatter) string {
+	var b strings.Builder
+	for i, q := range quantities {
+		amount := q.Amount
+		if i > 0 {
+			if amount < 0 {
+				b.WriteString(" - ")
+				amount = -amount
+			} else {
+				b.WriteString(" + ")
+			}
+		}
+		b.WriteString(fmt.Sprintf("%s %s", nf.Format(amount, -1), q.Unit))
+	}
+	return b.String()
+}
+
+// quantitySymbols 返回所有数量的单位符号。
+func quantitySymbols(quantities []parser.Quantity) []string {
+	symbols := make([]string, len(quantities))
+	for i, q := range quantities {
+		symbols[i] = q.Unit
+	}
+	return symbols
 }
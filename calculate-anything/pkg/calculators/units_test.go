@@ -0,0 +1,83 @@
+// calculate-anything/pkg/calculators/units_test.go
+package calculators
+
+import "testing"
+
+func TestUnitConversions(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "10km in mi", calculator: "units", title: "10 km = 6.21371192237334 mi"},
+		{query: "100 km/h to m/s", calculator: "units", title: "100 km/h = 27.7777777777778 m/s"},
+		{query: "8 L/100km to mpg", calculator: "units", title: "8 L/100km = 29.4018229166667 mpg"},
+		{query: "3 kWh to MJ", calculator: "units", title: "3 kWh = 10.8 MJ"},
+		{query: "10 Meters to Feet", calculator: "units", title: "10 m = 32.8083989501312 ft"},
+		{query: "5ft 3in to cm", calculator: "units", title: "5 ft + 3 in = 160.02 cm"},
+		// 单位符号区分大小写：m 是毫，M 是兆
+		{query: "1 mW to W", calculator: "units", title: "1 mW = 0.001 W"},
+		{query: "1 mPa to Pa", calculator: "units", title: "1 mPa = 0.001 Pa"},
+		{query: "1 mHz to Hz", calculator: "units", title: "1 mHz = 0.001 Hz"},
+		{query: "1 Mm to m", calculator: "units", title: "1 Mm = 1,000,000 m"},
+		{query: "1 MHZ to kHz", calculator: "units", title: "1 MHZ = 1,000 kHz"},
+		{query: "10 KM to MI", calculator: "units", title: "10 KM = 6.21371192237334 MI"},
+		// 温度的仿射转换没有浮点误差
+		{query: "0 C to F", calculator: "units", title: "0 C = 32 F"},
+		{query: "10 C to F", calculator: "units", title: "10 C = 50 F"},
+		{query: "98.6 F to C", calculator: "units", title: "98.6 F = 37 C"},
+		// 微符号 µ (U+00B5) 与希腊字母 μ 等价
+		{query: "1 µm to nm", calculator: "units", title: "1 μm = 1,000 nm"},
+		{query: "1 μm to nm", calculator: "units", title: "1 μm = 1,000 nm"},
+		// "in" 作为单位时是英寸
+		{query: "5 in to cm", calculator: "units", title: "5 in = 12.7 cm"},
+		{query: "5 cm to in", calculator: "units", title: "5 cm = 1.96850393700787 in"},
+		{query: "12 in", calculator: "units", title: "12 in = 0.3048 m"},
+		// 无效的数字不能当作 0
+		{query: "1.000.000 km to m", calculator: ""},
+		// 类型不同或未知的单位报告原来的错误
+		{query: "1 km to kg", calculator: "units", err: "无法在不同类型单位间转换"},
+		{query: "5 kg to xyzzy", calculator: "units", err: "未知的目标单位"},
+		// 还没有输入目标单位
+		{query: "10 km to", calculator: ""},
+	})
+}
+
+func TestUnitList(t *testing.T) {
+	ctx := newTestContext(t)
+	titles := resultTitles(t, ctx, "10 km")
+	want := []string{"10 km = 10,000 m", "10 km = 1,000,000 cm", "10 km = 10,000,000 mm"}
+	for i, w := range want {
+		if i >= len(titles) || titles[i] != w {
+			t.Fatalf("10 km: 结果为 %q, 期望以 %q 开头", titles, want)
+		}
+	}
+	for _, title := range titles {
+		if title == "10 km = 10 km" {
+			t.Errorf("10 km: 不应列出源单位本身")
+		}
+	}
+}
+
+func TestResolveUnit(t *testing.T) {
+	tests := []struct {
+		symbol string
+		name   string
+		toSI   float64
+	}{
+		{"km", "kMeter", 1000},
+		{"mW", "mWatt", 1e-3},
+		{"MW", "MWatt", 1e6},
+		{"mw", "MWatt", 1e6}, // 全小写时使用别名表
+		{"Mm", "MMeter", 1e6},
+		{"MM", "MMeter", 1e6},
+		{"µs", "µSecond", 1e-6},
+	}
+	for _, tt := range tests {
+		units := resolveUnit(tt.symbol)
+		if len(units) == 0 {
+			t.Errorf("resolveUnit(%q) 没有结果", tt.symbol)
+			continue
+		}
+		if u := units[0]; u.Name != tt.name || u.ToSI != tt.toSI {
+			t.Errorf("resolveUnit(%q) = %s (%g), 期望 %s (%g)", tt.symbol, u.Name, u.ToSI, tt.name, tt.toSI)
+		}
+	}
+	checkNoMatch(t, &unitsCalculator{}, "5 pm", "hello", "100 usd to eur")
+}
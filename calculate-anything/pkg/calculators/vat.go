@@ -2,67 +2,172 @@
 package calculators
 
 import (
-	"calculate-anything/pkg/alfred"
-	"calculate-anything/pkg/config"
 	"calculate-anything/pkg/parser"
 	"fmt"
-	"strconv"
+	"regexp"
 	"strings"
-
-	"github.com/deanishe/awgo"
 )
 
-// HandleVAT 处理增值税（Value Added Tax）计算。
-func HandleVAT(wf *aw.Workflow, cfg *config.AppConfig, p *parser.ParsedQuery) {
-	// 从配置中读取用户设置的 VAT 百分比字符串
-	vatString := strings.TrimSpace(cfg.VATValue)
-	if vatString == "" {
-		alfred.ShowError(wf, fmt.Errorf("未在 Workflow 配置中设置 VAT 百分比"))
-		return
+// vatOverrideRegex 匹配查询中临时指定的税率, e.g. "@ 7%", "at 7.7%"
+var vatOverrideRegex = regexp.MustCompile(`(?i)(?:@|\bat\b)\s*([\d.,]+)\s*%?`)
+
+// vatCalculator 处理增值税（Value Added Tax）计算，由 "vat" 关键字触发。
+// 税率可以来自 vat_value 配置、查询中的国家代码 ("vat 100 de reduced") 或临时指定 ("vat 100 @ 7%")。
+type vatCalculator struct{ baseCalculator }
+
+func init() { Register(&vatCalculator{}) }
+
+func (*vatCalculator) Name() string    { return "vat" }
+func (*vatCalculator) Priority() int   { return 70 }
+func (*vatCalculator) Keyword() string { return "vat" }
+
+func (*vatCalculator) Examples() []string {
+	return []string{"vat 100", "vat 100 de", "vat 100 fr reduced", "vat 100 @ 7%", "vat of 119 gross"}
+}
+
+// Match 接受关键字之后的任意输入，金额和税率在 Compute 中校验。
+func (*vatCalculator) Match(ctx *Context, query string) *parser.ParsedQuery {
+	return &parser.ParsedQuery{Type: parser.VATQuery, Input: query}
+}
+
+// Compute 计算税额、税后总额和税前金额。
+// 输入标明 "net" 时只计算加税，标明 "gross" 时只从含税价中拆出税额。
+func (*vatCalculator) Compute(ctx *Context, p *parser.ParsedQuery) ([]Result, error) {
+	cfg := ctx.Config
+	nf := ctx.Format
+
+	// 步骤 1: 取出临时指定的税率，剩下的是金额、国家代码、税率档和 net/gross
+	input := p.Input
+	override := -1.0
+	if m := vatOverrideRegex.FindStringSubmatchIndex(input); m != nil {
+		rate, err := nf.Parse(input[m[2]:m[3]])
+		if err != nil {
+			return nil, fmt.Errorf("无效的 VAT 百分比: %s", input[m[2]:m[3]])
+		}
+		override = rate
+		input = input[:m[0]] + " " + input[m[1]:]
 	}
 
-	// 清理字符串（移除 % 符号）并转换为浮点数
-	vatString = strings.TrimSuffix(vatString, "%")
-	vatPercent, err := strconv.ParseFloat(vatString, 64)
+	fields := strings.Fields(input)
+	if len(fields) > 0 && strings.EqualFold(fields[0], "of") {
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("请输入 VAT 计算金额")
+	}
+	amount, err := nf.Parse(fields[0])
 	if err != nil {
-		alfred.ShowError(wf, fmt.Errorf("无效的 VAT 百分比格式: %s", cfg.VATValue))
-		return
+		return nil, fmt.Errorf("无效的 VAT 计算金额: %s", fields[0])
+	}
+
+	mode, country, kind := "", "", ""
+	for _, field := range fields[1:] {
+		word := strings.ToLower(field)
+		switch word {
+		case "net", "netto", "excl":
+			mode = "net"
+		case "gross", "brutto", "incl":
+			mode = "gross"
+		default:
+			if k, ok := vatKinds[word]; ok {
+				kind = k
+			} else if _, _, ok := lookupVATCountry(word); ok {
+				country = word
+			} else {
+				return nil, fmt.Errorf("未知的国家代码或税率档: %s", field)
+			}
+		}
 	}
 
-	// 解析用户输入的金额
-	amount, err := strconv.ParseFloat(p.Input, 64)
+	// 步骤 2: 确定税率。查询中的国家代码优先于配置，临时指定的税率只替换税率、保留国家的货币
+	var profile vatProfile
+	switch {
+	case country != "":
+		profile, err = vatCountryProfile(country, kind)
+	default:
+		profile, err = defaultVATProfile(cfg.VATValue, nf)
+		if err != nil && override >= 0 {
+			// 临时指定了税率时，配置无效也不影响计算
+			profile, err = vatProfile{}, nil
+		}
+		if err == nil && kind != "" {
+			if profile.Country == "" {
+				return nil, fmt.Errorf("税率档 %s 需要国家代码, e.g. \"vat 100 de %s\"", kind, kind)
+			}
+			profile, err = vatCountryProfile(profile.Country, kind)
+		}
+	}
 	if err != nil {
-		alfred.ShowError(wf, fmt.Errorf("无效的 VAT 计算金额: %s", p.Input))
-		return
+		return nil, err
+	}
+	if override >= 0 {
+		profile.Rate, profile.Label = override, "指定税率"
+		if profile.Country != "" {
+			_, c, _ := lookupVATCountry(profile.Country)
+			profile.Label = c.Name + " 指定税率"
+		}
 	}
 
-	// 执行计算
-	vatRate := vatPercent / 100.0
-	vatAmount := amount * vatRate         // 税额
-	amountWithVAT := amount + vatAmount   // 税后总额
+	// 步骤 3: 计算
+	vatRate := profile.Rate / 100.0
+	vatAmount := amount * vatRate              // 税额
+	amountWithVAT := amount + vatAmount        // 税后总额
 	amountWithoutVAT := amount / (1 + vatRate) // 税前金额（如果输入的是含税价）
+	includedVAT := amount - amountWithoutVAT   // 含税价中包含的税额
 
-	// 生成三个不同的结果，分别对应原始 README 中的三种情况
-	results := []alfred.Result{
+	decimals := cfg.CurrencyDecimals
+	money := func(v float64) string {
+		if profile.Currency == "" {
+			return nf.Format(v, decimals)
+		}
+		return nf.Format(v, decimals) + " " + profile.Currency
+	}
+	rate := fmt.Sprintf("%s %s%%", profile.Label, nf.Format(profile.Rate, -1))
+
+	forward := []Result{
 		{
-			Title:    fmt.Sprintf("VAT 金额 (%.2f%%): %.2f", vatPercent, vatAmount),
-			Subtitle: "复制税额",
-			Arg:      fmt.Sprintf("%.2f", vatAmount),
-			IconPath: "icon.png",
+			Value:    vatAmount,
+			Unit:     profile.Currency,
+			Title:    fmt.Sprintf("VAT 金额 (%s%%): %s", nf.Format(profile.Rate, -1), money(vatAmount)),
+			Subtitle: fmt.Sprintf("%s · 复制税额", rate),
+			Arg:      nf.Plain(vatAmount, decimals),
+			Icon:     "icon.png",
 		},
 		{
-			Title:    fmt.Sprintf("税后总额: %.2f", amountWithVAT),
-			Subtitle: "复制金额 + VAT",
-			Arg:      fmt.Sprintf("%.2f", amountWithVAT),
-			IconPath: "icon.png",
+			Value:    amountWithVAT,
+			Unit:     profile.Currency,
+			Title:    fmt.Sprintf("税后总额: %s", money(amountWithVAT)),
+			Subtitle: fmt.Sprintf("%s · 复制金额 + VAT", rate),
+			Arg:      nf.Plain(amountWithVAT, decimals),
+			Icon:     "icon.png",
 		},
+	}
+	reverse := []Result{
 		{
-			Title:    fmt.Sprintf("税前金额: %.2f", amountWithoutVAT),
-			Subtitle: fmt.Sprintf("如果 %g 是最终价格，则复制税前金额", amount),
-			Arg:      fmt.Sprintf("%.2f", amountWithoutVAT),
-			IconPath: "icon.png",
+			Value:    amountWithoutVAT,
+			Unit:     profile.Currency,
+			Title:    fmt.Sprintf("税前金额: %s", money(amountWithoutVAT)),
+			Subtitle: fmt.Sprintf("%s · 如果 %s 是最终价格，则复制税前金额", rate, nf.Format(amount, -1)),
+			Arg:      nf.Plain(amountWithoutVAT, decimals),
+			Icon:     "icon.png",
+		},
+		{
+			Value:    includedVAT,
+			Unit:     profile.Currency,
+			Title:    fmt.Sprintf("含 VAT (%s%%): %s", nf.Format(profile.Rate, -1), money(includedVAT)),
+			Subtitle: fmt.Sprintf("%s · %s 中包含的税额", rate, nf.Format(amount, -1)),
+			Arg:      nf.Plain(includedVAT, decimals),
+			Icon:     "icon.png",
 		},
 	}
 
-	alfred.AddToWorkflow(wf, results)
+	switch mode {
+	case "net":
+		return forward, nil
+	case "gross":
+		return reverse, nil
+	default:
+		// 未标明时按原来的三种情况给出: 税额、税后总额和税前金额
+		return append(forward, reverse[0]), nil
+	}
 }
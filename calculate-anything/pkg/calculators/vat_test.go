@@ -0,0 +1,12 @@
+// calculate-anything/pkg/calculators/vat_test.go
+package calculators
+
+import "testing"
+
+func TestVAT(t *testing.T) {
+	checkQueries(t, []queryTest{
+		{query: "vat 100", calculator: "vat", title: "VAT 金额 (16%): 16.00"},
+		{query: "vat 100 de", calculator: "vat", title: "VAT 金额 (19%): 19.00 EUR"},
+		{query: "vat 100 @ 7%", calculator: "vat", title: "VAT 金额 (7%): 7.00"},
+	})
+}
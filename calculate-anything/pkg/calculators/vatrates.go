@@ -0,0 +1,162 @@
+// calculate-anything/pkg/calculators/vatrates.go
+package calculators
+
+import (
+	"calculate-anything/pkg/format"
+	"fmt"
+	"strings"
+)
+
+// vatCountry 是一个国家或地区的增值税 (或消费税、GST) 税率，单位为百分比。
+type vatCountry struct {
+	Name         string
+	Currency     string
+	Standard     float64
+	Reduced      []float64 // 优惠税率，按常用程度排列
+	SuperReduced float64   // 超低税率，0 表示没有
+}
+
+// vatRates 是内置的税率表，按 ISO 3166-1 两位国家代码索引，数据截至 2025 年。
+// 税率经常调整，需要其他税率时可以用 "@ 7%" 临时指定，或在 vat_value 中配置。
+var vatRates = map[string]vatCountry{
+	// --- 欧盟 ---
+	"AT": {Name: "奥地利", Currency: "EUR", Standard: 20, Reduced: []float64{10, 13}},
+	"BE": {Name: "比利时", Currency: "EUR", Standard: 21, Reduced: []float64{6, 12}},
+	"BG": {Name: "保加利亚", Currency: "BGN", Standard: 20, Reduced: []float64{9}},
+	"HR": {Name: "克罗地亚", Currency: "EUR", Standard: 25, Reduced: []float64{13, 5}},
+	"CY": {Name: "塞浦路斯", Currency: "EUR", Standard: 19, Reduced: []float64{9, 5}, SuperReduced: 3},
+	"CZ": {Name: "捷克", Currency: "CZK", Standard: 21, Reduced: []float64{12}},
+	"DK": {Name: "丹麦", Currency: "DKK", Standard: 25},
+	"EE": {Name: "爱沙尼亚", Currency: "EUR", Standard: 24, Reduced: []float64{9, 13}},
+	"FI": {Name: "芬兰", Currency: "EUR", Standard: 25.5, Reduced: []float64{14, 10}},
+	"FR": {Name: "法国", Currency: "EUR", Standard: 20, Reduced: []float64{10, 5.5}, SuperReduced: 2.1},
+	"DE": {Name: "德国", Currency: "EUR", Standard: 19, Reduced: []float64{7}},
+	"GR": {Name: "希腊", Currency: "EUR", Standard: 24, Reduced: []float64{13, 6}},
+	"HU": {Name: "匈牙利", Currency: "HUF", Standard: 27, Reduced: []float64{18, 5}},
+	"IE": {Name: "爱尔兰", Currency: "EUR", Standard: 23, Reduced: []float64{13.5, 9}, SuperReduced: 4.8},
+	"IT": {Name: "意大利", Currency: "EUR", Standard: 22, Reduced: []float64{10, 5}, SuperReduced: 4},
+	"LV": {Name: "拉脱维亚", Currency: "EUR", Standard: 21, Reduced: []float64{12, 5}},
+	"LT": {Name: "立陶宛", Currency: "EUR", Standard: 21, Reduced: []float64{9, 5}},
+	"LU": {Name: "卢森堡", Currency: "EUR", Standard: 17, Reduced: []float64{8, 14}, SuperReduced: 3},
+	"MT": {Name: "马耳他", Currency: "EUR", Standard: 18, Reduced: []float64{7, 5}},
+	"NL": {Name: "荷兰", Currency: "EUR", Standard: 21, Reduced: []float64{9}},
+	"PL": {Name: "波兰", Currency: "PLN", Standard: 23, Reduced: []float64{8, 5}},
+	"PT": {Name: "葡萄牙", Currency: "EUR", Standard: 23, Reduced: []float64{13, 6}},
+	"RO": {Name: "罗马尼亚", Currency: "RON", Standard: 21, Reduced: []float64{11}},
+	"SK": {Name: "斯洛伐克", Currency: "EUR", Standard: 23, Reduced: []float64{19, 5}},
+	"SI": {Name: "斯洛文尼亚", Currency: "EUR", Standard: 22, Reduced: []float64{9.5, 5}},
+	"ES": {Name: "西班牙", Currency: "EUR", Standard: 21, Reduced: []float64{10}, SuperReduced: 4},
+	"SE": {Name: "瑞典", Currency: "SEK", Standard: 25, Reduced: []float64{12, 6}},
+
+	// --- 欧洲其他国家 ---
+	"GB": {Name: "英国", Currency: "GBP", Standard: 20, Reduced: []float64{5}},
+	"CH": {Name: "瑞士", Currency: "CHF", Standard: 8.1, Reduced: []float64{2.6, 3.8}},
+	"NO": {Name: "挪威", Currency: "NOK", Standard: 25, Reduced: []float64{15, 12}},
+	"IS": {Name: "冰岛", Currency: "ISK", Standard: 24, Reduced: []float64{11}},
+	"TR": {Name: "土耳其", Currency: "TRY", Standard: 20, Reduced: []float64{10, 1}},
+	"UA": {Name: "乌克兰", Currency: "UAH", Standard: 20, Reduced: []float64{14, 7}},
+
+	// --- 其他地区 ---
+	"AU": {Name: "澳大利亚", Currency: "AUD", Standard: 10},
+	"NZ": {Name: "新西兰", Currency: "NZD", Standard: 15},
+	"CA": {Name: "加拿大 (联邦 GST)", Currency: "CAD", Standard: 5},
+	"MX": {Name: "墨西哥", Currency: "MXN", Standard: 16, Reduced: []float64{8}},
+	"JP": {Name: "日本", Currency: "JPY", Standard: 10, Reduced: []float64{8}},
+	"CN": {Name: "中国", Currency: "CNY", Standard: 13, Reduced: []float64{9, 6}},
+	"KR": {Name: "韩国", Currency: "KRW", Standard: 10},
+	"SG": {Name: "新加坡", Currency: "SGD", Standard: 9},
+	"IN": {Name: "印度 (GST)", Currency: "INR", Standard: 18, Reduced: []float64{5}},
+	"TH": {Name: "泰国", Currency: "THB", Standard: 7},
+	"ZA": {Name: "南非", Currency: "ZAR", Standard: 15},
+	"AE": {Name: "阿联酋", Currency: "AED", Standard: 5},
+	"SA": {Name: "沙特阿拉伯", Currency: "SAR", Standard: 15},
+}
+
+// vatCountryAliases 是常见的非 ISO 写法
+var vatCountryAliases = map[string]string{
+	"UK": "GB", "EL": "GR",
+}
+
+// vatProfile 是一次计算使用的税率。
+type vatProfile struct {
+	Rate     float64 // 百分比, e.g. 19
+	Label    string  // 税率说明, e.g. "德国 标准税率"
+	Country  string  // 国家代码，自定义税率时为空
+	Currency string  // 金额的货币，未知时为空
+}
+
+// lookupVATCountry 按国家代码 (不区分大小写) 查找税率表。
+func lookupVATCountry(code string) (string, vatCountry, bool) {
+	code = strings.ToUpper(code)
+	if alias, ok := vatCountryAliases[code]; ok {
+		code = alias
+	}
+	country, ok := vatRates[code]
+	return code, country, ok
+}
+
+// vatCountryProfile 返回国家的某一档税率: "standard" (默认)、"reduced"、"reduced2" 或 "super"。
+func vatCountryProfile(code, kind string) (vatProfile, error) {
+	code, country, ok := lookupVATCountry(code)
+	if !ok {
+		return vatProfile{}, fmt.Errorf("未知的国家代码: %s", code)
+	}
+	profile := vatProfile{Country: code, Currency: country.Currency}
+	switch kind {
+	case "", "standard":
+		profile.Rate, profile.Label = country.Standard, country.Name+" 标准税率"
+	case "reduced", "reduced2":
+		i := 0
+		if kind == "reduced2" {
+			i = 1
+		}
+		if i >= len(country.Reduced) {
+			return vatProfile{}, fmt.Errorf("%s 没有第 %d 档优惠税率", country.Name, i+1)
+		}
+		profile.Rate, profile.Label = country.Reduced[i], country.Name+" 优惠税率"
+	case "super":
+		if country.SuperReduced == 0 {
+			return vatProfile{}, fmt.Errorf("%s 没有超低税率", country.Name)
+		}
+		profile.Rate, profile.Label = country.SuperReduced, country.Name+" 超低税率"
+	default:
+		return vatProfile{}, fmt.Errorf("未知的税率档: %s", kind)
+	}
+	return profile, nil
+}
+
+// vatKinds 将查询中税率档的写法映射为 vatCountryProfile 的 kind
+var vatKinds = map[string]string{
+	"standard": "standard", "std": "standard",
+	"reduced": "reduced", "red": "reduced", "reduced2": "reduced2",
+	"super": "super", "super-reduced": "super", "superreduced": "super",
+}
+
+// defaultVATProfile 解析 vat_value 配置。它可以是百分比 ("16%")，
+// 也可以是国家代码和可选的税率档 ("de", "de reduced")。
+func defaultVATProfile(setting string, nf *format.Formatter) (vatProfile, error) {
+	setting = strings.TrimSpace(setting)
+	if setting == "" {
+		return vatProfile{}, fmt.Errorf("未在 Workflow 配置中设置 VAT 百分比")
+	}
+	if rate, err := nf.Parse(strings.TrimSuffix(setting, "%")); err == nil {
+		return vatProfile{Rate: rate, Label: "默认税率"}, nil
+	}
+
+	fields := strings.Fields(strings.ToLower(setting))
+	kind := ""
+	if len(fields) == 2 {
+		kind = vatKinds[fields[1]]
+		if kind == "" {
+			return vatProfile{}, fmt.Errorf("无效的 VAT 配置: %s", setting)
+		}
+	}
+	if len(fields) > 2 {
+		return vatProfile{}, fmt.Errorf("无效的 VAT 配置: %s", setting)
+	}
+	profile, err := vatCountryProfile(fields[0], kind)
+	if err != nil {
+		return vatProfile{}, fmt.Errorf("无效的 VAT 配置: %w", err)
+	}
+	return profile, nil
+}
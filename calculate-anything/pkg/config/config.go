@@ -13,18 +13,32 @@ type AppConfig struct {
 	Language                 string   // 偏好语言 (e.g., "en_US")
 	DecimalSeparator         string   // 输入时的小数点分隔符 ("dot" or "comma")
 	NumberOutputFormat       string   // 数字输出格式
+	RoundingMode             string   // 固定小数位数时的舍入方式 (e.g., "half_even", "half_up", "floor")
 	Timezone                 string   // 时区 (e.g., "America/New_York")
 	CurrencyDecimals         int      // 货币转换结果的小数位数
 	BaseCurrencies           []string // 默认转换的目标货币 (e.g., ["USD", "EUR"])
 	APIKeyFixer              string   // Fixer.io 的 API 密钥
+	CurrencyProviders        []string // 汇率提供者的尝试顺序 (e.g., ["fixer", "ecb", "exchangeratehost", "file"])
+	CurrencyRatesFile        string   // 本地汇率文件的路径 (.json 或 .csv)
+	ExchangeRateHostURL      string   // exchangerate.host 风格接口的地址，为空时使用官方地址
+	ExchangeRateHostHistURL  string   // exchangerate.host 风格的历史汇率接口，{date} 会被替换为 YYYY-MM-DD
+	APIKeyExchangeRateHost   string   // exchangerate.host 的 API 密钥
 	CurrencyCacheHours       int      // 货币汇率缓存的小时数
 	APIKeyCoinMarket         string   // CoinMarketCap 的 API 密钥
 	CryptoCurrencyCacheHours int      // 加密货币汇率缓存的小时数
 	CryptoDecimals           int      // 加密货币转换结果的小数位数
-	VATValue                 string   // 默认的增值税率 (e.g., "16%")
+	CryptoListingLimit       int      // 价格表中包含的币种数量 (按市值排名)
+	CryptoShowMarket         bool     // 是否在转换结果中显示涨跌幅、市值和成交量
+	VATValue                 string   // 默认的增值税率 (e.g., "16%")，或国家代码加可选的税率档 (e.g., "de", "fr reduced")
 	DateFormat               string   // 时间计算结果的输出格式
+	WeekendDays              []string // 非工作日的星期 (e.g., ["sat", "sun"])
+	HolidaysFile             string   // 节假日文件 (.ics 或 .json)，相对路径相对于 workflow 数据目录
 	PixelsBase               string   // px/em/rem 转换的基础像素值 (e.g., "16px")
 	DataStorageForceBinary   bool     // 是否强制使用二进制模式（1024）进行数据存储单位转换
+
+	// UnitPreferences 是只输入源单位时 (e.g. "10 km") 列出的目标单位，按单位类型分组、按顺序排列,
+	// 键为类型名 (e.g. "length", "data storage")。配置中出现的类型会覆盖内置的默认列表。
+	UnitPreferences map[string][]string
 }
 
 // Load 函数使用 awgo 库从 Alfred 的环境变量和配置文件中加载所有配置项。
@@ -35,18 +49,29 @@ func Load(wf *aw.Workflow) *AppConfig {
 		Language:                 wf.Config.GetString("language", "en_US"),
 		DecimalSeparator:         wf.Config.GetString("decimal_separator", "dot"),
 		NumberOutputFormat:       wf.Config.GetString("number_output_format", "comma_dot"),
+		RoundingMode:             wf.Config.GetString("rounding_mode", "half_even"), // 默认银行家舍入法，适合金额
 		Timezone:                 wf.Config.GetString("timezone", "UTC"),
 		CurrencyDecimals:         wf.Config.GetInt("currency_decimals", 2),
 		BaseCurrencies:           parseBaseCurrencies(wf.Config.GetString("base_currencies", "USD,EUR")),
 		APIKeyFixer:              wf.Config.GetString("apikey_fixer", ""),
+		CurrencyProviders:        parseNameList(wf.Config.GetString("currency_providers", "fixer,ecb,exchangeratehost,file")),
+		CurrencyRatesFile:        wf.Config.GetString("currency_rates_file", ""),
+		ExchangeRateHostURL:      wf.Config.GetString("exchangerate_host_url", ""),
+		ExchangeRateHostHistURL:  wf.Config.GetString("exchangerate_host_historical_url", ""),
+		APIKeyExchangeRateHost:   wf.Config.GetString("apikey_exchangeratehost", ""),
 		CurrencyCacheHours:       wf.Config.GetInt("currency_cache_hours", 12),
 		APIKeyCoinMarket:         wf.Config.GetString("apikey_coinmarket", ""),
 		CryptoCurrencyCacheHours: wf.Config.GetInt("cryptocurrency_cache_hours", 6),
 		CryptoDecimals:           wf.Config.GetInt("crypto_decimals", -1),
+		CryptoListingLimit:       wf.Config.GetInt("crypto_listing_limit", 200),
+		CryptoShowMarket:         wf.Config.GetBool("crypto_show_market", false),
 		VATValue:                 wf.Config.GetString("vat_value", "16%"),
 		DateFormat:               wf.Config.GetString("date_format", "2006-01-02 15:04:05"), // 使用 Go 的标准时间格式
+		WeekendDays:              parseNameList(wf.Config.GetString("weekend_days", "sat,sun")),
+		HolidaysFile:             wf.Config.GetString("holidays_file", ""),
 		PixelsBase:               wf.Config.GetString("pixels_base", "16px"),
 		DataStorageForceBinary:   wf.Config.GetBool("datastorage_force_binary", false),
+		UnitPreferences:          parseUnitPreferences(wf.Config.GetString("unit_preferences", "")),
 	}
 }
 
@@ -62,3 +87,35 @@ func parseBaseCurrencies(s string) []string {
 	}
 	return parts
 }
+
+// parseNameList 将逗号分隔的名称 (提供者、星期等) 解析为小写的字符串切片，忽略空项。
+func parseNameList(s string) []string {
+	var names []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			names = append(names, p)
+		}
+	}
+	return names
+}
+
+// parseUnitPreferences 解析 "类型=单位,单位;类型=单位" 形式的单位列表,
+// e.g. "length=km,mi,ft; mass=kg,lb"。类型名不区分大小写，单位保留原样，因为 "mm" 与 "Mm" 含义不同。
+func parseUnitPreferences(s string) map[string][]string {
+	prefs := map[string][]string{}
+	for _, entry := range strings.Split(s, ";") {
+		family, list, ok := strings.Cut(entry, "=")
+		family = strings.ToLower(strings.TrimSpace(family))
+		if !ok || family == "" {
+			continue
+		}
+		var units []string
+		for _, u := range strings.Split(list, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				units = append(units, u)
+			}
+		}
+		prefs[family] = units
+	}
+	return prefs
+}
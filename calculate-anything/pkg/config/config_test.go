@@ -0,0 +1,27 @@
+// calculate-anything/pkg/config/config_test.go
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLists(t *testing.T) {
+	if got := parseBaseCurrencies(" USD, EUR "); !reflect.DeepEqual(got, []string{"USD", "EUR"}) {
+		t.Errorf("parseBaseCurrencies = %q", got)
+	}
+	if got := parseNameList("Fixer, ,ECB,"); !reflect.DeepEqual(got, []string{"fixer", "ecb"}) {
+		t.Errorf("parseNameList = %q", got)
+	}
+}
+
+func TestParseUnitPreferences(t *testing.T) {
+	got := parseUnitPreferences("Length=km, mi ,Mm; mass=kg,lb;; broken")
+	want := map[string][]string{
+		"length": {"km", "mi", "Mm"},
+		"mass":   {"kg", "lb"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseUnitPreferences = %v, 期望 %v", got, want)
+	}
+}
@@ -0,0 +1,343 @@
+// calculate-anything/pkg/decimal/decimal.go
+package decimal
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// DivisionPrecision 是除法结果至少保留的有效数字位数，与 IEEE 754 decimal128 相同。
+const DivisionPrecision = 34
+
+// MaxDigits 是 Parse 接受的数字最多的位数 (见 Digits)。位数有上限时指数也有上限，
+// 因此 align 补齐指数和 String 补零的开销都是有限的，e.g. "1e-999999999" 超出范围。
+// 比 float64 能表示的范围 (约 1e-324 到 1e308) 略大。
+const MaxDigits = 400
+
+// Decimal 是任意精度的十进制数，值为 coef × 10^exp。
+// 零值表示 0，可以直接使用。Decimal 不可变，所有运算都返回新的值。
+type Decimal struct {
+	coef *big.Int // 系数，nil 表示 0
+	exp  int32    // 十的指数，小数通常为负数
+}
+
+var (
+	bigTen = big.NewInt(10)
+	// pow10Cache 缓存常用的 10 的幂
+	pow10Cache = func() []*big.Int {
+		cache := make([]*big.Int, 40)
+		for i := range cache {
+			cache[i] = new(big.Int).Exp(bigTen, big.NewInt(int64(i)), nil)
+		}
+		return cache
+	}()
+)
+
+// New 返回 coef × 10^exp, e.g. New(125, -3) = 0.125。
+func New(coef int64, exp int32) Decimal {
+	return Decimal{coef: big.NewInt(coef), exp: exp}
+}
+
+// NewFromBigInt 返回 i × 10^exp。i 会被复制。
+func NewFromBigInt(i *big.Int, exp int32) Decimal {
+	return Decimal{coef: new(big.Int).Set(i), exp: exp}
+}
+
+// NewFromInt 返回整数 i。
+func NewFromInt(i int64) Decimal {
+	return New(i, 0)
+}
+
+// NewFromFloat 按 float64 的最短十进制表示转换，e.g. 0.1 -> 0.1 (而不是二进制的近似值)。
+// NaN 和无穷大转换为 0。
+func NewFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}
+	}
+	d, _ := Parse(strconv.FormatFloat(f, 'g', -1, 64))
+	return d
+}
+
+// Parse 解析以 "." 为小数点、不分组的数字，允许符号和科学计数法, e.g. "-12.50", "1e30", ".5"。
+func Parse(s string) (Decimal, error) {
+	orig := s
+	exp := int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return Decimal{}, fmt.Errorf("无效的数字 '%s'", orig)
+		}
+		exp, s = e, s[:i]
+	}
+
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative, s = s[0] == '-', s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return Decimal{}, fmt.Errorf("无效的数字 '%s'", orig)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if negative {
+		coef.Neg(coef)
+	}
+	exp -= int64(len(fracPart))
+	if exp < -MaxDigits || exp > MaxDigits {
+		return Decimal{}, fmt.Errorf("数字 '%s' 超出范围", orig)
+	}
+	d := Decimal{coef: coef, exp: int32(exp)}
+	if d.Digits() > MaxDigits {
+		return Decimal{}, fmt.Errorf("数字 '%s' 超出范围", orig)
+	}
+	return d, nil
+}
+
+// RequireParse 与 Parse 相同，但在失败时 panic，用于包级别的常量表。
+func RequireParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// int 返回系数，零值返回新的 0。
+func (d Decimal) int() *big.Int {
+	if d.coef == nil {
+		return new(big.Int)
+	}
+	return d.coef
+}
+
+// pow10 返回 10^n (n >= 0)。
+func pow10(n int64) *big.Int {
+	if n < int64(len(pow10Cache)) {
+		return pow10Cache[n]
+	}
+	return new(big.Int).Exp(bigTen, big.NewInt(n), nil)
+}
+
+// align 将两个数调整到相同的 (较小的) 指数，返回对应的系数。
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	x, y := a.int(), b.int()
+	switch {
+	case a.exp > b.exp:
+		x = new(big.Int).Mul(x, pow10(int64(a.exp)-int64(b.exp)))
+		return x, y, b.exp
+	case a.exp < b.exp:
+		y = new(big.Int).Mul(y, pow10(int64(b.exp)-int64(a.exp)))
+	}
+	return x, y, a.exp
+}
+
+// Add 返回 d + y。
+func (d Decimal) Add(y Decimal) Decimal {
+	x, z, exp := align(d, y)
+	return Decimal{coef: new(big.Int).Add(x, z), exp: exp}
+}
+
+// Sub 返回 d - y。
+func (d Decimal) Sub(y Decimal) Decimal {
+	x, z, exp := align(d, y)
+	return Decimal{coef: new(big.Int).Sub(x, z), exp: exp}
+}
+
+// Mul 返回 d × y。
+func (d Decimal) Mul(y Decimal) Decimal {
+	return Decimal{coef: new(big.Int).Mul(d.int(), y.int()), exp: d.exp + y.exp}
+}
+
+// Quo 返回 d / y，至少保留 digits 位有效数字，最后一位按银行家舍入法处理。
+func (d Decimal) Quo(y Decimal, digits int) (Decimal, error) {
+	if y.IsZero() {
+		return Decimal{}, fmt.Errorf("除数不能为零")
+	}
+	if d.IsZero() {
+		return Decimal{}, nil
+	}
+	a, b := d.int(), y.int()
+	// 将被除数放大，使商的位数不少于 digits
+	shift := int64(digits) + int64(numDigits(b)) - int64(numDigits(a)) + 1
+	if shift < 0 {
+		shift = 0
+	}
+	num := new(big.Int).Mul(a, pow10(shift))
+	q, r := new(big.Int).QuoRem(num, b, new(big.Int))
+	q = roundQuotient(q, r, b, num.Sign()*b.Sign() < 0, HalfEven)
+	return Decimal{coef: q, exp: d.exp - y.exp - int32(shift)}.Trim(), nil
+}
+
+// Mod 返回 d 除以 y 的余数，符号与 d 相同 (与 math.Mod 一致)。
+func (d Decimal) Mod(y Decimal) (Decimal, error) {
+	if y.IsZero() {
+		return Decimal{}, fmt.Errorf("取模的除数不能为零")
+	}
+	x, z, exp := align(d, y)
+	return Decimal{coef: new(big.Int).Rem(x, z), exp: exp}, nil
+}
+
+// Pow 返回 d 的 n 次幂 (n >= 0)。
+func (d Decimal) Pow(n int) Decimal {
+	coef := new(big.Int).Exp(d.int(), big.NewInt(int64(n)), nil)
+	return Decimal{coef: coef, exp: d.exp * int32(n)}
+}
+
+// Neg 返回 -d。
+func (d Decimal) Neg() Decimal {
+	return Decimal{coef: new(big.Int).Neg(d.int()), exp: d.exp}
+}
+
+// Abs 返回 |d|。
+func (d Decimal) Abs() Decimal {
+	return Decimal{coef: new(big.Int).Abs(d.int()), exp: d.exp}
+}
+
+// Sign 返回 -1、0 或 1。
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// IsZero 报告 d 是否为 0。
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// IsInteger 报告 d 是否为整数。
+func (d Decimal) IsInteger() bool {
+	if d.exp >= 0 {
+		return true
+	}
+	return new(big.Int).Rem(d.int(), pow10(-int64(d.exp))).Sign() == 0
+}
+
+// Cmp 比较 d 和 y，返回 -1、0 或 1。
+func (d Decimal) Cmp(y Decimal) int {
+	x, z, _ := align(d, y)
+	return x.Cmp(z)
+}
+
+// Float64 返回最接近 d 的 float64。
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Round 保留 places 位小数，按 mode 舍入。places 为负数时舍入到十位、百位等。
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	if -d.exp <= places {
+		return d
+	}
+	divisor := pow10(int64(-places) - int64(d.exp))
+	q, r := new(big.Int).QuoRem(d.int(), divisor, new(big.Int))
+	return Decimal{coef: roundQuotient(q, r, divisor, d.Sign() < 0, mode), exp: -places}
+}
+
+// RoundSignificant 保留 digits 位有效数字，但不舍去整数部分，
+// 因此大整数保持精确，只有小数部分会被舍入。
+func (d Decimal) RoundSignificant(digits int, mode RoundingMode) Decimal {
+	if d.IsZero() {
+		return d
+	}
+	// 最高位数字所在的位置，e.g. 123.4 -> 3, 0.05 -> -1
+	magnitude := int64(numDigits(d.int())) + int64(d.exp)
+	places := int64(digits) - magnitude
+	if places < 0 {
+		places = 0
+	}
+	return d.Round(int32(places), mode)
+}
+
+// Trim 去掉小数部分末尾的零，e.g. 1.500 -> 1.5。
+func (d Decimal) Trim() Decimal {
+	coef := d.int()
+	if coef.Sign() == 0 {
+		return Decimal{}
+	}
+	exp := d.exp
+	q, r := new(big.Int), new(big.Int)
+	for exp < 0 {
+		q.QuoRem(coef, bigTen, r)
+		if r.Sign() != 0 {
+			break
+		}
+		coef, exp = new(big.Int).Set(q), exp+1
+	}
+	return Decimal{coef: coef, exp: exp}
+}
+
+// String 返回不带分组、不使用科学计数法的十进制表示，小数位数由精度决定。
+func (d Decimal) String() string {
+	coef := d.int()
+	digits := new(big.Int).Abs(coef).String()
+	sign := ""
+	if coef.Sign() < 0 {
+		sign = "-"
+	}
+	if d.exp >= 0 {
+		if coef.Sign() == 0 {
+			return "0"
+		}
+		return sign + digits + strings.Repeat("0", int(d.exp))
+	}
+
+	places := int(-d.exp)
+	if len(digits) <= places {
+		digits = strings.Repeat("0", places-len(digits)+1) + digits
+	}
+	point := len(digits) - places
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Digits 返回 String 输出的数字个数 (不含符号和小数点), e.g. 1200 -> 4, 0.05 -> 3。
+func (d Decimal) Digits() int {
+	n := int64(numDigits(d.int()))
+	switch {
+	case d.IsZero():
+		return 1
+	case d.exp >= 0:
+		n += int64(d.exp)
+	case n <= -int64(d.exp):
+		n = -int64(d.exp) + 1
+	}
+	return int(n)
+}
+
+// StringFixed 保留 places 位小数并补足末尾的零，e.g. 1.5 -> "1.50"。
+func (d Decimal) StringFixed(places int32, mode RoundingMode) string {
+	s := d.Round(places, mode).String()
+	if places <= 0 {
+		return s
+	}
+	frac := 0
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		frac = len(s) - i - 1
+	} else {
+		s += "."
+	}
+	return s + strings.Repeat("0", int(places)-frac)
+}
+
+// numDigits 返回整数的十进制位数 (不含符号)，0 的位数为 1。
+func numDigits(i *big.Int) int {
+	if i.Sign() == 0 {
+		return 1
+	}
+	// BitLen 给出的估计最多多一位，用字符串长度确认
+	estimate := int(float64(i.BitLen())*math.Log10(2)) + 1
+	if estimate < 18 || estimate > 1000 {
+		return len(new(big.Int).Abs(i).String())
+	}
+	if new(big.Int).Abs(i).Cmp(pow10(int64(estimate-1))) < 0 {
+		return estimate - 1
+	}
+	return estimate
+}
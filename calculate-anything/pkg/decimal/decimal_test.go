@@ -0,0 +1,86 @@
+// calculate-anything/pkg/decimal/decimal_test.go
+package decimal
+
+import "testing"
+
+func TestArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"add", RequireParse("0.1").Add(RequireParse("0.2")), "0.3"},
+		{"sub", RequireParse("1").Sub(RequireParse("0.9")), "0.1"},
+		{"mul", RequireParse("1.1").Mul(RequireParse("1.1")), "1.21"},
+		{"pow", RequireParse("2").Pow(64), "18446744073709551616"},
+		{"neg", RequireParse("1.50").Neg(), "-1.50"},
+		{"trim", RequireParse("1.500").Trim(), "1.5"},
+		{"exponent", RequireParse("1.5e3"), "1500"},
+	}
+	for _, tt := range tests {
+		if s := tt.got.String(); s != tt.want {
+			t.Errorf("%s = %s, 期望 %s", tt.name, s, tt.want)
+		}
+	}
+}
+
+func TestQuo(t *testing.T) {
+	q, err := RequireParse("1").Quo(RequireParse("3"), 10)
+	// 至少保留 10 位有效数字
+	if err != nil || q.StringFixed(10, HalfEven) != "0.3333333333" {
+		t.Errorf("1 / 3 = %s (%v)", q, err)
+	}
+	if _, err := RequireParse("1").Quo(Decimal{}, 10); err == nil {
+		t.Errorf("除以 0 应返回错误")
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		value string
+		mode  RoundingMode
+		want  string
+	}{
+		{"2.345", HalfEven, "2.34"},
+		{"2.355", HalfEven, "2.36"},
+		{"2.345", HalfUp, "2.35"},
+		{"-2.345", HalfUp, "-2.35"},
+		{"2.349", Floor, "2.34"},
+		{"-2.341", Floor, "-2.35"},
+		{"2.341", Ceiling, "2.35"},
+	}
+	for _, tt := range tests {
+		if got := RequireParse(tt.value).StringFixed(2, tt.mode); got != tt.want {
+			t.Errorf("StringFixed(%s, 2, %s) = %s, 期望 %s", tt.value, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	// 位数超出 MaxDigits 的数字也无效
+	for _, s := range []string{"", "abc", "1.2.3", "--1", "1e-999999999", "1e401", "1e-400"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) 应返回错误", s)
+		}
+	}
+}
+
+func TestDigits(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"0", 1},
+		{"1200", 4},
+		{"1.2e3", 4},
+		{"-12.5", 3},
+		{"0.05", 3},
+		{"1e-399", 400},
+		{"1e399", 400},
+	}
+	for _, tt := range tests {
+		if got := RequireParse(tt.value).Digits(); got != tt.want {
+			t.Errorf("Digits(%s) = %d, 期望 %d", tt.value, got, tt.want)
+		}
+	}
+}
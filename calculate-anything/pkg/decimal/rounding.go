@@ -0,0 +1,95 @@
+// calculate-anything/pkg/decimal/rounding.go
+package decimal
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RoundingMode 是舍去多余小数位时的舍入方式。零值为 HalfEven。
+type RoundingMode int
+
+const (
+	HalfEven RoundingMode = iota // 四舍六入五成双 (银行家舍入法)，适合金额, e.g. 2.665 -> 2.66, 2.675 -> 2.68
+	HalfUp                       // 四舍五入，0.5 远离零, e.g. 2.665 -> 2.67, -2.665 -> -2.67
+	HalfDown                     // 五舍六入，0.5 趋向零, e.g. 2.665 -> 2.66
+	Up                           // 远离零, e.g. 2.661 -> 2.67
+	Down                         // 趋向零 (截断), e.g. 2.669 -> 2.66
+	Ceiling                      // 趋向正无穷, e.g. -2.669 -> -2.66
+	Floor                        // 趋向负无穷, e.g. -2.661 -> -2.67
+)
+
+// roundingModeNames 是配置中舍入方式的写法
+var roundingModeNames = map[string]RoundingMode{
+	"half_even": HalfEven, "bankers": HalfEven,
+	"half_up": HalfUp, "half_down": HalfDown,
+	"up": Up, "down": Down, "truncate": Down,
+	"ceiling": Ceiling, "floor": Floor,
+}
+
+// ParseRoundingMode 解析舍入方式的名称 (不区分大小写，"-" 与 "_" 等价)，e.g. "half_even", "half-up", "floor"。
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+	if mode, ok := roundingModeNames[key]; ok {
+		return mode, nil
+	}
+	return HalfEven, fmt.Errorf("未知的舍入方式: %s", name)
+}
+
+// String 返回舍入方式的配置名称。
+func (m RoundingMode) String() string {
+	switch m {
+	case HalfUp:
+		return "half_up"
+	case HalfDown:
+		return "half_down"
+	case Up:
+		return "up"
+	case Down:
+		return "down"
+	case Ceiling:
+		return "ceiling"
+	case Floor:
+		return "floor"
+	}
+	return "half_even"
+}
+
+// roundQuotient 根据余数 r (与被除数同号) 和除数 divisor 调整截断得到的商 q。
+// negative 表示精确的商为负数。
+func roundQuotient(q, r, divisor *big.Int, negative bool, mode RoundingMode) *big.Int {
+	if r.Sign() == 0 {
+		return q
+	}
+
+	away := false // 是否向远离零的方向进一位
+	switch mode {
+	case Up:
+		away = true
+	case Down:
+		away = false
+	case Ceiling:
+		away = !negative
+	case Floor:
+		away = negative
+	default:
+		// 比较 2|r| 与 |divisor|，判断余数是否超过一半
+		twice := new(big.Int).Abs(r)
+		twice.Lsh(twice, 1)
+		switch c := twice.Cmp(new(big.Int).Abs(divisor)); {
+		case c > 0:
+			away = true
+		case c == 0:
+			away = mode == HalfUp || (mode == HalfEven && q.Bit(0) == 1)
+		}
+	}
+
+	if !away {
+		return q
+	}
+	if negative {
+		return new(big.Int).Sub(q, big.NewInt(1))
+	}
+	return new(big.Int).Add(q, big.NewInt(1))
+}
@@ -0,0 +1,216 @@
+// calculate-anything/pkg/format/format.go
+package format
+
+import (
+	"calculate-anything/pkg/decimal"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 分组符号的配置名称与实际字符的对应关系
+var groupSeparators = map[string]string{
+	"comma":      ",",
+	"dot":        ".",
+	"space":      " ",
+	"apostrophe": "'",
+	"none":       "",
+}
+
+// Formatter 按用户配置的区域习惯解析输入数字并格式化输出数字。
+// nil 的 *Formatter 可以直接使用，等价于默认配置 (输入 "dot"，输出 "comma_dot"，银行家舍入法)。
+type Formatter struct {
+	inputDecimal  byte                 // 输入时的小数点: '.' 或 ','
+	outputGroup   string               // 输出时的千位分组符号, e.g. ",", ".", " ", ""
+	outputDecimal string               // 输出时的小数点: "." 或 ","
+	rounding      decimal.RoundingMode // 固定小数位数时的舍入方式
+}
+
+// New 根据配置创建 Formatter。
+// decimalSeparator 是输入的小数点 ("dot" 或 "comma")；
+// numberOutputFormat 形如 "分组_小数点"，e.g. "comma_dot" -> 1,234.56, "dot_comma" -> 1.234,56,
+// "space_comma" -> 1 234,56, "none_dot" -> 1234.56；
+// roundingMode 是固定小数位数时的舍入方式, e.g. "half_even", "half_up", "floor"，见 decimal.ParseRoundingMode。
+// 无法识别的值回退到默认配置。
+func New(decimalSeparator, numberOutputFormat, roundingMode string) *Formatter {
+	f := &Formatter{inputDecimal: '.', outputGroup: ",", outputDecimal: "."}
+	f.rounding, _ = decimal.ParseRoundingMode(roundingMode)
+	if strings.EqualFold(strings.TrimSpace(decimalSeparator), "comma") {
+		f.inputDecimal = ','
+	}
+
+	parts := strings.SplitN(strings.ToLower(strings.TrimSpace(numberOutputFormat)), "_", 2)
+	if len(parts) == 2 {
+		group, okGroup := groupSeparators[parts[0]]
+		decimal, okDecimal := groupSeparators[parts[1]]
+		if okGroup && okDecimal && (decimal == "." || decimal == ",") {
+			if group == decimal {
+				// 分组符号与小数点相同时无法区分，放弃分组
+				group = ""
+			}
+			f.outputGroup, f.outputDecimal = group, decimal
+		}
+	}
+	return f
+}
+
+// settings 返回有效的配置，nil 接收者使用默认配置。
+func (f *Formatter) settings() Formatter {
+	if f == nil {
+		return Formatter{inputDecimal: '.', outputGroup: ",", outputDecimal: "."}
+	}
+	return *f
+}
+
+// DecimalComma 报告输入是否以逗号作为小数点。
+func (f *Formatter) DecimalComma() bool {
+	return f.settings().inputDecimal == ','
+}
+
+// Parse 按输入小数点配置解析数字字符串，另一个符号视为千位分组符号。
+// e.g. 小数点为 "comma" 时 "1.234,56" -> 1234.56；为 "dot" 时 "1,234.56" -> 1234.56。
+func (f *Formatter) Parse(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	v, err := strconv.ParseFloat(f.normalize(s), 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的数字 '%s'", s)
+	}
+	return v, nil
+}
+
+// ParseDecimal 与 Parse 相同，但返回任意精度的十进制数，不会丢失超出 float64 精度的位数。
+func (f *Formatter) ParseDecimal(s string) (decimal.Decimal, error) {
+	s = strings.TrimSpace(s)
+	d, err := decimal.Parse(f.normalize(s))
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("无效的数字 '%s'", s)
+	}
+	return d, nil
+}
+
+// normalize 去掉千位分组符号并将小数点统一为 "."。
+func (f *Formatter) normalize(s string) string {
+	group := ","
+	if f.settings().inputDecimal == ',' {
+		group = "."
+	}
+	normalized := strings.ReplaceAll(s, group, "")
+	if group == "." {
+		normalized = strings.ReplaceAll(normalized, ",", ".")
+	}
+	return normalized
+}
+
+// Rounding 返回配置的舍入方式。
+func (f *Formatter) Rounding() decimal.RoundingMode {
+	return f.settings().rounding
+}
+
+// Format 按输出配置格式化数字，带千位分组。
+// decimals 为固定的小数位数，按配置的舍入方式舍入；小于 0 时保留 15 位有效数字并去掉多余的零，见 Clean。
+func (f *Formatter) Format(v float64, decimals int) string {
+	if s, ok := nonFinite(v); ok {
+		return s
+	}
+	cfg := f.settings()
+	return localize(cfg.fixed(v, decimals), cfg.outputGroup, cfg.outputDecimal)
+}
+
+// Plain 与 Format 相同但不分组，适合作为复制到剪贴板的值。
+func (f *Formatter) Plain(v float64, decimals int) string {
+	if s, ok := nonFinite(v); ok {
+		return s
+	}
+	cfg := f.settings()
+	return localize(cfg.fixed(v, decimals), "", cfg.outputDecimal)
+}
+
+// nonFinite 为 NaN 和 ±Inf 返回 "NaN", "+Inf", "-Inf"。它们不是可以分组的数字，
+// 交给 localize 会得到 "+,Inf" 这样的结果。
+func nonFinite(v float64) (string, bool) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// FormatDecimal 与 Format 相同，但格式化任意精度的十进制数。
+// decimals 小于 0 时见 CleanDecimal，整数部分不会因为位数过多而丢失精度。
+func (f *Formatter) FormatDecimal(d decimal.Decimal, decimals int) string {
+	cfg := f.settings()
+	return localize(cfg.fixedDecimal(d, decimals), cfg.outputGroup, cfg.outputDecimal)
+}
+
+// PlainDecimal 与 FormatDecimal 相同但不分组。
+func (f *Formatter) PlainDecimal(d decimal.Decimal, decimals int) string {
+	cfg := f.settings()
+	return localize(cfg.fixedDecimal(d, decimals), "", cfg.outputDecimal)
+}
+
+// Clean 将数字保留 15 位有效数字，以消除 0.1 + 0.2 这类浮点误差，
+// 同时避免输出科学计数法。结果总是使用 "." 作为小数点且不分组。
+func Clean(v float64) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+	if err != nil {
+		rounded = v
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// CleanDecimal 是 Clean 的十进制版本: 小数部分保留 15 位有效数字，整数部分总是完整显示。
+func CleanDecimal(d decimal.Decimal) string {
+	return d.RoundSignificant(15, decimal.HalfEven).Trim().String()
+}
+
+// fixed 返回以 "." 为小数点、不分组的数字字符串。
+// 固定小数位数时按 v 的最短十进制表示舍入，因此 2.675 按 2.675 而不是它的二进制近似值处理。
+func (f Formatter) fixed(v float64, decimals int) string {
+	if decimals < 0 {
+		return Clean(v)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'f', decimals, 64)
+	}
+	return decimal.NewFromFloat(v).StringFixed(int32(decimals), f.rounding)
+}
+
+// fixedDecimal 是 fixed 的十进制版本。
+func (f Formatter) fixedDecimal(d decimal.Decimal, decimals int) string {
+	if decimals < 0 {
+		return CleanDecimal(d)
+	}
+	return d.StringFixed(int32(decimals), f.rounding)
+}
+
+// localize 为 fixed 返回的字符串加上千位分组并替换小数点。
+func localize(s, group, decimal string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	if group != "" && len(intPart) > 3 {
+		var b strings.Builder
+		lead := len(intPart) % 3
+		if lead > 0 {
+			b.WriteString(intPart[:lead])
+		}
+		for i := lead; i < len(intPart); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(group)
+			}
+			b.WriteString(intPart[i : i+3])
+		}
+		intPart = b.String()
+	}
+
+	if fracPart == "" {
+		return sign + intPart
+	}
+	return sign + intPart + decimal + fracPart
+}
@@ -0,0 +1,70 @@
+// calculate-anything/pkg/format/format_test.go
+package format
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		output   string
+		value    float64
+		decimals int
+		want     string
+	}{
+		{"comma_dot", 1234567.891, 2, "1,234,567.89"},
+		{"dot_comma", 1234567.891, 2, "1.234.567,89"},
+		{"space_comma", 1234.5, -1, "1 234,5"},
+		{"none_dot", 1234.5, -1, "1234.5"},
+		{"comma_dot", 0.1 + 0.2, -1, "0.3"},
+		{"comma_dot", -1234.5, 0, "-1,234"},
+	}
+	for _, tt := range tests {
+		if got := New("dot", tt.output, "").Format(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("Format(%g, %d) [%s] = %q, 期望 %q", tt.value, tt.decimals, tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestFormatNonFinite(t *testing.T) {
+	// NaN 和 ±Inf 不能被当作数字分组, e.g. "+,Inf"
+	nf := New("dot", "comma_dot", "")
+	for _, tt := range []struct {
+		value float64
+		want  string
+	}{
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	} {
+		for _, decimals := range []int{-1, 2} {
+			if got := nf.Format(tt.value, decimals); got != tt.want {
+				t.Errorf("Format(%g, %d) = %q, 期望 %q", tt.value, decimals, got, tt.want)
+			}
+			if got := nf.Plain(tt.value, decimals); got != tt.want {
+				t.Errorf("Plain(%g, %d) = %q, 期望 %q", tt.value, decimals, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestParseNumbers(t *testing.T) {
+	tests := []struct {
+		decimal string
+		input   string
+		want    float64
+		ok      bool
+	}{
+		{"dot", "1,234.56", 1234.56, true},
+		{"comma", "1.234,56", 1234.56, true},
+		{"dot", "1.000.000", 0, false},
+		{"comma", "1,2,3", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := New(tt.decimal, "", "").Parse(tt.input)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("Parse(%q) [%s] = %g (%v), 期望 %g", tt.input, tt.decimal, got, err, tt.want)
+		}
+	}
+}
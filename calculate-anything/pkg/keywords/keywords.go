@@ -3,9 +3,17 @@ package keywords
 
 import (
 	"calculate-anything/pkg/i18n"
+	"regexp"
 	"strings"
 )
 
+var (
+	// amountWordRegex 匹配以数量开头的词, e.g. "100", "1.5", "10km"
+	amountWordRegex = regexp.MustCompile(`^[\d.,]*\d`)
+	// numberWordRegex 匹配只有数量的词
+	numberWordRegex = regexp.MustCompile(`^[\d.,]*\d[\d.,]*$`)
+)
+
 // PreprocessQuery 是智能解析器的第一步。
 // 它接收原始查询和加载的语言包，然后返回一个清理过的、更易于机器解析的字符串。
 // 例如: "100 euros to dollars" -> "100 eur usd"
@@ -15,34 +23,61 @@ func PreprocessQuery(query string, langPack *i18n.LanguagePack) string {
 		return query
 	}
 
-	// 将查询按词分割，并转为小写，以便匹配
-	words := strings.Fields(strings.ToLower(query))
+	// 将查询按词分割。匹配时不区分大小写，但未被替换的词保留原样，
+	// 因为单位符号的大小写有含义, e.g. "mW" (毫瓦) 与 "MW" (兆瓦)
+	words := strings.Fields(query)
 
 	// 步骤 1: 替换关键字
 	// 遍历每个词，如果它在语言包的关键字映射中，则替换为标准代码。
 	for i, word := range words {
-		if replacement, ok := langPack.Keywords[word]; ok {
+		if replacement, ok := langPack.Keywords[strings.ToLower(word)]; ok {
 			words[i] = replacement
 		}
 	}
 
-	// 步骤 2: 移除停用词
+	// 步骤 2: 移除作为连接词的停用词
+	// 停用词只在两个位置上是连接词：数量之前 (e.g. "what is 10 km in mi")，
+	// 以及源单位与目标之间 (e.g. "10 km to mi")。其余位置上的词是单位，
+	// 因此 "5 in to cm" 和 "12 in" 中的 "in" 仍然是英寸。
+	amount, source := sourceIndex(words)
 	var cleanedWords []string
-	for _, word := range words {
-		isStopWord := false
-		// 检查当前词是否在停用词列表中
-		for _, stopWord := range langPack.StopWords {
-			if word == stopWord {
-				isStopWord = true
-				break
-			}
+	connector := true
+	for i, word := range words {
+		isStopWord := isStopWord(word, langPack)
+		if i > source {
+			// 最后一个词总是目标, e.g. "5 cm to in"
+			connector = connector && isStopWord && i < len(words)-1
 		}
-		// 如果不是停用词，则将其保留
-		if !isStopWord {
-			cleanedWords = append(cleanedWords, word)
+		if (i < amount || i > source && connector) && isStopWord {
+			continue
 		}
+		cleanedWords = append(cleanedWords, word)
 	}
 
 	// 将清理后的词重新组合成一个字符串
 	return strings.Join(cleanedWords, " ")
 }
+
+// sourceIndex 返回数量和源单位所在的下标。数量与单位连写时 (e.g. "10km") 两者相同；
+// 查询中没有数量时 (e.g. "usd to eur")，第一个词就是源单位。
+func sourceIndex(words []string) (amount, source int) {
+	for i, word := range words {
+		if amountWordRegex.MatchString(word) {
+			if numberWordRegex.MatchString(word) {
+				return i, i + 1
+			}
+			return i, i
+		}
+	}
+	return -1, 0
+}
+
+// isStopWord 检查词语是否在语言包的停用词列表中 (不区分大小写)。
+func isStopWord(word string, langPack *i18n.LanguagePack) bool {
+	for _, stopWord := range langPack.StopWords {
+		if strings.EqualFold(word, stopWord) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+// calculate-anything/pkg/keywords/keywords_test.go
+package keywords
+
+import (
+	"calculate-anything/pkg/i18n"
+	"testing"
+)
+
+// testPack 是 en_US 语言包的一个子集。
+var testPack = &i18n.LanguagePack{
+	Keywords:  map[string]string{"euros": "eur", "dollars": "usd", "meters": "m"},
+	StopWords: []string{"to", "in", "as", "a", "=", "equals", "is", "what"},
+}
+
+func TestPreprocessQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"100 euros to dollars", "100 eur usd"},
+		{"100 Euros TO Dollars", "100 eur usd"},
+		{"10 km in mi", "10 km mi"},
+		{"10km in mi", "10km mi"},
+		{"what is 10 km in mi", "10 km mi"},
+		// 单位符号保留大小写
+		{"1 mW to W", "1 mW W"},
+		{"1 Mm = m", "1 Mm m"},
+		// "in" 作为源单位或最后一个目标时是英寸
+		{"5 in to cm", "5 in cm"},
+		{"12 in", "12 in"},
+		{"5 cm to in", "5 cm in"},
+		{"5 cm in in", "5 cm in"},
+		// 没有数量时第一个词是源单位
+		{"in to cm", "in cm"},
+		{"usd to eur gbp", "usd eur gbp"},
+	}
+	for _, tt := range tests {
+		if got := PreprocessQuery(tt.query, testPack); got != tt.want {
+			t.Errorf("PreprocessQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPreprocessQueryWithoutLanguagePack(t *testing.T) {
+	if got := PreprocessQuery("10 km to mi", nil); got != "10 km to mi" {
+		t.Errorf("PreprocessQuery without language pack = %q", got)
+	}
+}
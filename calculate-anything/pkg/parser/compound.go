@@ -0,0 +1,117 @@
+// calculate-anything/pkg/parser/compound.go
+package parser
+
+import (
+	"calculate-anything/pkg/format"
+	"calculate-anything/pkg/i18n"
+	"regexp"
+	"strings"
+)
+
+// compoundTokenRegex 将复合数量查询切分为数字、运算符和单位词。
+// 单位词允许以 2/3 结尾以表示平方/立方, e.g. "m2", "cm3"。
+var compoundTokenRegex = regexp.MustCompile(`(?i)(\d[\d.,]*|\.\d+)|([+\-])|([a-zμ°]+[23]?)|(\S)`)
+
+// compoundConnectors 是数量与目标单位之间的连接词。
+var compoundConnectors = map[string]bool{
+	"to": true, "in": true, "as": true, "into": true, "=": true,
+}
+
+// parseCompoundQuantities 解析复合或求和的数量查询，
+// e.g. "5ft 3in to cm", "2h 30min + 45min", "1.6 m to ft in"。
+// 只有包含多个数量、显式的加减运算或多个目标单位时才返回结果，
+// 单一数量的简单转换留给 simpleConversionRegex 处理。
+func parseCompoundQuantities(query string, langPack *i18n.LanguagePack, nf *format.Formatter) *ParsedQuery {
+	var quantities []Quantity
+	var targets []string
+	hasOperator := false
+	sign := 1.0
+
+	// 状态: 0 = 期望数量, -1 = 期望单位, 1 = 已读取数量, 2 = 读取目标单位
+	state := 0
+	for _, m := range compoundTokenRegex.FindAllStringSubmatch(microSignReplacer.Replace(strings.TrimSpace(query)), -1) {
+		number, op, word, other := m[1], m[2], m[3], m[4]
+		if other == "=" {
+			// "=" 作为连接词
+			word = "="
+		} else if other != "" {
+			return nil
+		}
+
+		switch state {
+		case 0, 1:
+			switch {
+			case number != "":
+				amount, err := parseAmount(number, nf)
+				if err != nil {
+					return nil
+				}
+				exact := parseExact(number, nf)
+				if sign < 0 {
+					exact = exact.Neg()
+				}
+				quantities = append(quantities, Quantity{Amount: sign * amount, Exact: exact})
+				sign = 1
+				state = -1 // 数字后面必须紧跟单位
+			case op != "" && state == 1:
+				hasOperator = true
+				if op == "-" {
+					sign = -1
+				}
+				state = 0
+			case word != "" && state == 1:
+				state = 2
+				if !compoundConnectors[strings.ToLower(word)] {
+					targets = append(targets, mapUnitWord(word, langPack))
+				}
+			default:
+				return nil
+			}
+		case -1:
+			if word == "" || word == "=" {
+				return nil
+			}
+			quantities[len(quantities)-1].Unit = mapUnitWord(word, langPack)
+			state = 1
+		case 2:
+			switch {
+			case word != "" && word != "=":
+				targets = append(targets, mapUnitWord(word, langPack))
+			case op == "+":
+				// 允许 "ft + in" 这种写法的目标单位
+			default:
+				return nil
+			}
+		}
+	}
+
+	if state != 1 && state != 2 {
+		return nil
+	}
+	if len(quantities) < 2 && !hasOperator && len(targets) < 2 {
+		return nil
+	}
+
+	p := &ParsedQuery{
+		Type:       UnitQuery,
+		Input:      query,
+		Amount:     quantities[0].Amount,
+		From:       quantities[0].Unit,
+		Quantities: quantities,
+		ToUnits:    targets,
+	}
+	if len(targets) > 0 {
+		p.To = targets[0]
+	}
+	return p
+}
+
+// mapUnitWord 使用语言包的关键字映射将单位名称转换为标准符号, e.g. "meters" -> "m"。
+func mapUnitWord(word string, langPack *i18n.LanguagePack) string {
+	if langPack != nil {
+		if replacement, ok := langPack.Keywords[strings.ToLower(word)]; ok {
+			return replacement
+		}
+	}
+	return word
+}
@@ -0,0 +1,601 @@
+// calculate-anything/pkg/parser/expression.go
+package parser
+
+import (
+	"calculate-anything/pkg/decimal"
+	"calculate-anything/pkg/format"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind 表示词法单元的类别。
+type tokenKind int
+
+const (
+	tokNumber   tokenKind = iota // 数字, e.g. "12.5", "1e3"
+	tokIdent                     // 标识符（函数名或常量）, e.g. "sqrt", "pi"
+	tokOperator                  // 运算符: + - * / % ^
+	tokLParen                    // 左括号
+	tokRParen                    // 右括号
+	tokComma                     // 函数参数分隔符
+	tokEOF                       // 输入结束
+)
+
+// token 是分词器产生的最小语法单元。
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64         // 仅对 tokNumber 有效
+	exact decimal.Decimal // value 的精确值，仅对 tokNumber 有效
+	pos   int             // 在原始输入中的位置，用于错误提示
+}
+
+// tokenize 将表达式字符串拆分为词法单元序列。
+// 小数点为逗号时，紧跟数字的 ',' 属于数字，函数参数需要用 ';' 分隔, e.g. "max(1,5; 2)"。
+func tokenize(s string, nf *format.Formatter) ([]token, error) {
+	var tokens []token
+	runes := []rune(s)
+	decimalComma := nf.DecimalComma()
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' ||
+				(decimalComma && runes[i] == ',' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]))) {
+				i++
+			}
+			// 支持科学计数法, e.g. "1e3", "2.5E-4"
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					for j < len(runes) && unicode.IsDigit(runes[j]) {
+						j++
+					}
+					i = j
+				}
+			}
+			text := string(runes[start:i])
+			v, err := nf.Parse(text)
+			if err != nil {
+				return nil, err
+			}
+			exact, err := nf.ParseDecimal(text)
+			if err != nil {
+				exact = decimal.NewFromFloat(v)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, value: v, exact: exact, pos: start})
+		case unicode.IsLetter(r) || r == 'π':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == 'π') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: strings.ToLower(string(runes[start:i])), pos: start})
+		case strings.ContainsRune("+-*/%^×÷", r):
+			// 将常见的数学符号统一为 ASCII 运算符
+			op := string(r)
+			switch r {
+			case '×':
+				op = "*"
+			case '÷':
+				op = "/"
+			}
+			// "**" 作为幂运算的别名
+			if r == '*' && i+1 < len(runes) && runes[i+1] == '*' {
+				op = "^"
+				i++
+			}
+			tokens = append(tokens, token{kind: tokOperator, text: op, pos: i})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case r == ',' || r == ';':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("无法识别的字符 '%c'", r)
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// Node 是算术表达式抽象语法树（AST）的节点。
+type Node interface {
+	// Eval 计算节点的数值。
+	Eval() (float64, error)
+	// String 返回节点的规范化文本形式。
+	String() string
+}
+
+// numberNode 是数字字面量。
+type numberNode struct {
+	value float64
+	exact decimal.Decimal
+}
+
+func (n *numberNode) Eval() (float64, error) { return n.value, nil }
+func (n *numberNode) String() string         { return strconv.FormatFloat(n.value, 'g', -1, 64) }
+
+// constNode 是命名常量, e.g. pi, e。
+type constNode struct {
+	name  string
+	value float64
+}
+
+func (n *constNode) Eval() (float64, error) { return n.value, nil }
+func (n *constNode) String() string         { return n.name }
+
+// unaryNode 是一元运算：负号或后缀百分号。
+type unaryNode struct {
+	op      string // "-" 或 "%"
+	operand Node
+}
+
+func (n *unaryNode) Eval() (float64, error) {
+	v, err := n.operand.Eval()
+	if err != nil {
+		return 0, err
+	}
+	if n.op == "%" {
+		return v / 100, nil
+	}
+	return -v, nil
+}
+
+func (n *unaryNode) String() string {
+	if n.op == "%" {
+		return n.operand.String() + "%"
+	}
+	return "-" + n.operand.String()
+}
+
+// binaryNode 是二元运算。
+type binaryNode struct {
+	op          string
+	left, right Node
+}
+
+func (n *binaryNode) Eval() (float64, error) {
+	l, err := n.left.Eval()
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.Eval()
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, fmt.Errorf("除数不能为零")
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return 0, fmt.Errorf("取模的除数不能为零")
+		}
+		return math.Mod(l, r), nil
+	case "^":
+		return math.Pow(l, r), nil
+	}
+	return 0, fmt.Errorf("未知的运算符: %s", n.op)
+}
+
+func (n *binaryNode) String() string {
+	return "(" + n.left.String() + " " + n.op + " " + n.right.String() + ")"
+}
+
+// callNode 是函数调用, e.g. sqrt(2)。
+type callNode struct {
+	name string
+	args []Node
+}
+
+func (n *callNode) Eval() (float64, error) {
+	fn, ok := exprFunctions[n.name]
+	if !ok {
+		return 0, fmt.Errorf("未知的函数: %s", n.name)
+	}
+	if len(n.args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.args) > fn.maxArgs) {
+		return 0, fmt.Errorf("函数 %s 的参数个数不正确", n.name)
+	}
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, err := a.Eval()
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return fn.eval(args), nil
+}
+
+func (n *callNode) String() string {
+	parts := make([]string, len(n.args))
+	for i, a := range n.args {
+		parts[i] = a.String()
+	}
+	return n.name + "(" + strings.Join(parts, ", ") + ")"
+}
+
+// maxExactPower 是精确计算时允许的最大整数指数，更大的指数回退到 float64
+const maxExactPower = 1000
+
+// EvalExact 以任意精度的十进制数计算表达式，e.g. "0.1 + 0.2" 精确等于 0.3，
+// "12345678901234567 + 1" 不会丢失末位。表达式中含有常量、非整数指数或
+// 无法精确计算的函数 (sqrt、sin 等) 时返回 false，调用方应回退到 Eval。
+// 结果或中间结果超过 decimal.MaxDigits 位时同样返回 false, e.g. "10^-400"。
+func EvalExact(n Node) (decimal.Decimal, bool) {
+	v, ok := evalExact(n)
+	if !ok || v.Digits() > decimal.MaxDigits {
+		return decimal.Decimal{}, false
+	}
+	return v, true
+}
+
+// evalExact 计算 EvalExact 的一个节点，子节点通过 EvalExact 计算并检查位数。
+func evalExact(n Node) (decimal.Decimal, bool) {
+	switch n := n.(type) {
+	case *numberNode:
+		return n.exact, true
+
+	case *unaryNode:
+		v, ok := EvalExact(n.operand)
+		if !ok {
+			return v, false
+		}
+		if n.op == "%" {
+			return v.Mul(decimal.New(1, -2)), true
+		}
+		return v.Neg(), true
+
+	case *binaryNode:
+		l, ok := EvalExact(n.left)
+		if !ok {
+			return l, false
+		}
+		r, ok := EvalExact(n.right)
+		if !ok {
+			return r, false
+		}
+		switch n.op {
+		case "+":
+			return l.Add(r), true
+		case "-":
+			return l.Sub(r), true
+		case "*":
+			return l.Mul(r), true
+		case "/":
+			v, err := l.Quo(r, decimal.DivisionPrecision)
+			return v, err == nil
+		case "%":
+			v, err := l.Mod(r)
+			return v, err == nil
+		case "^":
+			return exactPower(l, r)
+		}
+
+	case *callNode:
+		args := make([]decimal.Decimal, len(n.args))
+		for i, a := range n.args {
+			v, ok := EvalExact(a)
+			if !ok {
+				return v, false
+			}
+			args[i] = v
+		}
+		return exactCall(n.name, args)
+	}
+	return decimal.Decimal{}, false
+}
+
+// exactPower 计算整数次幂，负指数通过除法得到。
+func exactPower(base, exponent decimal.Decimal) (decimal.Decimal, bool) {
+	if !exponent.IsInteger() || exponent.Abs().Cmp(decimal.NewFromInt(maxExactPower)) > 0 {
+		return decimal.Decimal{}, false
+	}
+	n := int(exponent.Float64())
+	if n >= 0 {
+		// base 有 k 位时 base^n 至少有 (k-1)×n 位，超出上限的不必计算
+		if n*(base.Digits()-1) > decimal.MaxDigits {
+			return decimal.Decimal{}, false
+		}
+		return base.Pow(n), true
+	}
+	v, err := decimal.NewFromInt(1).Quo(base.Pow(-n), decimal.DivisionPrecision)
+	return v, err == nil
+}
+
+// exactCall 计算可以精确求值的函数，其他函数返回 false。
+// round 与 math.Round 一致，0.5 远离零舍入。
+func exactCall(name string, args []decimal.Decimal) (decimal.Decimal, bool) {
+	fn, ok := exprFunctions[name]
+	if !ok || len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return decimal.Decimal{}, false
+	}
+	switch name {
+	case "abs":
+		return args[0].Abs(), true
+	case "floor":
+		return args[0].Round(0, decimal.Floor), true
+	case "ceil":
+		return args[0].Round(0, decimal.Ceiling), true
+	case "trunc":
+		return args[0].Round(0, decimal.Down), true
+	case "round":
+		places := int32(0)
+		if len(args) == 2 {
+			p := args[1].Round(0, decimal.Down).Float64()
+			if p < -decimal.MaxDigits || p > decimal.MaxDigits {
+				return decimal.Decimal{}, false
+			}
+			places = int32(p)
+		}
+		return args[0].Round(places, decimal.HalfUp), true
+	case "pow":
+		return exactPower(args[0], args[1])
+	case "min", "max":
+		m := args[0]
+		for _, v := range args[1:] {
+			if c := v.Cmp(m); (name == "min" && c < 0) || (name == "max" && c > 0) {
+				m = v
+			}
+		}
+		return m, true
+	}
+	return decimal.Decimal{}, false
+}
+
+// exprFunction 描述一个内置函数及其允许的参数个数（maxArgs 为 -1 表示不限）。
+type exprFunction struct {
+	minArgs, maxArgs int
+	eval             func(args []float64) float64
+}
+
+// unary 将单参数的 math 函数包装为 exprFunction。
+func unary(f func(float64) float64) exprFunction {
+	return exprFunction{minArgs: 1, maxArgs: 1, eval: func(a []float64) float64 { return f(a[0]) }}
+}
+
+// exprFunctions 是表达式中可用的函数表。三角函数使用弧度。
+var exprFunctions = map[string]exprFunction{
+	"sqrt":  unary(math.Sqrt),
+	"cbrt":  unary(math.Cbrt),
+	"abs":   unary(math.Abs),
+	"sin":   unary(math.Sin),
+	"cos":   unary(math.Cos),
+	"tan":   unary(math.Tan),
+	"asin":  unary(math.Asin),
+	"acos":  unary(math.Acos),
+	"atan":  unary(math.Atan),
+	"sinh":  unary(math.Sinh),
+	"cosh":  unary(math.Cosh),
+	"tanh":  unary(math.Tanh),
+	"ln":    unary(math.Log),
+	"log":   unary(math.Log10),
+	"log2":  unary(math.Log2),
+	"log10": unary(math.Log10),
+	"exp":   unary(math.Exp),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"trunc": unary(math.Trunc),
+	// round(x) 取整，round(x, n) 保留 n 位小数
+	"round": {minArgs: 1, maxArgs: 2, eval: func(a []float64) float64 {
+		if len(a) == 1 {
+			return math.Round(a[0])
+		}
+		p := math.Pow(10, math.Trunc(a[1]))
+		return math.Round(a[0]*p) / p
+	}},
+	"pow": {minArgs: 2, maxArgs: 2, eval: func(a []float64) float64 { return math.Pow(a[0], a[1]) }},
+	"min": {minArgs: 1, maxArgs: -1, eval: func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	}},
+	"max": {minArgs: 1, maxArgs: -1, eval: func(a []float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	}},
+}
+
+// exprConstants 是表达式中可用的命名常量。
+var exprConstants = map[string]float64{
+	"pi":  math.Pi,
+	"π":   math.Pi,
+	"e":   math.E,
+	"tau": 2 * math.Pi,
+}
+
+// binaryPrecedence 定义二元运算符的优先级，数值越大绑定越紧。
+var binaryPrecedence = map[string]int{
+	"+": 1, "-": 1,
+	"*": 2, "/": 2, "%": 2,
+	"^": 4,
+}
+
+// unaryPrecedence 是一元负号的优先级：高于乘除、低于幂运算，因此 -2^2 = -4。
+const unaryPrecedence = 3
+
+// exprParser 使用优先级爬升（precedence climbing）算法构建 AST。
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token { return p.tokens[p.pos] }
+
+func (p *exprParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseExpr 解析优先级不低于 minPrec 的二元表达式。
+func (p *exprParser) parseExpr(minPrec int) (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOperator {
+			return left, nil
+		}
+		// 后缀百分号: "200 * 15%" 中的 % 后面没有操作数
+		if t.text == "%" && p.isPostfixPercent() {
+			p.next()
+			left = &unaryNode{op: "%", operand: left}
+			continue
+		}
+		prec := binaryPrecedence[t.text]
+		if prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		// 幂运算是右结合的，其余运算符左结合
+		nextMin := prec + 1
+		if t.text == "^" {
+			nextMin = prec
+		}
+		right, err := p.parseExpr(nextMin)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.text, left: left, right: right}
+	}
+}
+
+// isPostfixPercent 判断当前的 % 是否为后缀百分号而不是取模运算符。
+func (p *exprParser) isPostfixPercent() bool {
+	switch p.tokens[p.pos+1].kind {
+	case tokEOF, tokRParen, tokComma, tokOperator:
+		return true
+	}
+	return false
+}
+
+// parseUnary 解析一元负号/正号。
+func (p *exprParser) parseUnary() (Node, error) {
+	t := p.peek()
+	if t.kind == tokOperator && (t.text == "-" || t.text == "+") {
+		p.next()
+		operand, err := p.parseExpr(unaryPrecedence)
+		if err != nil {
+			return nil, err
+		}
+		if t.text == "+" {
+			return operand, nil
+		}
+		return &unaryNode{op: "-", operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary 解析数字、常量、函数调用和括号表达式。
+func (p *exprParser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &numberNode{value: t.value, exact: t.exact}, nil
+	case tokLParen:
+		inner, err := p.parseExpr(1)
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		return inner, nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(t.text)
+		}
+		if v, ok := exprConstants[t.text]; ok {
+			return &constNode{name: t.text, value: v}, nil
+		}
+		return nil, fmt.Errorf("未知的标识符: %s", t.text)
+	case tokEOF:
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	return nil, fmt.Errorf("位置 %d 处出现意外的 '%s'", t.pos+1, t.text)
+}
+
+// parseCall 解析函数调用的参数列表。
+func (p *exprParser) parseCall(name string) (Node, error) {
+	if _, ok := exprFunctions[name]; !ok {
+		return nil, fmt.Errorf("未知的函数: %s", name)
+	}
+	p.next() // 跳过 '('
+	call := &callNode{name: name}
+	if p.peek().kind == tokRParen {
+		p.next()
+		return call, nil
+	}
+	for {
+		arg, err := p.parseExpr(1)
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		switch p.next().kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return call, nil
+		default:
+			return nil, fmt.Errorf("函数 %s 的参数列表缺少右括号", name)
+		}
+	}
+}
+
+// ParseExpression 将算术表达式字符串解析为 AST。
+// 支持 + - * / % ^、括号、一元负号、后缀百分号以及 exprFunctions 中的函数。
+func ParseExpression(s string, nf *format.Formatter) (Node, error) {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "="))
+	tokens, err := tokenize(s, nf)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens}
+	node, err := p.parseExpr(1)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("位置 %d 处出现意外的 '%s'", t.pos+1, t.text)
+	}
+	return node, nil
+}
+
+// isTrivialExpression 判断 AST 是否只是一个孤立的数字，这类输入不应被视为计算。
+func isTrivialExpression(n Node) bool {
+	_, ok := n.(*numberNode)
+	return ok
+}
@@ -0,0 +1,75 @@
+// calculate-anything/pkg/parser/expression_test.go
+package parser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"2^3^2", 512},
+		{"-2^2", -4},
+		{"10 % 4", 2},
+		{"50 + 10%", 50.1},
+		{"sqrt(16) + abs(-2)", 6},
+		{"2 * pi", 2 * math.Pi},
+	}
+	for _, tt := range tests {
+		n, err := ParseExpression(tt.expr, nil)
+		if err != nil {
+			t.Errorf("ParseExpression(%q): %v", tt.expr, err)
+			continue
+		}
+		got, err := n.Eval()
+		if err != nil || math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%q = %g (%v), 期望 %g", tt.expr, got, err, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"1 / 0", "1 % 0"} {
+		n, err := ParseExpression(expr, nil)
+		if err != nil {
+			continue
+		}
+		if _, err := n.Eval(); err == nil {
+			t.Errorf("%q: 期望计算失败", expr)
+		}
+	}
+}
+
+func TestEvalExact(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0.1 + 0.2", "0.3"},
+		{"1.1 * 3", "3.3"},
+		{"2^100", "1267650600228229401496703205376"},
+		{"1 / 4", "0.25"},
+	}
+	for _, tt := range tests {
+		n, err := ParseExpression(tt.expr, nil)
+		if err != nil {
+			t.Errorf("ParseExpression(%q): %v", tt.expr, err)
+			continue
+		}
+		d, ok := EvalExact(n)
+		if !ok || d.String() != tt.want {
+			t.Errorf("EvalExact(%q) = %s (%v), 期望 %s", tt.expr, d, ok, tt.want)
+		}
+	}
+
+	// 超越函数和超出 decimal.MaxDigits 位的结果没有精确结果
+	for _, expr := range []string{"sqrt(2)", "10^-400", "10^400", "0.5^1000", "round(1.5, -999999999)"} {
+		n, _ := ParseExpression(expr, nil)
+		if d, ok := EvalExact(n); ok {
+			t.Errorf("EvalExact(%q) = %s, 不应有精确结果", expr, d)
+		}
+	}
+}
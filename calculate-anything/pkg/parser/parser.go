@@ -3,55 +3,168 @@ package parser
 
 import (
 	// 修正：现在 i18n 包被正确使用了
+	"calculate-anything/pkg/decimal"
+	"calculate-anything/pkg/format"
 	"calculate-anything/pkg/i18n"
 	"calculate-anything/pkg/keywords"
 	"regexp"
-	"strconv"
 	"strings"
+	"time"
 )
 
 // 正则表达式集合
 var (
-	simpleConversionRegex = regexp.MustCompile(`^([\d.,]+)\s*([a-zA-Zμ°$€¥£\d]+)\s*([a-zA-Zμ°$€¥£\d]+)$`)
+	// 单位部分允许复合单位表达式, e.g. "km/h", "kWh/100km", "N·m", "m/s^2"。
+	// 两个单位之间必须有空白，否则 "100 usd" 会被拆成 "us" 和 "d"
+	simpleConversionRegex = regexp.MustCompile(`^([\d.,]+)\s*([a-zA-Zμµ°$€¥£][a-zA-Zμµ°$€¥£\d/·*^²³]*)\s+([a-zA-Zμµ°$€¥£][a-zA-Zμµ°$€¥£\d/·*^²³]*)$`)
 	percentageRegex     = regexp.MustCompile(`(?i)^([\d.,]+)\s*([+\-]|plus|minus)\s*([\d.,]+)%$`)
 	percentageOfRegex   = regexp.MustCompile(`(?i)^([\d.,]+)%\s*of\s*([\d.,]+)$`)
 	percentageAsOfRegex = regexp.MustCompile(`(?i)^([\d.,]+)\s*(?:as a|is what)?\s*% of\s*([\d.,]+)$`)
 	pxEmRemRegex        = regexp.MustCompile(`(?i)^([\d.,]+)\s*(px|em|rem|pt)(?:\s*(?:to|in)\s*(px|em|rem|pt))?$`)
+	// 数量开头的查询, e.g. "100 usd to eur,gbp" -> "100", "usd to eur,gbp"
+	leadingAmountRegex = regexp.MustCompile(`^\s*([\d.,]*\d[\d.,]*)\s*(\S.*)$`)
+	unitWordRegex      = regexp.MustCompile(`^[a-zA-Zμµ°$€¥£][a-zA-Zμµ°$€¥£\d/·*^²³]*$`)
+	// 查询末尾的日期子句, e.g. "100 usd to eur on 2024-03-01"
+	onDateRegex = regexp.MustCompile(`(?i)\s+on\s+(\d{4}-\d{2}-\d{2})\s*$`)
+	// 微符号 µ (U+00B5) 与希腊字母 μ (U+03BC) 外观相同，单位表中只使用后者
+	microSignReplacer = strings.NewReplacer("µ", "μ")
 )
 
-// Parse 是主解析函数，它接收原始查询和加载的语言包，返回一个结构化的 ParsedQuery。
-// 修正：修复了 i1A 的拼写错误
-func Parse(query string, langPack *i18n.LanguagePack) *ParsedQuery {
-	if p := parseFixedStructureQueries(query); p != nil {
+// 百分比计算的扩展形式
+var (
+	// "% change from 80 to 100", "change from 80 to 100"
+	percentChangeRegex = regexp.MustCompile(`(?i)^(?:%|percent(?:age)?)?\s*change\s+from\s+([\d.,]+)\s+to\s+([\d.,]+)$`)
+	// "what is 120 after 20% off then +5%", 各步之间用 then、and 或 ", " 分隔
+	percentChainRegex = regexp.MustCompile(`(?i)^(?:what\s+is\s+)?([\d.,]+)\s+after\s+(.+?)\??$`)
+	percentStepRegex  = regexp.MustCompile(`(?i)^([+\-]|plus|minus)?\s*([\d.,]+)\s*%\s*(off|discount|less|down|decrease|more|up|increase)?$`)
+	percentStepSplit  = regexp.MustCompile(`(?i)\s*(?:,\s|\bthen\b|\band\b)\s*`)
+	// "30 is 15% of what"
+	percentOfWhatRegex = regexp.MustCompile(`(?i)^([\d.,]+)\s+is\s+([\d.,]+)\s*%\s+of\s+what\??$`)
+	// "1000 at 5% for 10 years"
+	compoundInterestRegex = regexp.MustCompile(`(?i)^([\d.,]+)\s+at\s+([\d.,]+)\s*%\s+(?:for|over)\s+([\d.,]+)\s*(?:years?|yrs?|y)$`)
+	// "cagr from 1000 to 2000 in 5 years"
+	cagrRegex = regexp.MustCompile(`(?i)^cagr\s+(?:from\s+)?([\d.,]+)\s+to\s+([\d.,]+)\s+(?:in|over)\s+([\d.,]+)\s*(?:years?|yrs?|y)$`)
+	// "80 with 25% markup", "80 + 25% margin"
+	markupMarginRegex = regexp.MustCompile(`(?i)^([\d.,]+)\s*(?:with|at|\+)\s*([\d.,]+)\s*%\s+(markup|margin)$`)
+	// "cost 80 price 100"
+	costPriceRegex = regexp.MustCompile(`(?i)^cost\s+([\d.,]+)\s*,?\s*(?:price|sell(?:ing)?(?:\s+price)?|revenue)\s+([\d.,]+)$`)
+)
+
+// 各计算器在自己的 Match 方法中调用下面的解析函数，按需组合使用。
+// nf 决定数字的小数点和分组符号，为 nil 时使用 "." 作为小数点。
+
+// ParseFixed 解析结构固定的查询（百分比、px/em/rem），不匹配时返回 nil。
+func ParseFixed(query string, nf *format.Formatter) *ParsedQuery {
+	return parseFixedStructureQueries(query, nf)
+}
+
+// ParseConversion 解析 "数量 源单位 [to] 目标单位" 形式的转换查询，以及复合数量查询。
+// 返回的查询类型总是 UnitQuery，由各计算器根据单位进一步判断是否属于自己。
+func ParseConversion(query string, langPack *i18n.LanguagePack, nf *format.Formatter) *ParsedQuery {
+	// 复合数量需要在停用词处理之前解析，因为 "in" 既可能是连接词也可能是英寸
+	if p := parseCompoundQuantities(query, langPack, nf); p != nil {
 		return p
 	}
 
-	processedQuery := keywords.PreprocessQuery(query, langPack)
+	processedQuery := microSignReplacer.Replace(keywords.PreprocessQuery(query, langPack))
 
 	matches := simpleConversionRegex.FindStringSubmatch(processedQuery)
-	if len(matches) == 4 {
-		return &ParsedQuery{
-			Type:   UnitQuery,
-			Input:  query,
-			Amount: parseAmount(matches[1]),
-			From:   matches[2],
-			To:     matches[3],
+	if len(matches) != 4 {
+		return nil
+	}
+	amount, err := parseAmount(matches[1], nf)
+	if err != nil {
+		// "1.000.000 km" 在以 "." 为小数点的配置下不是有效的数字，不能当作 0
+		return nil
+	}
+	return &ParsedQuery{
+		Type:   UnitQuery,
+		Input:  query,
+		Amount: amount,
+		Exact:  parseExact(matches[1], nf),
+		From:   matches[2],
+		To:     matches[3],
+	}
+}
+
+// ParseConversionTargets 解析目标可省略或有多个的转换查询,
+// e.g. "100 usd" (无目标), "100 usd to eur", "100 usd to eur,gbp,jpy"。
+// 目标列表保存在 ToUnits 中，To 为第一个目标。
+// 查询末尾可以带 "on YYYY-MM-DD" 日期子句，原文保存在 DateText 中，解析结果保存在 Date 中；
+// 日期无效 (e.g. "2024-02-30") 时 Date 为零值，由计算器报告错误。
+func ParseConversionTargets(query string, langPack *i18n.LanguagePack, nf *format.Formatter) *ParsedQuery {
+	var date time.Time
+	dateText, body := "", query
+	if m := onDateRegex.FindStringSubmatchIndex(query); m != nil {
+		dateText, body = query[m[2]:m[3]], query[:m[0]]
+		if d, err := time.Parse("2006-01-02", dateText); err == nil {
+			date = d
 		}
 	}
 
-	return &ParsedQuery{Type: UnknownQuery, Input: query}
+	matches := leadingAmountRegex.FindStringSubmatch(body)
+	if len(matches) != 3 {
+		return nil
+	}
+	// 数量之后的逗号只可能是目标之间的分隔符
+	rest := strings.ReplaceAll(matches[2], ",", " ")
+	words := strings.Fields(microSignReplacer.Replace(keywords.PreprocessQuery(rest, langPack)))
+	if len(words) == 0 {
+		return nil
+	}
+	for _, word := range words {
+		if !unitWordRegex.MatchString(word) {
+			return nil
+		}
+	}
+
+	amount, err := parseAmount(matches[1], nf)
+	if err != nil {
+		return nil
+	}
+	p := &ParsedQuery{
+		Type:     UnitQuery,
+		Input:    query,
+		Amount:   amount,
+		Exact:    parseExact(matches[1], nf),
+		From:     words[0],
+		ToUnits:  words[1:],
+		Date:     date,
+		DateText: dateText,
+	}
+	if len(p.ToUnits) > 0 {
+		p.To = p.ToUnits[0]
+	}
+	return p
+}
+
+// ParseArithmetic 将查询作为算术表达式解析，孤立的数字不算作表达式。
+func ParseArithmetic(query string, nf *format.Formatter) *ParsedQuery {
+	if node, err := ParseExpression(query, nf); err == nil && !isTrivialExpression(node) {
+		return &ParsedQuery{Type: ExpressionQuery, Input: query, Expr: node}
+	}
+	return nil
 }
 
-// parseFixedStructureQueries 专门处理结构固定的查询。
-func parseFixedStructureQueries(q string) *ParsedQuery {
+// parseFixedStructureQueries 专门处理结构固定的查询，其中的数字无效时返回 nil。
+func parseFixedStructureQueries(q string, nf *format.Formatter) *ParsedQuery {
+	num := &amountParser{nf: nf}
+	if p := matchFixedStructure(q, num); p != nil && num.err == nil {
+		return p
+	}
+	return nil
+}
+
+// matchFixedStructure 按顺序尝试各个固定结构，数字由 num 解析。
+func matchFixedStructure(q string, num *amountParser) *ParsedQuery {
 	matches := percentageRegex.FindStringSubmatch(q)
 	if len(matches) == 4 {
 		return &ParsedQuery{
 			Type:      PercentageQuery,
 			Input:     q,
-			BaseValue: parseAmount(matches[1]),
+			BaseValue: num.amount(matches[1]),
 			Action:    normalizeAction(matches[2]),
-			Percent:   parseAmount(matches[3]),
+			Percent:   num.amount(matches[3]),
 		}
 	}
 	matches = percentageOfRegex.FindStringSubmatch(q)
@@ -60,8 +173,8 @@ func parseFixedStructureQueries(q string) *ParsedQuery {
 			Type:      PercentageQuery,
 			Input:     q,
 			Action:    "of",
-			Percent:   parseAmount(matches[1]),
-			BaseValue: parseAmount(matches[2]),
+			Percent:   num.amount(matches[1]),
+			BaseValue: num.amount(matches[2]),
 		}
 	}
 	matches = percentageAsOfRegex.FindStringSubmatch(q)
@@ -70,11 +183,15 @@ func parseFixedStructureQueries(q string) *ParsedQuery {
 			Type:      PercentageQuery,
 			Input:     q,
 			Action:    "as % of",
-			Amount:    parseAmount(matches[1]),
-			BaseValue: parseAmount(matches[2]),
+			Amount:    num.amount(matches[1]),
+			BaseValue: num.amount(matches[2]),
 		}
 	}
 
+	if p := parsePercentageExtras(q, num); p != nil {
+		return p
+	}
+
 	matches = pxEmRemRegex.FindStringSubmatch(q)
 	if len(matches) > 0 {
 		toUnit := ""
@@ -84,7 +201,7 @@ func parseFixedStructureQueries(q string) *ParsedQuery {
 		return &ParsedQuery{
 			Type:   PxEmRemQuery,
 			Input:  q,
-			Amount: parseAmount(matches[1]),
+			Amount: num.amount(matches[1]),
 			From:   matches[2],
 			To:     toUnit,
 		}
@@ -93,13 +210,81 @@ func parseFixedStructureQueries(q string) *ParsedQuery {
 	return nil
 }
 
-// parseAmount 清理数字字符串并将其转换为 float64。
-func parseAmount(s string) float64 {
-	s = strings.ReplaceAll(s, ",", "")
-	f, _ := strconv.ParseFloat(s, 64)
+// parsePercentageExtras 解析变化率、连续变化、反推基数、复利、CAGR 以及加价率和毛利率查询。
+func parsePercentageExtras(q string, num *amountParser) *ParsedQuery {
+	if m := percentChangeRegex.FindStringSubmatch(q); m != nil {
+		return &ParsedQuery{Type: PercentageQuery, Input: q, Action: "change", BaseValue: num.amount(m[1]), Amount: num.amount(m[2])}
+	}
+	if m := percentChainRegex.FindStringSubmatch(q); m != nil {
+		var steps []float64
+		for _, step := range percentStepSplit.Split(strings.TrimSpace(m[2]), -1) {
+			s := percentStepRegex.FindStringSubmatch(step)
+			if s == nil {
+				return nil
+			}
+			percent := num.amount(s[2])
+			switch strings.ToLower(s[3]) {
+			case "off", "discount", "less", "down", "decrease":
+				percent = -percent
+			case "":
+				if normalizeAction(s[1]) == "-" {
+					percent = -percent
+				}
+			}
+			steps = append(steps, percent)
+		}
+		return &ParsedQuery{Type: PercentageQuery, Input: q, Action: "chain", BaseValue: num.amount(m[1]), Steps: steps}
+	}
+	if m := percentOfWhatRegex.FindStringSubmatch(q); m != nil {
+		return &ParsedQuery{Type: PercentageQuery, Input: q, Action: "of what", Amount: num.amount(m[1]), Percent: num.amount(m[2])}
+	}
+	if m := compoundInterestRegex.FindStringSubmatch(q); m != nil {
+		return &ParsedQuery{Type: PercentageQuery, Input: q, Action: "compound", BaseValue: num.amount(m[1]), Percent: num.amount(m[2]), Periods: num.amount(m[3])}
+	}
+	if m := cagrRegex.FindStringSubmatch(q); m != nil {
+		return &ParsedQuery{Type: PercentageQuery, Input: q, Action: "cagr", BaseValue: num.amount(m[1]), Amount: num.amount(m[2]), Periods: num.amount(m[3])}
+	}
+	if m := markupMarginRegex.FindStringSubmatch(q); m != nil {
+		return &ParsedQuery{Type: PercentageQuery, Input: q, Action: strings.ToLower(m[3]), BaseValue: num.amount(m[1]), Percent: num.amount(m[2])}
+	}
+	if m := costPriceRegex.FindStringSubmatch(q); m != nil {
+		return &ParsedQuery{Type: PercentageQuery, Input: q, Action: "cost price", BaseValue: num.amount(m[1]), Amount: num.amount(m[2])}
+	}
+	return nil
+}
+
+// parseAmount 按用户配置的小数点将数字字符串转换为 float64。
+func parseAmount(s string, nf *format.Formatter) (float64, error) {
+	return nf.Parse(s)
+}
+
+// amountParser 依次解析一个查询中的多个数字，并记住遇到的第一个错误，
+// 调用方在组装完 ParsedQuery 后检查 err 即可。
+type amountParser struct {
+	nf  *format.Formatter
+	err error
+}
+
+// amount 解析一个数字，无效时返回 0 并记录错误。
+func (a *amountParser) amount(s string) float64 {
+	f, err := parseAmount(s, a.nf)
+	if err != nil && a.err == nil {
+		a.err = err
+	}
 	return f
 }
 
+// parseExact 与 parseAmount 相同，但返回精确的十进制值。
+// 位数超出 decimal.MaxDigits 时使用 float64 的值。
+func parseExact(s string, nf *format.Formatter) decimal.Decimal {
+	d, err := nf.ParseDecimal(s)
+	if err != nil {
+		f, _ := nf.Parse(s)
+		return decimal.NewFromFloat(f)
+	}
+	return d
+}
+
 // normalizeAction 将词语统一转换成符号。
 func normalizeAction(action string) string {
 	action = strings.ToLower(action)
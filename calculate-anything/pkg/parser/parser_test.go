@@ -0,0 +1,128 @@
+// calculate-anything/pkg/parser/parser_test.go
+package parser
+
+import (
+	"calculate-anything/pkg/format"
+	"calculate-anything/pkg/i18n"
+	"reflect"
+	"testing"
+)
+
+// testPack 是 en_US 语言包的一个子集。
+var testPack = &i18n.LanguagePack{
+	Keywords:  map[string]string{"euros": "eur", "dollars": "usd", "meters": "m", "feet": "ft"},
+	StopWords: []string{"to", "in", "as", "a", "=", "equals", "is", "what"},
+}
+
+func TestParseConversion(t *testing.T) {
+	tests := []struct {
+		query    string
+		decimal  string
+		amount   float64
+		from, to string
+	}{
+		{"10 km to mi", "dot", 10, "km", "mi"},
+		{"10km in mi", "dot", 10, "km", "mi"},
+		{"100 euros to dollars", "dot", 100, "eur", "usd"},
+		{"1,000.5 m to ft", "dot", 1000.5, "m", "ft"},
+		{"1.000,5 m to ft", "comma", 1000.5, "m", "ft"},
+		{"1 mW to W", "dot", 1, "mW", "W"},
+		{"1 µm to nm", "dot", 1, "μm", "nm"},
+		{"5 in to cm", "dot", 5, "in", "cm"},
+		{"5 cm to in", "dot", 5, "cm", "in"},
+	}
+	for _, tt := range tests {
+		p := ParseConversion(tt.query, testPack, format.New(tt.decimal, "comma_dot", ""))
+		if p == nil {
+			t.Errorf("ParseConversion(%q) = nil", tt.query)
+			continue
+		}
+		if p.Amount != tt.amount || p.From != tt.from || p.To != tt.to {
+			t.Errorf("ParseConversion(%q) = %g %s -> %s, 期望 %g %s -> %s", tt.query, p.Amount, p.From, p.To, tt.amount, tt.from, tt.to)
+		}
+	}
+
+	// 无效的数字不能被当作 0
+	for _, q := range []string{"1.000.000 km to m", "1..5 km to m", "hello world"} {
+		if p := ParseConversion(q, testPack, nil); p != nil {
+			t.Errorf("ParseConversion(%q) = %+v, 期望 nil", q, p)
+		}
+	}
+}
+
+func TestParseConversionTargets(t *testing.T) {
+	tests := []struct {
+		query   string
+		from    string
+		targets []string
+	}{
+		{"100 usd", "usd", nil},
+		{"100 usd to eur,gbp,jpy", "usd", []string{"eur", "gbp", "jpy"}},
+		{"100 usd in eur, gbp", "usd", []string{"eur", "gbp"}},
+		{"12 in", "in", nil},
+		{"5 in to cm", "in", []string{"cm"}},
+		{"5 cm in in", "cm", []string{"in"}},
+	}
+	for _, tt := range tests {
+		p := ParseConversionTargets(tt.query, testPack, nil)
+		if p == nil {
+			t.Errorf("ParseConversionTargets(%q) = nil", tt.query)
+			continue
+		}
+		if p.From != tt.from || len(p.ToUnits) != len(tt.targets) || len(tt.targets) > 0 && !reflect.DeepEqual(p.ToUnits, tt.targets) {
+			t.Errorf("ParseConversionTargets(%q) = %s -> %q, 期望 %s -> %q", tt.query, p.From, p.ToUnits, tt.from, tt.targets)
+		}
+	}
+
+	p := ParseConversionTargets("100 usd to eur on 2024-03-01", testPack, nil)
+	if p == nil || p.Date.Format("2006-01-02") != "2024-03-01" || p.To != "eur" {
+		t.Errorf("ParseConversionTargets 日期子句解析错误: %+v", p)
+	}
+	// 无效的日期保留原文，由计算器报告错误
+	p = ParseConversionTargets("100 usd to eur on 2024-02-30", testPack, nil)
+	if p == nil || !p.Date.IsZero() || p.DateText != "2024-02-30" || p.To != "eur" {
+		t.Errorf("ParseConversionTargets 无效日期解析错误: %+v", p)
+	}
+	if p := ParseConversionTargets("1.000.000 km", testPack, nil); p != nil {
+		t.Errorf("ParseConversionTargets(\"1.000.000 km\") = %+v, 期望 nil", p)
+	}
+}
+
+func TestParseFixed(t *testing.T) {
+	tests := []struct {
+		query   string
+		action  string
+		base    float64
+		percent float64
+	}{
+		{"120 + 15%", "+", 120, 15},
+		{"120 minus 15%", "-", 120, 15},
+		{"15% of 50", "of", 50, 15},
+		{"80 with 25% markup", "markup", 80, 25},
+	}
+	for _, tt := range tests {
+		p := ParseFixed(tt.query, nil)
+		if p == nil || p.Type != PercentageQuery {
+			t.Errorf("ParseFixed(%q) = %+v", tt.query, p)
+			continue
+		}
+		if p.Action != tt.action || p.BaseValue != tt.base || p.Percent != tt.percent {
+			t.Errorf("ParseFixed(%q) = %s %g %g%%, 期望 %s %g %g%%", tt.query, p.Action, p.BaseValue, p.Percent, tt.action, tt.base, tt.percent)
+		}
+	}
+	for _, q := range []string{"1.000.000 + 5%", "5% of 1.2.3", "10 km"} {
+		if p := ParseFixed(q, nil); p != nil {
+			t.Errorf("ParseFixed(%q) = %+v, 期望 nil", q, p)
+		}
+	}
+}
+
+func TestParseCompoundQuantities(t *testing.T) {
+	p := ParseConversion("5ft 3in to cm", testPack, nil)
+	if p == nil || len(p.Quantities) != 2 || p.To != "cm" {
+		t.Fatalf("ParseConversion(\"5ft 3in to cm\") = %+v", p)
+	}
+	if q := p.Quantities[1]; q.Amount != 3 || q.Unit != "in" {
+		t.Errorf("第二个数量为 %g %s, 期望 3 in", q.Amount, q.Unit)
+	}
+}
@@ -1,22 +1,37 @@
 // calculate-anything/pkg/parser/types.go
 package parser
 
-// QueryType 是一个枚举类型，用于定义解析器识别出的查询类型。
-type QueryType int
+import (
+	"calculate-anything/pkg/decimal"
+	"time"
+)
+
+// QueryType 标识查询的类型。它是字符串而不是枚举，
+// 因此新的计算器可以在自己的包中定义类型常量，无需修改这里。
+type QueryType string
 
-// 定义所有可能的查询类型常量
+// 内置计算器使用的查询类型常量
 const (
-	UnknownQuery     QueryType = iota // 未知或无法解析的查询
-	CurrencyQuery                     // 货币转换查询
-	CryptoQuery                       // 加密货币转换查询
-	UnitQuery                         // 物理单位转换查询
-	DataStorageQuery                  // 数据存储单位转换查询
-	PercentageQuery                   // 百分比计算查询
-	PxEmRemQuery                      // Web 开发单位转换查询
-	TimeQuery                         // 时间计算查询
-	VATQuery                          // 增值税计算查询
+	UnknownQuery     QueryType = "unknown"     // 未知或无法解析的查询
+	CurrencyQuery    QueryType = "currency"    // 货币转换查询
+	CryptoQuery      QueryType = "crypto"      // 加密货币转换查询
+	UnitQuery        QueryType = "unit"        // 物理单位转换查询
+	DataStorageQuery QueryType = "datastorage" // 数据存储单位转换查询
+	PercentageQuery  QueryType = "percentage"  // 百分比计算查询
+	PxEmRemQuery     QueryType = "pxemrem"     // Web 开发单位转换查询
+	TimeQuery        QueryType = "time"        // 时间计算查询
+	VATQuery         QueryType = "vat"         // 增值税计算查询
+	ColorQuery       QueryType = "color"       // 颜色转换查询
+	ExpressionQuery  QueryType = "expression"  // 算术表达式查询
 )
 
+// Quantity 是一个带单位的数量，用于复合数量查询 (e.g., "5ft" in "5ft 3in to cm")。
+type Quantity struct {
+	Amount float64         // 数值，减法项为负数
+	Exact  decimal.Decimal // Amount 的精确十进制值
+	Unit   string          // 单位符号
+}
+
 // ParsedQuery 是解析自然语言查询后的结构化结果。
 // 它是解析器和计算器之间传递数据的核心数据结构。
 type ParsedQuery struct {
@@ -28,4 +43,23 @@ type ParsedQuery struct {
 	Action    string    // 附加的动作，主要用于百分比计算 (e.g., "+", "-", "of")
 	Percent   float64   // 百分比计算中的百分比值 (e.g., 15 in "120 + 15%")
 	BaseValue float64   // 百分比计算中的基础值 (e.g., 120 in "120 + 15%")
+	Expr      Node      // 算术表达式的语法树 (e.g., "(12.5 * 4) / 3 + 2^8")
+	Periods   float64   // 复利和 CAGR 计算的年数 (e.g., 10 in "1000 at 5% for 10 years")
+	Steps     []float64 // 连续的百分比变化，减少为负数 (e.g., [-20, 5] in "120 after 20% off then +5%")
+
+	Quantities []Quantity // 复合/求和数量 (e.g., "5ft 3in" -> [{5 ft} {3 in}])
+	ToUnits    []string   // 复合目标单位或多个目标货币 (e.g., "ft in" in "1.6 m to ft in", "eur,gbp" in "100 usd to eur,gbp")
+	Date       time.Time  // 历史汇率的日期，零值表示最新汇率 (e.g., "on 2024-03-01")
+	DateText   string     // 日期子句中的日期原文；日期无效时 Date 为零值 (e.g., "2024-02-30")
+
+	Exact decimal.Decimal // Amount 的精确十进制值，货币、加密货币和数据存储的换算用它避免浮点误差
+}
+
+// ExactAmount 返回 Amount 的精确十进制值。计算器自行构造的查询可能没有设置 Exact，
+// 这时由 Amount 转换得到。
+func (p *ParsedQuery) ExactAmount() decimal.Decimal {
+	if p.Exact.IsZero() && p.Amount != 0 {
+		return decimal.NewFromFloat(p.Amount)
+	}
+	return p.Exact
 }